@@ -0,0 +1,39 @@
+// Command auditverify recomputes the hash chain of the audit log and reports the
+// first entry that was changed, removed or inserted out of band.
+//
+//	auditverify
+//
+// It reads the database settings from the environment or .env like the server
+// and exits with status 1 when the chain is broken.
+package main
+
+import (
+	"auth/internal/core"
+	"auth/internal/repositories"
+	"auth/pkg/customError"
+	"errors"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	"log"
+	"os"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Print("No .env file found")
+	}
+
+	db := repositories.AccessDataBase()
+	defer db.Close()
+
+	checked, err := core.NewAuditLog(repositories.NewAuditRepository(db)).Verify()
+	if errors.Is(err, customError.AuditChainBrokenError) {
+		log.Printf("%v (%d entries before it are intact)", err, checked)
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Audit log is intact, %d entries checked", checked)
+}
@@ -0,0 +1,82 @@
+// Command pwnedfilter builds a compact Bloom filter from a HaveIBeenPwned SHA-1
+// password list, to be used as BREACHED_PASSWORDS_FILE instead of the full list.
+//
+//	pwnedfilter -in pwned-passwords-sha1-ordered-by-hash.txt -out pwned.filter -min-count 10
+package main
+
+import (
+	"auth/pkg/pwned"
+	"bufio"
+	"flag"
+	"log"
+	"os"
+)
+
+func main() {
+	in := flag.String("in", "", "HaveIBeenPwned SHA-1 list in HASH:COUNT format")
+	out := flag.String("out", "pwned.filter", "filter file to write")
+	minCount := flag.Int("min-count", 1, "only keep hashes seen at least this many times")
+	falsePositiveRate := flag.Float64("fp-rate", 0.001, "false positive rate of the filter")
+	flag.Parse()
+
+	if *in == "" || *minCount < 1 || *falsePositiveRate <= 0 || *falsePositiveRate >= 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var n uint64
+	err := eachHash(*in, *minCount, func(hash [20]byte) { n++ })
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	filter := pwned.NewFilter(n, *falsePositiveRate, *minCount)
+
+	err = eachHash(*in, *minCount, filter.Add)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	writer := bufio.NewWriter(file)
+
+	size, err := filter.WriteTo(writer)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Wrote %d hashes seen at least %d times to %s, %d bytes", n, *minCount, *out, size)
+}
+
+// eachHash calls add for every hash of the list seen at least minCount times.
+func eachHash(path string, minCount int, add func(hash [20]byte)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		hash, count, err := pwned.ParseLine(scanner.Bytes())
+		if err != nil {
+			log.Printf("Skipping line %d: %v", line, err)
+			continue
+		}
+		if count >= minCount {
+			add(hash)
+		}
+	}
+
+	return scanner.Err()
+}
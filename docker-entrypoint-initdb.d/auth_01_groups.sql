@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS user_group (
+    group_id   uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_name varchar(64) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS group_profile (
+    group_id   uuid NOT NULL REFERENCES user_group (group_id) ON DELETE CASCADE,
+    profile_id uuid NOT NULL REFERENCES profile (profile_id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, profile_id)
+);
+
+CREATE INDEX IF NOT EXISTS group_profile_profile_id_idx ON group_profile (profile_id);
+
+CREATE TABLE IF NOT EXISTS group_role (
+    group_id uuid NOT NULL REFERENCES user_group (group_id) ON DELETE CASCADE,
+    role_id  uuid NOT NULL REFERENCES role (role_id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, role_id)
+);
@@ -0,0 +1,8 @@
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS profile_created_at timestamptz NOT NULL DEFAULT now();
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS profile_status varchar(16) NOT NULL DEFAULT 'active';
+
+CREATE INDEX IF NOT EXISTS profile_login_pattern_idx ON profile (profile_login text_pattern_ops, profile_id);
+CREATE INDEX IF NOT EXISTS profile_created_at_idx ON profile (profile_created_at, profile_id);
+CREATE INDEX IF NOT EXISTS profile_status_idx ON profile (profile_status, profile_id);
+CREATE INDEX IF NOT EXISTS profile_role_role_id_idx ON profile_role (role_id);
+CREATE INDEX IF NOT EXISTS group_role_role_id_idx ON group_role (role_id);
@@ -0,0 +1,2 @@
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS profile_status_reason text NOT NULL DEFAULT '';
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS profile_status_changed_at timestamptz;
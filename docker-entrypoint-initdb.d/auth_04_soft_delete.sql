@@ -0,0 +1,3 @@
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS profile_deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS profile_deleted_at_idx ON profile (profile_deleted_at) WHERE profile_deleted_at IS NOT NULL;
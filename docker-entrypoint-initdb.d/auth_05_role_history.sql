@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS role_history (
+    history_id     bigserial PRIMARY KEY,
+    profile_id     uuid NOT NULL REFERENCES profile (profile_id) ON DELETE CASCADE,
+    role_name      varchar(64) NOT NULL,
+    history_action varchar(16) NOT NULL,
+    history_at     timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS role_history_profile_id_idx ON role_history (profile_id, history_at);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS login_attempt (
+    attempt_key           varchar(320) PRIMARY KEY,
+    attempt_failures      integer     NOT NULL DEFAULT 0,
+    attempt_last_failure  timestamptz NOT NULL,
+    attempt_blocked_until timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS login_attempt_last_failure_idx ON login_attempt (attempt_last_failure);
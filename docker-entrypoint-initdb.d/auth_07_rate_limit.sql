@@ -0,0 +1,8 @@
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit (
+    limit_key        varchar(320) PRIMARY KEY,
+    limit_tokens     double precision NOT NULL,
+    limit_allowed    boolean          NOT NULL,
+    limit_updated_at timestamptz      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_updated_at_idx ON rate_limit (limit_updated_at);
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    audit_id         bigserial PRIMARY KEY,
+    audit_at         timestamptz NOT NULL,
+    audit_actor      text NOT NULL DEFAULT '',
+    audit_target     text NOT NULL DEFAULT '',
+    audit_action     varchar(64) NOT NULL,
+    audit_ip         varchar(64) NOT NULL DEFAULT '',
+    audit_user_agent text NOT NULL DEFAULT '',
+    audit_request_id varchar(64) NOT NULL DEFAULT '',
+    audit_details    jsonb,
+    audit_prev_hash  varchar(64) NOT NULL,
+    audit_hash       varchar(64) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (audit_actor, audit_id);
+CREATE INDEX IF NOT EXISTS audit_log_target_idx ON audit_log (audit_target, audit_id);
+CREATE INDEX IF NOT EXISTS audit_log_action_idx ON audit_log (audit_action, audit_id);
+CREATE INDEX IF NOT EXISTS audit_log_request_id_idx ON audit_log (audit_request_id);
+
+-- Entries can only be appended. Tampering through SQL has to drop these triggers
+-- first, and anything changed behind them is caught by verifying the hash chain.
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_no_change ON audit_log;
+CREATE TRIGGER audit_log_no_change BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS session (
+    session_id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    profile_id         uuid NOT NULL REFERENCES profile (profile_id) ON DELETE CASCADE,
+    session_ip         varchar(64) NOT NULL,
+    session_user_agent text NOT NULL DEFAULT '',
+    session_device     varchar(128) NOT NULL DEFAULT '',
+    session_created_at timestamptz NOT NULL DEFAULT now(),
+    session_expires_at timestamptz NOT NULL,
+    session_revoked_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS session_profile_id_idx ON session (profile_id, session_created_at);
+CREATE INDEX IF NOT EXISTS session_expires_at_idx ON session (session_expires_at);
@@ -0,0 +1,6 @@
+ALTER TABLE session ADD COLUMN IF NOT EXISTS session_country     varchar(2) NOT NULL DEFAULT '';
+ALTER TABLE session ADD COLUMN IF NOT EXISTS session_city        text NOT NULL DEFAULT '';
+ALTER TABLE session ADD COLUMN IF NOT EXISTS session_latitude    double precision;
+ALTER TABLE session ADD COLUMN IF NOT EXISTS session_longitude   double precision;
+ALTER TABLE session ADD COLUMN IF NOT EXISTS session_risk_score  smallint NOT NULL DEFAULT 0;
+ALTER TABLE session ADD COLUMN IF NOT EXISTS session_risk_action varchar(16) NOT NULL DEFAULT 'allow';
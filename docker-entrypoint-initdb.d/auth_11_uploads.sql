@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS upload (
+    upload_id           varchar(64) PRIMARY KEY,
+    profile_id          uuid NOT NULL REFERENCES profile (profile_id) ON DELETE CASCADE,
+    upload_file_name    text NOT NULL,
+    upload_content_type varchar(255) NOT NULL,
+    upload_metadata     text NOT NULL DEFAULT '',
+    upload_multipart_id text NOT NULL,
+    upload_length       bigint NOT NULL,
+    upload_offset       bigint NOT NULL DEFAULT 0,
+    upload_tail_size    bigint NOT NULL DEFAULT 0,
+    upload_parts        text[] NOT NULL DEFAULT '{}',
+    upload_created_at   timestamptz NOT NULL DEFAULT now(),
+    upload_expires_at   timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS upload_profile_id_idx ON upload (profile_id);
+CREATE INDEX IF NOT EXISTS upload_expires_at_idx ON upload (upload_expires_at);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS storage_quota (
+    profile_id          uuid PRIMARY KEY REFERENCES profile (profile_id) ON DELETE CASCADE,
+    quota_max_bytes     bigint CHECK (quota_max_bytes >= 0),
+    quota_max_objects   bigint CHECK (quota_max_objects >= 0),
+    quota_used_bytes    bigint NOT NULL DEFAULT 0,
+    quota_used_objects  bigint NOT NULL DEFAULT 0,
+    quota_reconciled_at timestamptz
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS share (
+    share_id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id           uuid NOT NULL REFERENCES profile (profile_id) ON DELETE CASCADE,
+    share_path         text NOT NULL,
+    grantee_profile_id uuid REFERENCES profile (profile_id) ON DELETE CASCADE,
+    grantee_group_id   uuid REFERENCES user_group (group_id) ON DELETE CASCADE,
+    share_permission   varchar(16) NOT NULL CHECK (share_permission IN ('read', 'read-write')),
+    share_created_at   timestamptz NOT NULL DEFAULT now(),
+    CHECK ((grantee_profile_id IS NULL) <> (grantee_group_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS share_profile_grantee_idx ON share (owner_id, share_path, grantee_profile_id) WHERE grantee_profile_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS share_group_grantee_idx ON share (owner_id, share_path, grantee_group_id) WHERE grantee_group_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS share_grantee_profile_id_idx ON share (grantee_profile_id);
+CREATE INDEX IF NOT EXISTS share_grantee_group_id_idx ON share (grantee_group_id);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS share_link (
+    link_id               uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id              uuid NOT NULL REFERENCES profile (profile_id) ON DELETE CASCADE,
+    link_path             text NOT NULL,
+    link_token_hash       char(64) NOT NULL UNIQUE,
+    link_password_hash    text,
+    link_expires_at       timestamptz,
+    link_max_downloads    bigint CHECK (link_max_downloads > 0),
+    link_downloads        bigint NOT NULL DEFAULT 0,
+    link_last_accessed_at timestamptz,
+    link_created_at       timestamptz NOT NULL DEFAULT now(),
+    link_revoked_at       timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS share_link_owner_id_idx ON share_link (owner_id, link_created_at);
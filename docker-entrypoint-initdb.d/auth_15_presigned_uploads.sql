@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS presigned_upload (
+    presign_id           varchar(64) PRIMARY KEY,
+    profile_id           uuid NOT NULL REFERENCES profile (profile_id) ON DELETE CASCADE,
+    presign_file_name    text NOT NULL,
+    presign_content_type varchar(255) NOT NULL,
+    presign_size         bigint NOT NULL,
+    presign_created_at   timestamptz NOT NULL DEFAULT now(),
+    presign_expires_at   timestamptz NOT NULL,
+    presign_confirmed_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS presigned_upload_profile_id_idx ON presigned_upload (profile_id);
+CREATE INDEX IF NOT EXISTS presigned_upload_expires_at_idx ON presigned_upload (presign_expires_at);
@@ -15,27 +15,70 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/addRoles": {
-            "put": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/audit": {
+            "get": {
+                "description": "Events are returned newest first. Pass next_before of a page as before to get the next one.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Admin"
                 ],
-                "summary": "AddRoles user",
+                "summary": "Query audit log",
                 "parameters": [
                     {
-                        "description": "Login of an account and roles to add",
-                        "name": "AddRolesDTO",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.AddRolesDTO"
-                        }
+                        "type": "integer",
+                        "description": "Only events with a lower id",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 100 by default and 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Login that performed the action",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Login the action was performed on",
+                        "name": "target",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Action, e.g. user.login_failed",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client IP",
+                        "name": "ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request id",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "At or after, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Before, RFC 3339",
+                        "name": "to",
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -49,40 +92,28 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.AddRolesSuccess"
+                            "$ref": "#/definitions/responses.AuditLogSuccess"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/responses.AddRolesError"
+                            "$ref": "#/definitions/responses.Error"
                         }
                     }
                 }
             }
         },
-        "/deleteFile": {
-            "delete": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/deletions": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "File"
+                    "Admin"
                 ],
-                "summary": "DeleteFile user",
+                "summary": "List unregistered accounts waiting to be purged",
                 "parameters": [
-                    {
-                        "description": "Login of an owner and name of file to delete",
-                        "name": "DeleteFileDTO",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.DeleteFileDTO"
-                        }
-                    },
                     {
                         "type": "string",
                         "description": "Access token",
@@ -93,7 +124,10 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "File was successfully deleted"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ListPendingDeletionsSuccess"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -104,27 +138,22 @@ const docTemplate = `{
                 }
             }
         },
-        "/downloadFile": {
+        "/admin/deletions/{login}/restore": {
             "post": {
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "File"
+                    "Admin"
                 ],
-                "summary": "DownloadFile user",
+                "summary": "Restore unregistered account",
                 "parameters": [
                     {
-                        "description": "Login of an owner, name of file and path of downloading",
-                        "name": "DownloadFileDTO",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.DownloadFileDTO"
-                        }
+                        "type": "string",
+                        "description": "Login of an account",
+                        "name": "login",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "string",
@@ -136,7 +165,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "File was successfully downloaded"
+                        "description": "Account was successfully restored"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -147,8 +176,8 @@ const docTemplate = `{
                 }
             }
         },
-        "/getFileList": {
-            "post": {
+        "/admin/lockouts": {
+            "delete": {
                 "consumes": [
                     "application/json"
                 ],
@@ -156,17 +185,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "File"
+                    "Admin"
                 ],
-                "summary": "GetFileList user",
+                "summary": "Clear failed login lockout",
                 "parameters": [
                     {
-                        "description": "Login of an owner of files",
-                        "name": "GetFileListDTO",
+                        "description": "Login and/or IP to unblock",
+                        "name": "ClearLockoutDTO",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.GetFileListDTO"
+                            "$ref": "#/definitions/models.ClearLockoutDTO"
                         }
                     },
                     {
@@ -179,10 +208,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/responses.GetFileListSuccess"
-                        }
+                        "description": "Lockout was successfully cleared"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -193,27 +219,83 @@ const docTemplate = `{
                 }
             }
         },
-        "/getUserData": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/users": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Admin"
                 ],
-                "summary": "GetUserData user",
+                "summary": "List users",
                 "parameters": [
                     {
-                        "description": "Login of an account which data to get",
-                        "name": "GetUserDataDTO",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.GetUserDataDTO"
-                        }
+                        "type": "string",
+                        "description": "Cursor returned with the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 50 by default and 500 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Beginning of a login",
+                        "name": "login_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Role held directly or through a group",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "disabled",
+                            "locked"
+                        ],
+                        "type": "string",
+                        "description": "Account status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Registered at or after, RFC 3339",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Registered before, RFC 3339",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "login",
+                            "created_at",
+                            "status"
+                        ],
+                        "type": "string",
+                        "description": "Sort column",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -227,7 +309,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.GetUserSuccess"
+                            "$ref": "#/definitions/responses.ListUsersSuccess"
                         }
                     },
                     "400": {
@@ -239,8 +321,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
-            "post": {
+        "/admin/users/{login}/quota": {
+            "put": {
+                "description": "Overrides the limits the roles of the user give, 0 meaning unlimited. A null limit is taken from the roles again.",
                 "consumes": [
                     "application/json"
                 ],
@@ -248,26 +331,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Admin"
                 ],
-                "summary": "Login user",
+                "summary": "Set storage limits of a user",
                 "parameters": [
                     {
-                        "description": "Account data",
-                        "name": "LoginDTO",
+                        "type": "string",
+                        "description": "Login of an account",
+                        "name": "login",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Largest number of bytes and of files",
+                        "name": "QuotaOverride",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.LoginDTO"
+                            "$ref": "#/definitions/models.QuotaOverride"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/responses.LoginSuccess"
-                        }
+                        "description": "Storage quota was successfully changed"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -278,8 +372,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
-            "post": {
+        "/admin/users/{login}/status": {
+            "put": {
+                "description": "Disabled and locked accounts can neither log in nor use issued tokens. Their data is kept, so setting the status back to active restores access.",
                 "consumes": [
                     "application/json"
                 ],
@@ -287,23 +382,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Admin"
                 ],
-                "summary": "Register new user",
+                "summary": "Change account status",
                 "parameters": [
                     {
-                        "description": "Data of new account",
-                        "name": "RegisterDTO",
+                        "type": "string",
+                        "description": "Login of an account",
+                        "name": "login",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New status (active, disabled or locked) and its reason",
+                        "name": "SetAccountStatusDTO",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.RegisterDTO"
+                            "$ref": "#/definitions/models.SetAccountStatusDTO"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "New profile was successfully registered"
+                        "description": "Account status was successfully changed"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -314,27 +423,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/unregister": {
-            "delete": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/files/{name}": {
+            "get": {
+                "description": "Streams the file body. Slashes in the name are part of it. Supports single and multiple\nbyte ranges, If-Range to resume only an unchanged file, and If-None-Match or If-Modified-Since\nto revalidate a cached copy. Other users' files can be read when they shared them, or a folder\nholding them, with the caller.",
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
-                    "User"
+                    "File"
                 ],
-                "summary": "Unregister user",
+                "summary": "Download file",
                 "parameters": [
                     {
-                        "description": "Data of account to delete",
-                        "name": "UnregisterDTO",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.UnregisterDTO"
-                        }
+                        "type": "string",
+                        "description": "File name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Login of the owner, the token owner by default",
+                        "name": "login",
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -342,254 +453,2965 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte ranges, e.g. bytes=0-1023,4096-",
+                        "name": "Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag or Last-Modified the ranges apply to",
+                        "name": "If-Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETags of cached copies",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of a cached copy",
+                        "name": "If-Modified-Since",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Profile was successfully unregistered"
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Accept-Ranges": {
+                                "type": "string",
+                                "description": "bytes"
+                            },
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "attachment with the file name"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Entity tag of the object"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Modification time of the object"
+                            }
+                        }
+                    },
+                    "206": {
+                        "description": "The requested range, or multipart/byteranges for several",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Accept-Ranges": {
+                                "type": "string",
+                                "description": "bytes"
+                            },
+                            "Content-Range": {
+                                "type": "string",
+                                "description": "Range sent for a single range"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Entity tag of the object"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Modification time of the object"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Cached copy is still current",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/responses.Error"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "416": {
+                        "description": "No requested range overlaps the file",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/uploadFile": {
-            "post": {
-                "consumes": [
-                    "multipart/form-data"
-                ],
+        "/s/{token}": {
+            "get": {
+                "description": "Needs no account. Links with a password ask for it with HTTP Basic authentication, any user name.\nEvery request sends the whole file and counts as a download; Range and conditional headers are ignored.",
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
                     "File"
                 ],
-                "summary": "UploadFile user",
+                "summary": "Download a file through a public link",
                 "parameters": [
-                    {
-                        "type": "file",
-                        "description": "File to upload",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Login of a user",
-                        "name": "login",
-                        "in": "formData",
-                        "required": true
-                    },
                     {
                         "type": "string",
-                        "description": "Access token",
-                        "name": "Authorization",
-                        "in": "header",
+                        "description": "Token of the link",
+                        "name": "token",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "File was successfully uploaded"
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        },
+                        "headers": {
+                            "WWW-Authenticate": {
+                                "type": "string",
+                                "description": "Basic realm of the link password"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "410": {
+                        "description": "Link was revoked, has expired or reached its download limit",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
                         "schema": {
                             "$ref": "#/definitions/responses.Error"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.AddRolesDTO": {
-            "type": "object",
+        },
+        "/uploads": {
+            "post": {
+                "description": "Upload-Metadata must carry filename and may carry filetype, the declared content type, and login\nto upload for another user as an Admin. The name and type are checked against the file type policy.\nDeferred lengths are not supported.",
+                "tags": [
+                    "Upload"
+                ],
+                "summary": "Start a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Size of the file in bytes",
+                        "name": "Upload-Length",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated keys with base64 values",
+                        "name": "Upload-Metadata",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the upload"
+                            },
+                            "Upload-Expires": {
+                                "type": "string",
+                                "description": "When the upload is dropped unless continued"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            },
+            "options": {
+                "tags": [
+                    "Upload"
+                ],
+                "summary": "Describe the upload server",
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "headers": {
+                            "Tus-Extension": {
+                                "type": "string",
+                                "description": "creation,expiration,termination"
+                            },
+                            "Tus-Max-Size": {
+                                "type": "integer",
+                                "description": "Largest upload in bytes"
+                            },
+                            "Tus-Version": {
+                                "type": "string",
+                                "description": "1.0.0"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{id}": {
+            "delete": {
+                "tags": [
+                    "Upload"
+                ],
+                "summary": "Cancel a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "tags": [
+                    "Upload"
+                ],
+                "summary": "Get the offset of a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "headers": {
+                            "Upload-Expires": {
+                                "type": "string",
+                                "description": "When the upload is dropped unless continued"
+                            },
+                            "Upload-Length": {
+                                "type": "integer",
+                                "description": "Size of the file in bytes"
+                            },
+                            "Upload-Offset": {
+                                "type": "integer",
+                                "description": "Bytes received so far"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "The chunk is written at Upload-Offset, which must equal the offset of the upload. With the last chunk\nthe file is checked before it is stored; a file breaking the upload rules ends the upload and 400 is\nreturned, or 409 when the name is taken and 413 when it does not fit in the storage quota. After\nany other failure an empty chunk at the final offset tries again.",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "tags": [
+                    "Upload"
+                ],
+                "summary": "Send a chunk of a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset the chunk starts at",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "headers": {
+                            "Upload-Expires": {
+                                "type": "string",
+                                "description": "When the upload is dropped unless continued"
+                            },
+                            "Upload-Offset": {
+                                "type": "integer",
+                                "description": "Bytes received so far"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/addGroupMembers": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Group"
+                ],
+                "summary": "Add users to group",
+                "parameters": [
+                    {
+                        "description": "Name of a group and space separated logins to add",
+                        "name": "GroupMembersDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupMembersDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GroupStatusSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GroupStatusError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/addGroupRoles": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Group"
+                ],
+                "summary": "Add roles to group",
+                "parameters": [
+                    {
+                        "description": "Name of a group and roles to add",
+                        "name": "GroupRolesDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupRolesDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GroupStatusSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GroupStatusError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/addRoles": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "AddRoles user",
+                "parameters": [
+                    {
+                        "description": "Login of an account and roles to add",
+                        "name": "AddRolesDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AddRolesDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.AddRolesSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.AddRolesError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/confirmUpload": {
+            "post": {
+                "description": "Checks the size and content of the staged file against the upload rules and the storage quota, and moves\nit into the bucket. Each upload is accepted once; a file that breaks the rules is dropped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Accept a file uploaded with a presigned URL",
+                "parameters": [
+                    {
+                        "description": "Login of an owner and id of the presigned upload",
+                        "name": "ConfirmUploadDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ConfirmUploadDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File was successfully uploaded"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/copyFile": {
+            "post": {
+                "description": "Copies within storage, without downloading. A source ending with \"/\" is a folder, copied with everything\nin it. A destination ending with \"/\" is the folder a file is copied into. The copy counts against the\nstorage quota. conflict is fail, overwrite or auto-rename, which appends a number to a taken name.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Copy a file or folder",
+                "parameters": [
+                    {
+                        "description": "Login of an owner, source and destination",
+                        "name": "TransferFileDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TransferFileDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.TransferFileSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/createFolder": {
+            "post": {
+                "description": "Folders are the parts of file names up to a \"/\". A created folder is listed while it holds no files.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Create an empty folder",
+                "parameters": [
+                    {
+                        "description": "Login of an owner and name of the folder",
+                        "name": "FolderDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.FolderDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Folder was successfully created"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/createGroup": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Group"
+                ],
+                "summary": "Create new group",
+                "parameters": [
+                    {
+                        "description": "Name of new group",
+                        "name": "CreateGroupDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateGroupDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Group was successfully created"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/deleteFile": {
+            "delete": {
+                "description": "Deletes a file of login, which may be another user's when they shared it read-write with the caller.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "DeleteFile user",
+                "parameters": [
+                    {
+                        "description": "Login of an owner and name of file to delete",
+                        "name": "DeleteFileDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DeleteFileDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File was successfully deleted"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/deleteFolder": {
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Delete a folder with everything in it",
+                "parameters": [
+                    {
+                        "description": "Login of an owner and name of the folder",
+                        "name": "FolderDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.FolderDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Folder was successfully deleted"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/deleteGroup": {
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Group"
+                ],
+                "summary": "Delete group",
+                "parameters": [
+                    {
+                        "description": "Name of a group to delete",
+                        "name": "DeleteGroupDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DeleteGroupDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Group was successfully deleted"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/export": {
+            "post": {
+                "description": "Starts building a ZIP archive with the profile, role history, login sessions, audit events and all files of a user. Poll the returned job for its status.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Export"
+                ],
+                "summary": "Request export of personal data",
+                "parameters": [
+                    {
+                        "description": "Login of a user whose data to export",
+                        "name": "ExportDataDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ExportDataDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ExportStartedSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/export/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Export"
+                ],
+                "summary": "Get status of personal data export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export job id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ExportStatusSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/export/{id}/download": {
+            "get": {
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "Export"
+                ],
+                "summary": "Download personal data export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export job id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/getFileList": {
+            "post": {
+                "description": "Lists files under prefix with their size, content type, modification time and ETag. Files below the next\ndelimiter (\"/\" by default) are listed as folders unless recursive is set. filter keeps names containing it.\nsort is name, size or last_modified and order asc or desc; limit is 100 by default and 1000 at most.\nPass next_cursor as cursor with the same prefix, delimiter, recursive, filter, sort and order to get the next page.\nOther users' files can be listed with a prefix ending in \"/\" that is a folder they shared with the caller or lies below one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "GetFileList user",
+                "parameters": [
+                    {
+                        "description": "Login of an owner of files and listing options",
+                        "name": "GetFileListDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GetFileListDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GetFileListSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/getUserData": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "GetUserData user",
+                "parameters": [
+                    {
+                        "description": "Login of an account which data to get",
+                        "name": "GetUserDataDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GetUserDataDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GetUserSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/links": {
+            "get": {
+                "description": "Lists the links that still serve downloads, newest first, with how often they were used.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "List the public links of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Login of an owner, the token owner by default",
+                        "name": "login",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ListShareLinksSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Anyone with the link can download the file without an account until it expires after expires seconds,\nreaches max-downloads or is revoked; 0 means no limit. A password is asked for with HTTP Basic\nauthentication, any user name. The token is only returned here. The link ends when the file is deleted or moved.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Create a public link to a file",
+                "parameters": [
+                    {
+                        "description": "Owner, file name and limits of the link",
+                        "name": "CreateShareLinkDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateShareLinkDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.CreateShareLinkSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/links/{id}": {
+            "delete": {
+                "description": "Only the owner of the file and Admins may revoke a link.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Revoke a public link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the link",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link was successfully revoked"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/login": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Login user",
+                "parameters": [
+                    {
+                        "description": "Account data",
+                        "name": "LoginDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.LoginSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Login requires additional verification",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Login was blocked as suspicious",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "Seconds until the next attempt is allowed"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/user/moveFile": {
+            "put": {
+                "description": "A source ending with \"/\" is a folder, moved with everything in it. A destination ending with \"/\" is the\nfolder a file is moved into. The extension of a file must stay one of its type. conflict is fail,\noverwrite or auto-rename, which appends a number to a taken name; fail is the default.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Rename or move a file or folder",
+                "parameters": [
+                    {
+                        "description": "Login of an owner, source and destination",
+                        "name": "TransferFileDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TransferFileDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.TransferFileSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/presignDownload": {
+            "post": {
+                "description": "The URL expires after expires seconds, bounded by PRESIGN_MAX_EXPIRY.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Get a URL to download a file directly from storage",
+                "parameters": [
+                    {
+                        "description": "Login of an owner and name of file to download",
+                        "name": "PresignDownloadDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PresignDownloadDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PresignDownloadSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/presignUpload": {
+            "post": {
+                "description": "Returns a URL for a PUT that must send put_headers, and a URL with form fields for a multipart POST\nwhose policy caps the size. Files may be up to PRESIGN_MAX_SIZE bytes; the data never passes through\nthis service. Both URLs expire after expires seconds, bounded by PRESIGN_MAX_EXPIRY.\nThe file is sent to staging and only reaches the bucket once the returned id is confirmed,\nat most an hour after the URLs expire.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Get URLs to upload a file directly to storage",
+                "parameters": [
+                    {
+                        "description": "Owner, name, content type and size of the new file",
+                        "name": "PresignUploadDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PresignUploadDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PresignUploadSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/quota": {
+            "get": {
+                "description": "A limit of 0 means unlimited. Limits come from STORAGE_QUOTAS for the roles of the user unless an Admin set them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Get storage usage and limits",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Login of a user, the token owner by default",
+                        "name": "login",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.QuotaSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/register": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Register new user",
+                "parameters": [
+                    {
+                        "description": "Data of new account",
+                        "name": "RegisterDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RegisterDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New profile was successfully registered"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.PasswordPolicyError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/removeGroupMembers": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Group"
+                ],
+                "summary": "Remove users from group",
+                "parameters": [
+                    {
+                        "description": "Name of a group and space separated logins to remove",
+                        "name": "GroupMembersDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupMembersDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GroupStatusSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GroupStatusError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/removeGroupRoles": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Group"
+                ],
+                "summary": "Remove roles from group",
+                "parameters": [
+                    {
+                        "description": "Name of a group and roles to remove",
+                        "name": "GroupRolesDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupRolesDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GroupStatusSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.GroupStatusError"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/renameGroup": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Group"
+                ],
+                "summary": "Rename group",
+                "parameters": [
+                    {
+                        "description": "Current and new name of a group",
+                        "name": "RenameGroupDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RenameGroupDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Group was successfully renamed"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/sessions": {
+            "get": {
+                "description": "Every successful login starts a session that lasts as long as its access token. Sessions are listed newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "List login history and active sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Login of an account",
+                        "name": "login",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ListSessionsSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/sessions/{id}": {
+            "delete": {
+                "description": "The access token of the session stops working at once.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Revoke session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Login of an account",
+                        "name": "login",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session was successfully revoked"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/shares": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "List the shares a user gave",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Login of an owner, the token owner by default",
+                        "name": "login",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ListSharesSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Gives user or the members of group, exactly one of them, access to path, or to everything in it when it ends\nwith \"/\". read allows downloading and listing, read-write also deleting. Sharing a path with the same user\nor group again changes the permission. Shares end when the path is deleted or moved.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Share a file or folder with a user or group",
+                "parameters": [
+                    {
+                        "description": "Login of an owner, the path, the grantee and read or read-write",
+                        "name": "ShareFileDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ShareFileDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ShareSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/shares/received": {
+            "get": {
+                "description": "Includes what was shared with groups the caller is a member of.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "List what others shared with the caller",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ListSharesSuccess"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/shares/{id}": {
+            "delete": {
+                "description": "Only the owner of the shared path and Admins may revoke a share.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Revoke a share",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the share",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Share was successfully revoked"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/unregister": {
+            "delete": {
+                "description": "The account is hidden at once and purged with all its files after the deletion grace period. Until then an Admin can restore it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Unregister user",
+                "parameters": [
+                    {
+                        "description": "Data of account to delete",
+                        "name": "UnregisterDTO",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UnregisterDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Profile was successfully unregistered"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/uploadFile": {
+            "post": {
+                "description": "The extension, the declared content type and the content must agree on a type the roles of the user\nmay upload, see UPLOAD_ALLOWED_TYPES. SVG images are stored without scripts and external references.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "UploadFile user",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "File to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Login of a user",
+                        "name": "login",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File was successfully uploaded"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Error"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "customError.PolicyFailure": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AddRolesDTO": {
+            "type": "object",
+            "required": [
+                "login",
+                "roles"
+            ],
+            "properties": {
+                "login": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "prev_hash": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ClearLockoutDTO": {
+            "type": "object",
+            "properties": {
+                "ip": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConfirmUploadDTO": {
+            "type": "object",
+            "required": [
+                "id",
+                "login"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateGroupDTO": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateShareLinkDTO": {
+            "type": "object",
+            "required": [
+                "file-name",
+                "login"
+            ],
+            "properties": {
+                "expires": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "file-name": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "max-downloads": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeleteFileDTO": {
+            "type": "object",
+            "required": [
+                "file-name",
+                "login"
+            ],
+            "properties": {
+                "file-name": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeleteGroupDTO": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExportDataDTO": {
+            "type": "object",
+            "required": [
+                "login"
+            ],
+            "properties": {
+                "login": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FolderDTO": {
+            "type": "object",
+            "required": [
+                "folder",
+                "login"
+            ],
+            "properties": {
+                "folder": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GetFileListDTO": {
+            "type": "object",
+            "required": [
+                "login"
+            ],
+            "properties": {
+                "cursor": {
+                    "type": "string"
+                },
+                "delimiter": {
+                    "type": "string"
+                },
+                "filter": {
+                    "type": "string"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "order": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "recursive": {
+                    "type": "boolean"
+                },
+                "sort": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GetUserDataDTO": {
+            "type": "object",
             "required": [
-                "login",
+                "login"
+            ],
+            "properties": {
+                "login": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GroupMembersDTO": {
+            "type": "object",
+            "required": [
+                "logins",
+                "name"
+            ],
+            "properties": {
+                "logins": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GroupRolesDTO": {
+            "type": "object",
+            "required": [
+                "name",
                 "roles"
             ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LoginDTO": {
+            "type": "object",
+            "required": [
+                "login",
+                "password"
+            ],
+            "properties": {
+                "login": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PresignDownloadDTO": {
+            "type": "object",
+            "required": [
+                "file-name",
+                "login"
+            ],
+            "properties": {
+                "expires": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "file-name": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PresignUploadDTO": {
+            "type": "object",
+            "required": [
+                "content-type",
+                "file-name",
+                "login",
+                "size"
+            ],
+            "properties": {
+                "content-type": {
+                    "type": "string"
+                },
+                "expires": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "file-name": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.QuotaOverride": {
+            "type": "object",
+            "properties": {
+                "max_bytes": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "max_objects": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "models.RegisterDTO": {
+            "type": "object",
+            "required": [
+                "login",
+                "password"
+            ],
+            "properties": {
+                "login": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RenameGroupDTO": {
+            "type": "object",
+            "required": [
+                "name",
+                "new-name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "new-name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SetAccountStatusDTO": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ShareFileDTO": {
+            "type": "object",
+            "required": [
+                "login",
+                "path",
+                "permission"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "permission": {
+                    "type": "string"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TransferFileDTO": {
+            "type": "object",
+            "required": [
+                "destination",
+                "login",
+                "source"
+            ],
+            "properties": {
+                "conflict": {
+                    "type": "string"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UnregisterDTO": {
+            "type": "object",
+            "required": [
+                "login"
+            ],
+            "properties": {
+                "login": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.AddRolesError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "roles status": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "responses.AddRolesSuccess": {
+            "type": "object",
             "properties": {
                 "login": {
                     "type": "string"
                 },
-                "roles": {
+                "roles status": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "responses.AuditLogSuccess": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEvent"
+                    }
+                },
+                "next_before": {
+                    "type": "integer"
+                }
+            }
+        },
+        "responses.CreateShareLinkSuccess": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.Error": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.ExportStartedSuccess": {
+            "type": "object",
+            "properties": {
+                "job_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.ExportStatusSuccess": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "job_id": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "models.DeleteFileDTO": {
+        "responses.FileSummary": {
             "type": "object",
-            "required": [
-                "file-name",
-                "login"
-            ],
             "properties": {
-                "file-name": {
+                "content_type": {
                     "type": "string"
                 },
-                "login": {
+                "etag": {
+                    "type": "string"
+                },
+                "folder": {
+                    "type": "boolean"
+                },
+                "last_modified": {
+                    "type": "string"
+                },
+                "name": {
                     "type": "string"
+                },
+                "size": {
+                    "type": "integer"
                 }
             }
         },
-        "models.DownloadFileDTO": {
+        "responses.GetFileListSuccess": {
             "type": "object",
-            "required": [
-                "file-name",
-                "login",
-                "path"
-            ],
             "properties": {
-                "file-name": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.FileSummary"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.GetUserSuccess": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
                     "type": "string"
                 },
                 "login": {
                     "type": "string"
                 },
-                "path": {
+                "password": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "status_reason": {
                     "type": "string"
                 }
             }
         },
-        "models.GetFileListDTO": {
+        "responses.GroupStatusError": {
             "type": "object",
-            "required": [
-                "login"
-            ],
             "properties": {
-                "login": {
+                "error": {
                     "type": "string"
+                },
+                "status": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "models.GetUserDataDTO": {
+        "responses.GroupStatusSuccess": {
             "type": "object",
-            "required": [
-                "login"
-            ],
             "properties": {
-                "login": {
+                "group": {
                     "type": "string"
+                },
+                "status": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "models.LoginDTO": {
+        "responses.ListPendingDeletionsSuccess": {
             "type": "object",
-            "required": [
-                "login",
-                "password"
-            ],
             "properties": {
-                "login": {
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.PendingDeletion"
+                    }
+                }
+            }
+        },
+        "responses.ListSessionsSuccess": {
+            "type": "object",
+            "properties": {
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.SessionSummary"
+                    }
+                }
+            }
+        },
+        "responses.ListShareLinksSuccess": {
+            "type": "object",
+            "properties": {
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.ShareLinkSummary"
+                    }
+                }
+            }
+        },
+        "responses.ListSharesSuccess": {
+            "type": "object",
+            "properties": {
+                "shares": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.ShareSummary"
+                    }
+                }
+            }
+        },
+        "responses.ListUsersSuccess": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "password": {
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.UserSummary"
+                    }
+                }
+            }
+        },
+        "responses.LoginSuccess": {
+            "type": "object",
+            "properties": {
+                "access_token": {
                     "type": "string"
                 }
             }
         },
-        "models.RegisterDTO": {
+        "responses.PasswordPolicyError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "failures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/customError.PolicyFailure"
+                    }
+                }
+            }
+        },
+        "responses.PendingDeletion": {
             "type": "object",
-            "required": [
-                "login",
-                "password"
-            ],
             "properties": {
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
                 "login": {
                     "type": "string"
                 },
-                "password": {
+                "purge_at": {
                     "type": "string"
                 }
             }
         },
-        "models.UnregisterDTO": {
+        "responses.PresignDownloadSuccess": {
             "type": "object",
-            "required": [
-                "login"
-            ],
             "properties": {
-                "login": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "url": {
                     "type": "string"
                 }
             }
         },
-        "responses.AddRolesError": {
+        "responses.PresignUploadSuccess": {
             "type": "object",
             "properties": {
-                "error": {
+                "expires_at": {
                     "type": "string"
                 },
-                "roles status": {
+                "form_data": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "post_url": {
+                    "type": "string"
+                },
+                "put_headers": {
                     "type": "object",
                     "additionalProperties": {
                         "type": "string"
                     }
+                },
+                "put_url": {
+                    "type": "string"
                 }
             }
         },
-        "responses.AddRolesSuccess": {
+        "responses.QuotaSuccess": {
             "type": "object",
             "properties": {
-                "login": {
+                "max_bytes": {
+                    "type": "integer"
+                },
+                "max_objects": {
+                    "type": "integer"
+                },
+                "overridden": {
+                    "type": "boolean"
+                },
+                "reconciled_at": {
                     "type": "string"
                 },
-                "roles status": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
-                    }
+                "used_bytes": {
+                    "type": "integer"
+                },
+                "used_objects": {
+                    "type": "integer"
                 }
             }
         },
-        "responses.Error": {
+        "responses.SessionSummary": {
             "type": "object",
             "properties": {
-                "error": {
+                "active": {
+                    "type": "boolean"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "type": "boolean"
+                },
+                "device": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "flagged": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "risk_score": {
+                    "type": "integer"
+                },
+                "user_agent": {
                     "type": "string"
                 }
             }
         },
-        "responses.GetFileListSuccess": {
+        "responses.ShareLinkSummary": {
             "type": "object",
             "properties": {
-                "files list": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "created_at": {
+                    "type": "string"
+                },
+                "downloads": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_accessed_at": {
+                    "type": "string"
+                },
+                "max_downloads": {
+                    "type": "integer"
+                },
+                "protected": {
+                    "type": "boolean"
                 }
             }
         },
-        "responses.GetUserSuccess": {
+        "responses.ShareSummary": {
             "type": "object",
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
-                "login": {
+                "owner": {
                     "type": "string"
                 },
-                "password": {
+                "path": {
                     "type": "string"
                 },
-                "roles": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "permission": {
+                    "type": "string"
+                },
+                "user": {
+                    "type": "string"
                 }
             }
         },
-        "responses.LoginSuccess": {
+        "responses.TransferFileSuccess": {
             "type": "object",
             "properties": {
-                "access_token": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.UserSummary": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
                     "type": "string"
                 }
             }
@@ -601,7 +3423,7 @@ const docTemplate = `{
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
 	Host:             "localhost:8080",
-	BasePath:         "/user",
+	BasePath:         "/",
 	Schemes:          []string{},
 	Title:            "Auth API",
 	Description:      "",
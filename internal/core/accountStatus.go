@@ -0,0 +1,57 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"strings"
+)
+
+// CheckAccountStatus returns an error unless the account exists and is active.
+func (service *UserService) CheckAccountStatus(login string) error {
+
+	status, err := service.repo.GetUserStatus(login)
+	if err != nil {
+		return customError.UnexistingLoginError
+	}
+
+	return statusError(status)
+}
+
+// SetAccountStatus moves an account to status. Nothing but the status and its reason
+// is touched, so re-activating an account restores access to all of its data.
+func (service *UserService) SetAccountStatus(ctx context.Context, login, status, reason string) error {
+
+	_, err := service.repo.GetUserStatus(login)
+	if err != nil {
+		return customError.UnexistingLoginError
+	}
+
+	status = strings.ToLower(status)
+
+	switch status {
+	case models.StatusActive, models.StatusDisabled, models.StatusLocked:
+	default:
+		return customError.InvalidStatusError
+	}
+
+	err = service.repo.SetUserStatus(login, status, reason)
+	if err != nil {
+		return err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditStatusChanged, login, map[string]string{"status": status, "reason": reason})
+
+	return nil
+}
+
+func statusError(status string) error {
+	switch status {
+	case models.StatusActive:
+		return nil
+	case models.StatusLocked:
+		return customError.AccountLockedError
+	default:
+		return customError.AccountDisabledError
+	}
+}
@@ -0,0 +1,215 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 1000
+	auditVerifyBatch     = 1000
+)
+
+type AuditStore interface {
+	// AppendAudit stores event after the last entry. It fills in At and PrevHash,
+	// then seal computes Hash; both happen while other appends wait.
+	AppendAudit(event models.AuditEvent, seal func(event models.AuditEvent) string) (models.AuditEvent, error)
+	QueryAudit(query models.AuditQuery) ([]models.AuditEvent, error)
+	// ScanAudit returns up to limit entries with ids greater than afterId, oldest first.
+	ScanAudit(afterId int64, limit int) ([]models.AuditEvent, error)
+}
+
+// AuditLog records security events in an append-only, hash chained log and
+// streams them to the sinks added with AddSink.
+type AuditLog struct {
+	store AuditStore
+	sinks []*sinkQueue
+}
+
+func NewAuditLog(store AuditStore) *AuditLog {
+	return &AuditLog{store: store}
+}
+
+// Record appends event to the log and hands it to the sinks. A failure is logged
+// and never fails the audited request; sinks get the event even if storing it failed.
+func (audit *AuditLog) Record(event models.AuditEvent) {
+	stored, err := audit.store.AppendAudit(event, AuditHash)
+	if err != nil {
+		log.Printf("Recording audit event %s of %s failed: %v", event.Action, event.Target, err)
+		stored = event
+		stored.At = time.Now().UTC()
+	}
+
+	for _, queue := range audit.sinks {
+		queue.enqueue(stored)
+	}
+}
+
+// RecordAction records action on target as part of the request ctx carries. The
+// actor is the one of the request or, for requests such as register and login
+// made without a token, the target itself. Actions taken without a request, by
+// background jobs, are recorded with the actor "system". A nil log records nothing.
+func (audit *AuditLog) RecordAction(ctx context.Context, action, target string, details map[string]string) {
+	if audit == nil {
+		return
+	}
+
+	event := models.AuditEvent{Actor: "system", Target: target, Action: action, Details: details}
+
+	if request, ok := ctx.Value(models.AuditRequestKey{}).(models.AuditRequest); ok {
+		event.Actor = request.Actor
+		if event.Actor == "" {
+			event.Actor = target
+		}
+		event.IP, event.UserAgent, event.RequestId = request.IP, request.UserAgent, request.RequestId
+	}
+
+	audit.Record(event)
+}
+
+// riskDetails records the risk assessment of a login, if it got that far.
+func riskDetails(risk models.RiskAssessment) map[string]string {
+	details := make(map[string]string)
+	if risk.Action == "" {
+		return details
+	}
+
+	details["risk_score"] = strconv.Itoa(risk.Score)
+	details["risk_action"] = risk.Action
+	if len(risk.Factors) > 0 {
+		details["risk_factors"] = strings.Join(risk.Factors, " ")
+	}
+	if risk.Location.Country != "" {
+		details["country"] = risk.Location.Country
+	}
+
+	return details
+}
+
+// Query returns a page of events, newest first, and the id to pass as before for the next page.
+func (audit *AuditLog) Query(params models.AuditLogDTO) ([]models.AuditEvent, int64, error) {
+
+	query := models.AuditQuery{
+		Actor:     params.Actor,
+		Target:    params.Target,
+		Action:    strings.ToLower(params.Action),
+		IP:        params.IP,
+		RequestId: params.RequestId,
+		From:      params.From,
+		To:        params.To,
+		Before:    params.Before,
+		Limit:     params.Limit,
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = defaultAuditPageSize
+	}
+	if query.Limit > maxAuditPageSize {
+		query.Limit = maxAuditPageSize
+	}
+
+	query.Limit++
+	events, err := audit.store.QueryAudit(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	query.Limit--
+
+	if len(events) <= query.Limit {
+		return events, 0, nil
+	}
+
+	events = events[:query.Limit]
+
+	return events, events[len(events)-1].Id, nil
+}
+
+// EventsOf returns every event where login is the actor or the target, oldest first.
+func (audit *AuditLog) EventsOf(login string) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+
+	query := models.AuditQuery{Login: login, Limit: maxAuditPageSize}
+	for {
+		page, err := audit.store.QueryAudit(query)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, page...)
+		if len(page) < query.Limit {
+			break
+		}
+		query.Before = page[len(page)-1].Id
+	}
+
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+
+	return events, nil
+}
+
+// Verify walks the whole log and recomputes the chain. It returns the number of
+// entries checked and a *customError.AuditChainBroken for the first bad entry.
+func (audit *AuditLog) Verify() (int, error) {
+	checked := 0
+	prevHash := ""
+	afterId := int64(0)
+
+	for {
+		events, err := audit.store.ScanAudit(afterId, auditVerifyBatch)
+		if err != nil {
+			return checked, err
+		}
+
+		for _, event := range events {
+			if event.PrevHash != prevHash {
+				return checked, &customError.AuditChainBroken{Id: event.Id, Reason: "previous hash does not match the entry before it"}
+			}
+			if AuditHash(event) != event.Hash {
+				return checked, &customError.AuditChainBroken{Id: event.Id, Reason: "hash does not match the entry content"}
+			}
+
+			prevHash = event.Hash
+			afterId = event.Id
+			checked++
+		}
+
+		if len(events) < auditVerifyBatch {
+			return checked, nil
+		}
+	}
+}
+
+// AuditHash is the hex SHA-256 of the entry content and the hash of the entry before it.
+func AuditHash(event models.AuditEvent) string {
+	var details map[string]string
+	if len(event.Details) > 0 {
+		details = event.Details
+	}
+
+	content, _ := json.Marshal([]interface{}{
+		event.PrevHash,
+		event.At.UTC().Format(time.RFC3339Nano),
+		event.Actor,
+		event.Target,
+		event.Action,
+		event.IP,
+		event.UserAgent,
+		event.RequestId,
+		details,
+	})
+
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// AuditSink receives copies of audit events, e.g. for a SIEM. Each sink is fed by
+// a single goroutine, so implementations need no locking.
+type AuditSink interface {
+	WriteAudit(events []models.AuditEvent) error
+}
+
+// sinkQueue buffers events for one sink. Enqueuing never blocks: when the sink
+// falls behind and the buffer is full, new events are dropped and counted.
+type sinkQueue struct {
+	name          string
+	sink          AuditSink
+	events        chan models.AuditEvent
+	batchSize     int
+	flushInterval time.Duration
+	retries       int
+	dropped       atomic.Int64
+}
+
+// AddSink starts streaming every recorded event to sink. Buffering is configured
+// by AUDIT_SINK_BUFFER, AUDIT_SINK_BATCH, AUDIT_SINK_FLUSH_INTERVAL and AUDIT_SINK_RETRIES.
+func (audit *AuditLog) AddSink(name string, sink AuditSink) {
+	queue := &sinkQueue{
+		name:          name,
+		sink:          sink,
+		events:        make(chan models.AuditEvent, max(envInt("AUDIT_SINK_BUFFER", 1024), 1)),
+		batchSize:     max(envInt("AUDIT_SINK_BATCH", 100), 1),
+		flushInterval: envDuration("AUDIT_SINK_FLUSH_INTERVAL", time.Second),
+		retries:       envInt("AUDIT_SINK_RETRIES", 3),
+	}
+
+	audit.sinks = append(audit.sinks, queue)
+
+	go queue.run()
+}
+
+func (queue *sinkQueue) enqueue(event models.AuditEvent) {
+	select {
+	case queue.events <- event:
+	default:
+		queue.dropped.Add(1)
+	}
+}
+
+// run sends events in batches, when a batch is full or every flush interval.
+func (queue *sinkQueue) run() {
+	ticker := time.NewTicker(queue.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.AuditEvent, 0, queue.batchSize)
+
+	for {
+		select {
+		case event := <-queue.events:
+			batch = append(batch, event)
+			if len(batch) < queue.batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if dropped := queue.dropped.Swap(0); dropped > 0 {
+				log.Printf("Audit sink %s is behind, %d events were dropped", queue.name, dropped)
+			}
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		queue.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+// flush writes a batch, retrying with a growing delay. Meanwhile new events pile
+// up in the buffer, so a sink that stays down only costs the events that overflow it.
+func (queue *sinkQueue) flush(batch []models.AuditEvent) {
+	delay := queue.flushInterval
+
+	for attempt := 0; ; attempt++ {
+		err := queue.sink.WriteAudit(batch)
+		if err == nil {
+			return
+		}
+
+		if attempt >= queue.retries {
+			log.Printf("Audit sink %s failed, %d events were dropped: %v", queue.name, len(batch), err)
+			return
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
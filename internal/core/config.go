@@ -0,0 +1,41 @@
+package core
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envDuration reads a time.ParseDuration formatted variable, falling back to def
+// when it is unset or invalid.
+func envDuration(name string, def time.Duration) time.Duration {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return def
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("Invalid %s %q, using %s", name, value, def)
+		return def
+	}
+
+	return duration
+}
+
+// envInt reads a non-negative integer variable, falling back to def when it is unset or invalid.
+func envInt(name string, def int) int {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return def
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		log.Printf("Invalid %s %q, using %d", name, value, def)
+		return def
+	}
+
+	return number
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/minio/minio-go/v7"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDeletionGraceDays = 30
+	defaultPurgeInterval     = time.Hour
+)
+
+// deletionGracePeriod reads ACCOUNT_DELETION_GRACE_DAYS, the number of days an
+// unregistered account can still be restored.
+func deletionGracePeriod() time.Duration {
+	return time.Duration(envInt("ACCOUNT_DELETION_GRACE_DAYS", defaultDeletionGraceDays)) * 24 * time.Hour
+}
+
+func (service *UserService) ListPendingDeletions() ([]models.User, error) {
+	return service.repo.GetDeletedUsers(time.Now())
+}
+
+// PurgeTime returns the moment an account deleted at deletedAt gets purged.
+func (service *UserService) PurgeTime(deletedAt time.Time) time.Time {
+	return deletedAt.Add(service.deletionGrace)
+}
+
+func (service *UserService) RestoreUser(ctx context.Context, login string) error {
+
+	profileData, err := service.repo.GetDeletedUserByLogin(login)
+	if err != nil {
+		return customError.UnexistingDeletion
+	}
+
+	if !time.Now().Before(service.PurgeTime(profileData.DeletedAt)) {
+		return customError.RestorePeriodExpired
+	}
+
+	err = service.repo.Restore(login)
+	if errors.Is(err, sql.ErrNoRows) {
+		return customError.UnexistingDeletion
+	}
+	if err != nil {
+		return err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditRestored, login, nil)
+
+	return nil
+}
+
+// PurgeDeletedUsers finalizes deletion of every account whose grace period has ended:
+// the profile and the bucket with all its objects are removed.
+func (service *UserService) PurgeDeletedUsers(ctx context.Context) error {
+
+	cutoff := time.Now().Add(-service.deletionGrace)
+
+	users, err := service.repo.GetDeletedUsers(cutoff)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		err = service.purgeUser(ctx, user, cutoff)
+		if err != nil {
+			log.Printf("Purging account %s failed: %v", user.Login, err)
+		}
+	}
+
+	return nil
+}
+
+// purgeUser removes user unless it was restored since it was listed, in which
+// case its files are left alone too.
+func (service *UserService) purgeUser(ctx context.Context, user models.User, cutoff time.Time) error {
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(user.Login), user.Id)
+
+	purged, err := service.repo.Purge(user.Login, cutoff, func() error {
+		err := service.fileStorage.RemoveObjects(ctx, bucketName)
+		if err != nil && minio.ToErrorResponse(err).Code != "NoSuchBucket" {
+			return err
+		}
+
+		err = service.fileStorage.RemoveBucket(ctx, bucketName)
+		if err != nil && minio.ToErrorResponse(err).Code != "NoSuchBucket" {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil || !purged {
+		return err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditPurged, user.Login, nil)
+
+	return nil
+}
+
+// RunPurger calls PurgeDeletedUsers and PruneSessions every ACCOUNT_PURGE_INTERVAL
+// (an hour by default) until ctx is done.
+func (service *UserService) RunPurger(ctx context.Context) {
+	ticker := time.NewTicker(envDuration("ACCOUNT_PURGE_INTERVAL", defaultPurgeInterval))
+	defer ticker.Stop()
+
+	for {
+		err := service.PurgeDeletedUsers(ctx)
+		if err != nil {
+			log.Printf("Purging deleted accounts failed: %v", err)
+		}
+
+		err = service.PruneSessions()
+		if err != nil {
+			log.Printf("Pruning old sessions failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakePurges purges the accounts in deleted when they are old enough.
+type fakePurges struct {
+	fakeUsers
+	deleted map[string]time.Time
+}
+
+func (repo fakePurges) Purge(login string, deletedBefore time.Time, removeFiles func() error) (bool, error) {
+	deletedAt, ok := repo.deleted[login]
+	if !ok || !deletedAt.Before(deletedBefore) {
+		return false, nil
+	}
+
+	err := removeFiles()
+	if err != nil {
+		return false, err
+	}
+	delete(repo.deleted, login)
+
+	return true, nil
+}
+
+// fakeBuckets records the buckets removed.
+type fakeBuckets struct {
+	FileStorage
+	removed *[]string
+	err     error
+}
+
+func (storage fakeBuckets) RemoveObjects(_ context.Context, bucketName string) error {
+	return storage.err
+}
+
+func (storage fakeBuckets) RemoveBucket(_ context.Context, bucketName string) error {
+	*storage.removed = append(*storage.removed, bucketName)
+	return nil
+}
+
+func TestPurgeUser(t *testing.T) {
+	cutoff := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	errStorage := errors.New("storage unavailable")
+
+	tests := []struct {
+		name        string
+		deleted     map[string]time.Time
+		storageErr  error
+		wantErr     error
+		wantRemoved []string
+		wantKept    bool
+	}{
+		{"purged", map[string]time.Time{"bob": cutoff.Add(-time.Hour)}, nil, nil, []string{"bob-7"}, false},
+		{"restored", map[string]time.Time{}, nil, nil, []string{}, false},
+		{"deleted again recently", map[string]time.Time{"bob": cutoff.Add(time.Hour)}, nil, nil, []string{}, true},
+		{"storage fails", map[string]time.Time{"bob": cutoff.Add(-time.Hour)}, errStorage, errStorage, []string{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			removed := make([]string, 0)
+			service := &UserService{
+				repo:        fakePurges{deleted: test.deleted},
+				fileStorage: fakeBuckets{removed: &removed, err: test.storageErr},
+			}
+
+			err := service.purgeUser(context.Background(), models.User{Id: "7", Login: "bob"}, cutoff)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("purgeUser() error = %v, want %v", err, test.wantErr)
+			}
+			if !slices.Equal(removed, test.wantRemoved) {
+				t.Errorf("removed buckets = %q, want %q", removed, test.wantRemoved)
+			}
+			if _, kept := test.deleted["bob"]; kept != test.wantKept {
+				t.Errorf("account kept = %v, want %v", kept, test.wantKept)
+			}
+		})
+	}
+}
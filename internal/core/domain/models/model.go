@@ -2,6 +2,58 @@ package models
 
 import (
 	"github.com/dgrijalva/jwt-go"
+	"time"
+)
+
+const (
+	StatusActive   = "active"
+	StatusDisabled = "disabled"
+	StatusLocked   = "locked"
+)
+
+const (
+	AuditRegister          = "user.register"
+	AuditLogin             = "user.login"
+	AuditLoginFailed       = "user.login_failed"
+	AuditRolesAdded        = "user.roles_added"
+	AuditUnregister        = "user.unregister"
+	AuditStatusChanged     = "user.status_changed"
+	AuditRestored          = "user.restored"
+	AuditLockoutCleared    = "user.lockout_cleared"
+	AuditSessionRevoked    = "user.session_revoked"
+	AuditFileUploaded      = "file.uploaded"
+	AuditFileDeleted       = "file.deleted"
+	AuditFileMoved         = "file.moved"
+	AuditFileCopied        = "file.copied"
+	AuditFolderCreated     = "folder.created"
+	AuditFolderDeleted     = "folder.deleted"
+	AuditShareCreated      = "file.shared"
+	AuditShareRevoked      = "file.share_revoked"
+	AuditLinkCreated       = "file.link_created"
+	AuditLinkRevoked       = "file.link_revoked"
+	AuditQuotaChanged      = "user.quota_changed"
+	AuditPurged            = "user.purged"
+	AuditGroupCreated      = "group.created"
+	AuditGroupRenamed      = "group.renamed"
+	AuditGroupDeleted      = "group.deleted"
+	AuditMembersAdded      = "group.members_added"
+	AuditMembersRemoved    = "group.members_removed"
+	AuditGroupRolesAdded   = "group.roles_added"
+	AuditGroupRolesRemoved = "group.roles_removed"
+)
+
+const (
+	RiskAllow  = "allow"
+	RiskFlag   = "flag"
+	RiskStepUp = "step_up"
+	RiskBlock  = "block"
+)
+
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
 )
 
 type RegisterDTO struct {
@@ -27,11 +79,71 @@ type GetUserDataDTO struct {
 	Login string `json:"login" binding:"required"`
 }
 
+type CreateGroupDTO struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type RenameGroupDTO struct {
+	Name    string `json:"name" binding:"required"`
+	NewName string `json:"new-name" binding:"required"`
+}
+
+type DeleteGroupDTO struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type GroupMembersDTO struct {
+	Name   string `json:"name" binding:"required"`
+	Logins string `json:"logins" binding:"required"`
+}
+
+type GroupRolesDTO struct {
+	Name  string `json:"name" binding:"required"`
+	Roles string `json:"roles" binding:"required"`
+}
+
+type ListUsersDTO struct {
+	Cursor      string    `form:"cursor"`
+	Limit       int       `form:"limit"`
+	LoginPrefix string    `form:"login_prefix"`
+	Role        string    `form:"role"`
+	Status      string    `form:"status"`
+	CreatedFrom time.Time `form:"created_from"`
+	CreatedTo   time.Time `form:"created_to"`
+	Sort        string    `form:"sort"`
+	Order       string    `form:"order"`
+}
+
+type SetAccountStatusDTO struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
 type User struct {
-	Id       string
-	Login    string
-	Password string
-	Roles    []string
+	Id           string
+	Login        string
+	Password     string
+	Roles        []string
+	Groups       []string
+	Status       string
+	StatusReason string
+	CreatedAt    time.Time
+	DeletedAt    time.Time
+}
+
+// UsersQuery is a validated ListUsersDTO ready to be run against the repository.
+// AfterValue and AfterId hold the position decoded from the cursor.
+type UsersQuery struct {
+	LoginPrefix string
+	Role        string
+	Status      string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Sort        string
+	Desc        bool
+	Limit       int
+	AfterValue  string
+	AfterId     string
 }
 
 type Role struct {
@@ -39,23 +151,338 @@ type Role struct {
 	Name string
 }
 
+type RoleChange struct {
+	Role   string    `json:"role"`
+	Action string    `json:"action"`
+	At     time.Time `json:"at"`
+}
+
+type ClearLockoutDTO struct {
+	Login string `json:"login"`
+	IP    string `json:"ip"`
+}
+
+type LoginAttempts struct {
+	Failures     int
+	LastFailure  time.Time
+	BlockedUntil time.Time
+}
+
+// RateLimitPolicy allows Capacity requests per Period for every key, refilling
+// the bucket continuously. Key tells what requests are grouped by: "ip", "login"
+// (taken from the request body) or "subject" (login of the access token).
+type RateLimitPolicy struct {
+	Name     string
+	Capacity int
+	Period   time.Duration
+	Key      string
+}
+
+type RateLimitState struct {
+	Allowed bool
+	Tokens  float64
+}
+
+// AuditEvent is an entry of the audit log. Hash covers every other field except Id,
+// including PrevHash, the hash of the entry before it, so entries form a chain.
+type AuditEvent struct {
+	Id        int64             `json:"id"`
+	At        time.Time         `json:"at"`
+	Actor     string            `json:"actor"`
+	Target    string            `json:"target"`
+	Action    string            `json:"action"`
+	IP        string            `json:"ip"`
+	UserAgent string            `json:"user_agent"`
+	RequestId string            `json:"request_id"`
+	Details   map[string]string `json:"details,omitempty"`
+	PrevHash  string            `json:"prev_hash"`
+	Hash      string            `json:"hash"`
+}
+
+// AuditRequest describes the request an audited action is part of. Handlers put
+// it in the request context under AuditRequestKey.
+type AuditRequest struct {
+	Actor     string
+	IP        string
+	UserAgent string
+	RequestId string
+}
+
+type AuditRequestKey struct{}
+
+type AuditLogDTO struct {
+	Before    int64     `form:"before"`
+	Limit     int       `form:"limit"`
+	Actor     string    `form:"actor"`
+	Target    string    `form:"target"`
+	Action    string    `form:"action"`
+	IP        string    `form:"ip"`
+	RequestId string    `form:"request_id"`
+	From      time.Time `form:"from"`
+	To        time.Time `form:"to"`
+}
+
+// AuditQuery selects audit events, newest first. Login matches events where the
+// login is either the actor or the target.
+type AuditQuery struct {
+	Actor     string
+	Target    string
+	Login     string
+	Action    string
+	IP        string
+	RequestId string
+	From      time.Time
+	To        time.Time
+	Before    int64
+	Limit     int
+}
+
+// Session is a successful login and the access token issued by it, whose jti is Id.
+type Session struct {
+	Id         string
+	ProfileId  string
+	IP         string
+	UserAgent  string
+	Device     string
+	Location   GeoLocation
+	RiskScore  int
+	RiskAction string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	RevokedAt  time.Time
+}
+
+// GeoLocation is where an IP is according to the GeoIP database. Country is empty
+// when the IP is unknown; HasCoordinates is false when the database has no position for it.
+type GeoLocation struct {
+	Country        string  `json:"country,omitempty"`
+	City           string  `json:"city,omitempty"`
+	Latitude       float64 `json:"latitude,omitempty"`
+	Longitude      float64 `json:"longitude,omitempty"`
+	HasCoordinates bool    `json:"-"`
+}
+
+// RiskAssessment is the score of a login, from 0 to 100, the factors that raised
+// it and what the configured thresholds make of it.
+type RiskAssessment struct {
+	Score    int
+	Action   string
+	Factors  []string
+	Location GeoLocation
+}
+
+type SessionsDTO struct {
+	Login string `form:"login" binding:"required"`
+}
+
+type ExportDataDTO struct {
+	Login string `json:"login" binding:"required"`
+}
+
+type ExportJob struct {
+	Id         string
+	Login      string
+	Status     string
+	Error      string
+	CreatedAt  time.Time
+	FinishedAt time.Time
+	FilePath   string
+}
+
+type Group struct {
+	Id   string
+	Name string
+}
+
 type TokenClaims struct {
-	Login string   `json:"login"`
-	Roles []string `json:"roles"`
+	Login  string   `json:"login"`
+	Roles  []string `json:"roles"`
+	Groups []string `json:"groups"`
 	jwt.StandardClaims
 }
 
-type DownloadFileDTO struct {
+type DeleteFileDTO struct {
 	Login    string `json:"login" binding:"required"`
 	FileName string `json:"file-name" binding:"required"`
-	Path     string `json:"path" binding:"required"`
 }
 
-type DeleteFileDTO struct {
+type PresignUploadDTO struct {
+	Login       string `json:"login" binding:"required"`
+	FileName    string `json:"file-name" binding:"required"`
+	ContentType string `json:"content-type" binding:"required"`
+	Size        int64  `json:"size" binding:"required,gt=0"`
+	Expires     int    `json:"expires" binding:"gte=0"`
+}
+
+type PresignDownloadDTO struct {
 	Login    string `json:"login" binding:"required"`
 	FileName string `json:"file-name" binding:"required"`
+	Expires  int    `json:"expires" binding:"gte=0"`
 }
 
-type GetFileListDTO struct {
+type ConfirmUploadDTO struct {
 	Login string `json:"login" binding:"required"`
+	Id    string `json:"id" binding:"required"`
+}
+
+// PresignedUpload lets a client send one file straight to object storage, either
+// with a PUT carrying PutHeaders or with a multipart POST of FormData and the file,
+// until ExpiresAt. The file waits in staging until the upload is confirmed.
+type PresignedUpload struct {
+	Id          string
+	ProfileId   string
+	Login       string
+	FileName    string
+	ContentType string
+	Size        int64
+	PutURL      string
+	PutHeaders  map[string]string
+	PostURL     string
+	FormData    map[string]string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Upload is a resumable upload in progress. Of the Offset bytes received so far,
+// all but the last TailSize are in Parts of the multipart upload; the tail is
+// kept aside until there is enough for another part.
+type Upload struct {
+	Id          string
+	ProfileId   string
+	Login       string
+	FileName    string
+	ContentType string
+	Metadata    string
+	MultipartId string
+	Length      int64
+	Offset      int64
+	TailSize    int64
+	Parts       []string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// StorageQuota is what a user stores and may store. A limit of 0 means unlimited.
+// Limits come from the roles of the user unless an Admin overrode them.
+type StorageQuota struct {
+	UsedBytes    int64
+	UsedObjects  int64
+	MaxBytes     int64
+	MaxObjects   int64
+	Overridden   bool
+	ReconciledAt time.Time
+}
+
+// QuotaOverride are limits set for one user; nil ones come from their roles.
+type QuotaOverride struct {
+	MaxBytes   *int64 `json:"max_bytes" binding:"omitempty,gte=0"`
+	MaxObjects *int64 `json:"max_objects" binding:"omitempty,gte=0"`
+}
+
+type QuotaDTO struct {
+	Login string `form:"login"`
+}
+
+// Policies for a move or copy whose destination exists.
+const (
+	ConflictFail       = "fail"
+	ConflictOverwrite  = "overwrite"
+	ConflictAutoRename = "auto-rename"
+)
+
+// Permissions a share grants: read lets the grantee list and download, read-write
+// also delete.
+const (
+	ShareRead      = "read"
+	ShareReadWrite = "read-write"
+)
+
+// Share grants a user, or the members of a group, access to a file of its owner
+// or, when Path ends with "/", to everything in a folder.
+type Share struct {
+	Id           string
+	OwnerId      string
+	OwnerLogin   string
+	Path         string
+	GranteeId    string
+	GranteeLogin string
+	GranteeGroup string
+	Permission   string
+	CreatedAt    time.Time
+}
+
+type ShareFileDTO struct {
+	Login      string `json:"login" binding:"required"`
+	Path       string `json:"path" binding:"required"`
+	User       string `json:"user"`
+	Group      string `json:"group"`
+	Permission string `json:"permission" binding:"required"`
+}
+
+type SharesDTO struct {
+	Login string `form:"login"`
+}
+
+// ShareLink lets anyone holding its token download a file of its owner without an
+// account. Only a hash of the token is kept. Zero ExpiresAt and MaxDownloads mean
+// no limit, an empty PasswordHash no password.
+type ShareLink struct {
+	Id             string
+	OwnerId        string
+	OwnerLogin     string
+	Path           string
+	TokenHash      string
+	PasswordHash   string
+	ExpiresAt      time.Time
+	MaxDownloads   int64
+	Downloads      int64
+	LastAccessedAt time.Time
+	CreatedAt      time.Time
+	RevokedAt      time.Time
+}
+
+type CreateShareLinkDTO struct {
+	Login        string `json:"login" binding:"required"`
+	FileName     string `json:"file-name" binding:"required"`
+	Password     string `json:"password"`
+	Expires      int    `json:"expires" binding:"gte=0"`
+	MaxDownloads int64  `json:"max-downloads" binding:"gte=0"`
+}
+
+type ShareLinksDTO struct {
+	Login string `form:"login"`
+}
+
+type FolderDTO struct {
+	Login  string `json:"login" binding:"required"`
+	Folder string `json:"folder" binding:"required"`
+}
+
+type TransferFileDTO struct {
+	Login       string `json:"login" binding:"required"`
+	Source      string `json:"source" binding:"required"`
+	Destination string `json:"destination" binding:"required"`
+	Conflict    string `json:"conflict"`
+}
+
+type GetFileListDTO struct {
+	Login     string `json:"login" binding:"required"`
+	Prefix    string `json:"prefix"`
+	Delimiter string `json:"delimiter"`
+	Recursive bool   `json:"recursive"`
+	Filter    string `json:"filter"`
+	Sort      string `json:"sort"`
+	Order     string `json:"order"`
+	Cursor    string `json:"cursor"`
+	Limit     int    `json:"limit"`
+}
+
+// FileInfo is a file or, when Folder is set, a common prefix of files in a listing.
+type FileInfo struct {
+	Name         string
+	Folder       bool
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+	ETag         string
 }
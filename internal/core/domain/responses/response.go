@@ -1,9 +1,20 @@
 package responses
 
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"time"
+)
+
 type Error struct {
 	Error string `json:"error"`
 }
 
+type PasswordPolicyError struct {
+	Error    string                      `json:"error"`
+	Failures []customError.PolicyFailure `json:"failures"`
+}
+
 type AddRolesError struct {
 	Error       string            `json:"error"`
 	RolesStatus map[string]string `json:"roles status"`
@@ -19,12 +30,160 @@ type LoginSuccess struct {
 }
 
 type GetUserSuccess struct {
-	Id       string   `json:"id"`
-	Login    string   `json:"login"`
-	Password string   `json:"password"`
-	Roles    []string `json:"roles"`
+	Id           string   `json:"id"`
+	Login        string   `json:"login"`
+	Password     string   `json:"password"`
+	Roles        []string `json:"roles"`
+	Groups       []string `json:"groups"`
+	Status       string   `json:"status"`
+	StatusReason string   `json:"status_reason"`
+}
+
+type GroupStatusError struct {
+	Error  string            `json:"error"`
+	Status map[string]string `json:"status"`
+}
+
+type GroupStatusSuccess struct {
+	Group  string            `json:"group"`
+	Status map[string]string `json:"status"`
+}
+
+type FileSummary struct {
+	Name         string     `json:"name"`
+	Folder       bool       `json:"folder,omitempty"`
+	Size         int64      `json:"size"`
+	ContentType  string     `json:"content_type,omitempty"`
+	LastModified *time.Time `json:"last_modified,omitempty"`
+	ETag         string     `json:"etag,omitempty"`
+}
+
+type TransferFileSuccess struct {
+	Name string `json:"name"`
+}
+
+type ShareSummary struct {
+	Id         string    `json:"id"`
+	Owner      string    `json:"owner"`
+	Path       string    `json:"path"`
+	User       string    `json:"user,omitempty"`
+	Group      string    `json:"group,omitempty"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ListSharesSuccess struct {
+	Shares []ShareSummary `json:"shares"`
+}
+
+type CreateShareLinkSuccess struct {
+	Id        string     `json:"id"`
+	Token     string     `json:"token"`
+	Link      string     `json:"link"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type ShareLinkSummary struct {
+	Id             string     `json:"id"`
+	FileName       string     `json:"file_name"`
+	Protected      bool       `json:"protected"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	MaxDownloads   int64      `json:"max_downloads,omitempty"`
+	Downloads      int64      `json:"downloads"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type ListShareLinksSuccess struct {
+	Links []ShareLinkSummary `json:"links"`
 }
 
 type GetFileListSuccess struct {
-	List []string `json:"files list"`
+	Files      []FileSummary `json:"files"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type UserSummary struct {
+	Id        string    `json:"id"`
+	Login     string    `json:"login"`
+	Roles     []string  `json:"roles"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListUsersSuccess struct {
+	Users      []UserSummary `json:"users"`
+	NextCursor string        `json:"next_cursor"`
+	Total      int           `json:"total"`
+}
+
+type PendingDeletion struct {
+	Id        string    `json:"id"`
+	Login     string    `json:"login"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+type ListPendingDeletionsSuccess struct {
+	Users []PendingDeletion `json:"users"`
+}
+
+type ExportStartedSuccess struct {
+	JobId string `json:"job_id"`
+}
+
+type ExportStatusSuccess struct {
+	JobId      string     `json:"job_id"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+type SessionSummary struct {
+	Id        string     `json:"id"`
+	IP        string     `json:"ip"`
+	UserAgent string     `json:"user_agent"`
+	Device    string     `json:"device"`
+	Country   string     `json:"country,omitempty"`
+	City      string     `json:"city,omitempty"`
+	RiskScore int        `json:"risk_score"`
+	Flagged   bool       `json:"flagged"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Active    bool       `json:"active"`
+	Current   bool       `json:"current"`
+}
+
+type ListSessionsSuccess struct {
+	Sessions []SessionSummary `json:"sessions"`
+}
+
+type AuditLogSuccess struct {
+	Events     []models.AuditEvent `json:"events"`
+	NextBefore int64               `json:"next_before,omitempty"`
+}
+
+type PresignUploadSuccess struct {
+	Id         string            `json:"id"`
+	PutURL     string            `json:"put_url"`
+	PutHeaders map[string]string `json:"put_headers"`
+	PostURL    string            `json:"post_url"`
+	FormData   map[string]string `json:"form_data"`
+	ExpiresAt  time.Time         `json:"expires_at"`
+}
+
+type PresignDownloadSuccess struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type QuotaSuccess struct {
+	UsedBytes    int64      `json:"used_bytes"`
+	UsedObjects  int64      `json:"used_objects"`
+	MaxBytes     int64      `json:"max_bytes"`
+	MaxObjects   int64      `json:"max_objects"`
+	Overridden   bool       `json:"overridden"`
+	ReconciledAt *time.Time `json:"reconciled_at,omitempty"`
 }
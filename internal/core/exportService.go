@@ -0,0 +1,389 @@
+package core
+
+import (
+	"archive/zip"
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultExportTTL = 24 * time.Hour
+
+// ExportService builds archives with all personal data of a user. Archives are
+// built in the background and kept on local disk for EXPORT_TTL after they are ready.
+type ExportService struct {
+	repo        UsersRepository
+	fileStorage FileStorage
+	sessions    SessionsRepository
+	audit       *AuditLog
+	dir         string
+	ttl         time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*models.ExportJob
+}
+
+type exportedProfile struct {
+	Id           string    `json:"id"`
+	Login        string    `json:"login"`
+	Status       string    `json:"status"`
+	StatusReason string    `json:"status_reason"`
+	CreatedAt    time.Time `json:"created_at"`
+	Roles        []string  `json:"roles"`
+	Groups       []string  `json:"groups"`
+}
+
+type exportedSession struct {
+	Id        string             `json:"id"`
+	IP        string             `json:"ip"`
+	UserAgent string             `json:"user_agent"`
+	Device    string             `json:"device"`
+	Location  models.GeoLocation `json:"location"`
+	RiskScore int                `json:"risk_score"`
+	CreatedAt time.Time          `json:"created_at"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	RevokedAt *time.Time         `json:"revoked_at,omitempty"`
+}
+
+type exportedRoles struct {
+	Current []string            `json:"current"`
+	History []models.RoleChange `json:"history"`
+}
+
+func NewExportService(repo UsersRepository, fileStorage FileStorage, sessions SessionsRepository, audit *AuditLog) *ExportService {
+	EXPORT_DIR, ok := os.LookupEnv("EXPORT_DIR")
+	if !ok {
+		EXPORT_DIR = filepath.Join(os.TempDir(), "auth-exports")
+	}
+
+	return &ExportService{
+		repo:        repo,
+		fileStorage: fileStorage,
+		sessions:    sessions,
+		audit:       audit,
+		dir:         EXPORT_DIR,
+		ttl:         envDuration("EXPORT_TTL", defaultExportTTL),
+		jobs:        make(map[string]*models.ExportJob),
+	}
+}
+
+// StartExport queues an export for login and returns the job id. While an export
+// of the user is still being built its id is returned instead of starting a new one.
+func (service *ExportService) StartExport(login string) (string, error) {
+
+	_, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return "", customError.UnexistingLoginError
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.sweep()
+
+	for _, job := range service.jobs {
+		if job.Login == login && (job.Status == models.ExportPending || job.Status == models.ExportRunning) {
+			return job.Id, nil
+		}
+	}
+
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+
+	job := &models.ExportJob{
+		Id:        hex.EncodeToString(id),
+		Login:     login,
+		Status:    models.ExportPending,
+		CreatedAt: time.Now(),
+	}
+	service.jobs[job.Id] = job
+
+	go service.run(job.Id)
+
+	return job.Id, nil
+}
+
+func (service *ExportService) GetExport(id string) (models.ExportJob, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	job, ok := service.jobs[id]
+	if !ok {
+		return models.ExportJob{}, customError.UnexistingExportError
+	}
+
+	return *job, nil
+}
+
+// OpenExport opens the archive of a ready export. The caller must close it.
+func (service *ExportService) OpenExport(id string) (*os.File, error) {
+	job, err := service.GetExport(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status != models.ExportReady {
+		return nil, customError.ExportNotReadyError
+	}
+
+	return os.Open(job.FilePath)
+}
+
+func (service *ExportService) run(id string) {
+	service.update(id, func(job *models.ExportJob) {
+		job.Status = models.ExportRunning
+	})
+
+	job, _ := service.GetExport(id)
+	filePath := filepath.Join(service.dir, job.Id+".zip")
+
+	err := service.build(context.Background(), job.Login, filePath)
+	if err != nil {
+		log.Printf("Export %s of %s failed: %v", job.Id, job.Login, err)
+		os.Remove(filePath)
+	}
+
+	service.update(id, func(job *models.ExportJob) {
+		job.FinishedAt = time.Now()
+		if err != nil {
+			job.Status = models.ExportFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = models.ExportReady
+		job.FilePath = filePath
+	})
+}
+
+func (service *ExportService) update(id string, change func(job *models.ExportJob)) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	job, ok := service.jobs[id]
+	if ok {
+		change(job)
+	}
+}
+
+// RunSweeper removes expired archives every interval until ctx is done, so they do
+// not outlive EXPORT_TTL when no new exports are started. Archives left behind by
+// an earlier run of the service are removed once they are older than the TTL.
+func (service *ExportService) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		service.mu.Lock()
+		service.sweep()
+		known := make(map[string]bool, len(service.jobs))
+		for _, job := range service.jobs {
+			known[filepath.Join(service.dir, job.Id+".zip")] = true
+		}
+		service.mu.Unlock()
+
+		entries, err := os.ReadDir(service.dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			filePath := filepath.Join(service.dir, entry.Name())
+			info, err := entry.Info()
+			if err != nil || entry.IsDir() || known[filePath] || time.Since(info.ModTime()) < service.ttl {
+				continue
+			}
+			err = os.Remove(filePath)
+			if err != nil {
+				log.Printf("Removing stale export %s failed: %v", filePath, err)
+			}
+		}
+	}
+}
+
+// sweep forgets finished jobs older than the TTL and removes their archives.
+// It must be called with mu held.
+func (service *ExportService) sweep() {
+	for id, job := range service.jobs {
+		if job.FinishedAt.IsZero() || time.Since(job.FinishedAt) < service.ttl {
+			continue
+		}
+		if job.FilePath != "" {
+			os.Remove(job.FilePath)
+		}
+		delete(service.jobs, id)
+	}
+}
+
+func (service *ExportService) build(ctx context.Context, login, filePath string) error {
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return customError.UnexistingLoginError
+	}
+
+	history, err := service.repo.GetRoleHistory(profileData.Id)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := service.sessions.GetSessions(profileData.Id)
+	if err != nil {
+		return err
+	}
+
+	events, err := service.audit.EventsOf(profileData.Login)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(service.dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	archive, err := os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	writer := zip.NewWriter(archive)
+
+	err = writeJSON(writer, "profile.json", exportedProfile{
+		Id:           profileData.Id,
+		Login:        profileData.Login,
+		Status:       profileData.Status,
+		StatusReason: profileData.StatusReason,
+		CreatedAt:    profileData.CreatedAt,
+		Roles:        profileData.Roles,
+		Groups:       profileData.Groups,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeJSON(writer, "roles.json", exportedRoles{Current: profileData.Roles, History: history})
+	if err != nil {
+		return err
+	}
+
+	err = writeJSON(writer, "sessions.json", exportSessions(sessions))
+	if err != nil {
+		return err
+	}
+
+	err = writeJSON(writer, "audit.json", events)
+	if err != nil {
+		return err
+	}
+
+	err = service.writeFiles(ctx, writer, profileData)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// writeFiles copies every object of the user's bucket into the files/ folder of
+// the archive. An export that could not list the bucket fails rather than leave
+// files out.
+func (service *ExportService) writeFiles(ctx context.Context, writer *zip.Writer, profileData models.User) error {
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
+
+	fileNames, err := service.fileStorage.GetFileList(ctx, bucketName)
+	if err != nil {
+		return err
+	}
+
+	for _, fileName := range fileNames {
+		name := strings.TrimPrefix(path.Clean("/"+fileName), "/")
+		if name == "" || name != fileName {
+			log.Printf("Export of %s skipped object with unsafe name %q", profileData.Login, fileName)
+			continue
+		}
+
+		err := service.copyToArchive(ctx, writer, "files/"+name, bucketName, fileName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportSessions(sessions []models.Session) []exportedSession {
+	exported := make([]exportedSession, 0, len(sessions))
+	for _, session := range sessions {
+		entry := exportedSession{
+			Id:        session.Id,
+			IP:        session.IP,
+			UserAgent: session.UserAgent,
+			Device:    session.Device,
+			Location:  session.Location,
+			RiskScore: session.RiskScore,
+			CreatedAt: session.CreatedAt,
+			ExpiresAt: session.ExpiresAt,
+		}
+		if !session.RevokedAt.IsZero() {
+			entry.RevokedAt = &session.RevokedAt
+		}
+		exported = append(exported, entry)
+	}
+
+	return exported
+}
+
+func writeJSON(writer *zip.Writer, name string, value interface{}) error {
+	entry, err := writer.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
+
+func (service *ExportService) copyToArchive(ctx context.Context, writer *zip.Writer, name, bucketName, fileName string) error {
+	body, _, err := service.fileStorage.OpenFile(ctx, bucketName, fileName)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	entry, err := writer.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(entry, body)
+
+	return err
+}
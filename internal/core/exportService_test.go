@@ -0,0 +1,93 @@
+package core
+
+import (
+	"archive/zip"
+	"auth/internal/core/domain/models"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// fakeBucket serves the files of a bucket from memory.
+type fakeBucket struct {
+	FileStorage
+	files   map[string]string
+	listErr error
+}
+
+func (storage fakeBucket) GetFileList(context.Context, string) ([]string, error) {
+	if storage.listErr != nil {
+		return nil, storage.listErr
+	}
+
+	names := make([]string, 0, len(storage.files))
+	for name := range storage.files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names, nil
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+func (storage fakeBucket) OpenFile(_ context.Context, _, fileName string) (io.ReadSeekCloser, minio.ObjectInfo, error) {
+	return nopSeekCloser{strings.NewReader(storage.files[fileName])}, minio.ObjectInfo{}, nil
+}
+
+func TestExportWriteFiles(t *testing.T) {
+	errList := errors.New("listing interrupted")
+
+	tests := []struct {
+		name    string
+		storage fakeBucket
+		want    []string
+		wantErr error
+	}{
+		{"files", fakeBucket{files: map[string]string{"a.png": "a", "docs/b.pdf": "b"}}, []string{"files/a.png", "files/docs/b.pdf"}, nil},
+		{"unsafe names skipped", fakeBucket{files: map[string]string{"../a.png": "a", "b.png": "b"}}, []string{"files/b.png"}, nil},
+		{"empty bucket", fakeBucket{files: map[string]string{}}, []string{}, nil},
+		{"listing fails", fakeBucket{listErr: errList}, nil, errList},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := &ExportService{fileStorage: test.storage}
+
+			var buffer bytes.Buffer
+			writer := zip.NewWriter(&buffer)
+			err := service.writeFiles(context.Background(), writer, models.User{Id: "1", Login: "bob"})
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("writeFiles() error = %v, want %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make([]string, 0)
+			for _, file := range archive.File {
+				names = append(names, file.Name)
+			}
+			if !slices.Equal(names, test.want) {
+				t.Errorf("archive holds %q, want %q", names, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFilesPageSize = 100
+	maxFilesPageSize     = 1000
+	// maxFileListPages bounds the storage pages read for one response when a
+	// filter matches little, so a rare name cannot make a request scan a bucket.
+	maxFileListPages = 10
+	// maxSortedFiles bounds the entries held in memory to sort by something other than name.
+	maxSortedFiles = 10000
+)
+
+// filesCursor is the position of the last entry of a page, opaque to clients.
+// It pins the listing it was made for, which a cursor may only continue.
+type filesCursor struct {
+	Sort      string    `json:"s"`
+	Desc      bool      `json:"d"`
+	Prefix    string    `json:"p"`
+	Delimiter string    `json:"l"`
+	Recursive bool      `json:"r"`
+	Filter    string    `json:"f"`
+	Name      string    `json:"n"`
+	Size      int64     `json:"z"`
+	Modified  time.Time `json:"m"`
+}
+
+// GetFileList lists the files of login under a prefix, which requester may list
+// when they are login or an Admin, or when the prefix is a folder ending with "/"
+// shared with them or below one. Files below the next delimiter, "/" unless
+// another is given, are grouped into folders unless the listing is recursive.
+// Entries whose name after the prefix does not contain filter are left out.
+//
+// In ascending name order pages are read from storage as needed. Sorting by size,
+// modification time or descending name reads the whole folder first, which must
+// not hold more than maxSortedFiles matching entries.
+func (service *UserService) GetFileList(ctx context.Context, requester string, params models.GetFileListDTO) ([]models.FileInfo, string, error) {
+
+	profileData, err := service.repo.GetUserByLogin(params.Login)
+	if err != nil {
+		return nil, "", customError.UnexistingLoginError
+	}
+
+	err = service.authorizeListing(requester, profileData, params.Prefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
+
+	query := filesCursor{
+		Sort:      strings.ToLower(params.Sort),
+		Desc:      strings.EqualFold(params.Order, "desc"),
+		Prefix:    params.Prefix,
+		Delimiter: params.Delimiter,
+		Recursive: params.Recursive,
+		Filter:    strings.ToLower(params.Filter),
+	}
+
+	switch query.Sort {
+	case "":
+		query.Sort = "name"
+	case "name", "size", "last_modified":
+	default:
+		return nil, "", customError.InvalidSortError
+	}
+
+	if query.Delimiter == "" {
+		query.Delimiter = "/"
+	}
+	delimiter := query.Delimiter
+	if query.Recursive {
+		delimiter = ""
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultFilesPageSize
+	}
+	if limit > maxFilesPageSize {
+		limit = maxFilesPageSize
+	}
+
+	after := query
+	if params.Cursor != "" {
+		after, err = decodeFilesCursor(params.Cursor)
+		if err != nil || !after.continues(query) {
+			return nil, "", customError.InvalidCursorError
+		}
+	}
+
+	// The marker of the folder being listed is not one of its entries.
+	matches := func(file models.FileInfo) bool {
+		return file.Name != query.Prefix && strings.Contains(strings.ToLower(strings.TrimPrefix(file.Name, query.Prefix)), query.Filter)
+	}
+
+	if query.Sort == "name" && !query.Desc {
+		return service.listFilesInOrder(ctx, bucketName, delimiter, query, after.Name, limit, matches)
+	}
+
+	return service.listFilesSorted(ctx, bucketName, delimiter, query, after, params.Cursor != "", limit, matches)
+}
+
+func (service *UserService) listFilesInOrder(ctx context.Context, bucketName, delimiter string, query filesCursor, after string,
+	limit int, matches func(models.FileInfo) bool) ([]models.FileInfo, string, error) {
+
+	files := make([]models.FileInfo, 0, limit+1)
+	token := ""
+	lastSeen := after
+
+	for page := 0; page < maxFileListPages; page++ {
+		entries, next, err := service.fileStorage.ListFiles(ctx, bucketName, query.Prefix, delimiter, after, token)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, file := range entries {
+			// A folder listed last on the previous page is listed again after it.
+			if file.Name <= after {
+				continue
+			}
+			lastSeen = file.Name
+
+			if !matches(file) {
+				continue
+			}
+			files = append(files, file)
+
+			// One extra entry tells whether there is a next page.
+			if len(files) > limit {
+				files = files[:limit]
+				query.Name = files[limit-1].Name
+				return files, encodeFilesCursor(query), nil
+			}
+		}
+
+		if next == "" {
+			return files, "", nil
+		}
+		token = next
+	}
+
+	// Enough was read for one request; the next one goes on after the last entry seen.
+	query.Name = lastSeen
+
+	return files, encodeFilesCursor(query), nil
+}
+
+func (service *UserService) listFilesSorted(ctx context.Context, bucketName, delimiter string, query, after filesCursor, hasCursor bool,
+	limit int, matches func(models.FileInfo) bool) ([]models.FileInfo, string, error) {
+
+	files := make([]models.FileInfo, 0)
+	token := ""
+
+	for {
+		entries, next, err := service.fileStorage.ListFiles(ctx, bucketName, query.Prefix, delimiter, "", token)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, file := range entries {
+			if !matches(file) {
+				continue
+			}
+			if len(files) == maxSortedFiles {
+				return nil, "", customError.TooManyFilesError
+			}
+			files = append(files, file)
+		}
+
+		if next == "" {
+			break
+		}
+		token = next
+	}
+
+	compare := func(a, b models.FileInfo) int {
+		order := 0
+		switch query.Sort {
+		case "size":
+			order = cmp.Compare(a.Size, b.Size)
+		case "last_modified":
+			order = a.LastModified.Compare(b.LastModified)
+		}
+		if order == 0 {
+			order = strings.Compare(a.Name, b.Name)
+		}
+		if query.Desc {
+			return -order
+		}
+		return order
+	}
+
+	slices.SortFunc(files, compare)
+
+	if hasCursor {
+		position := models.FileInfo{Name: after.Name, Size: after.Size, LastModified: after.Modified}
+		start, found := slices.BinarySearchFunc(files, position, compare)
+		if found {
+			start++
+		}
+		files = files[start:]
+	}
+
+	if len(files) <= limit {
+		return files, "", nil
+	}
+
+	files = files[:limit]
+	last := files[limit-1]
+	query.Name, query.Size, query.Modified = last.Name, last.Size, last.LastModified
+
+	return files, encodeFilesCursor(query), nil
+}
+
+// continues tells whether the cursor was made for the listing query asks for.
+func (cursor filesCursor) continues(query filesCursor) bool {
+	return cursor.Sort == query.Sort && cursor.Desc == query.Desc && cursor.Prefix == query.Prefix &&
+		cursor.Delimiter == query.Delimiter && cursor.Recursive == query.Recursive && cursor.Filter == query.Filter
+}
+
+func encodeFilesCursor(cursor filesCursor) string {
+	data, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeFilesCursor(value string) (filesCursor, error) {
+	var cursor filesCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+
+	err = json.Unmarshal(data, &cursor)
+
+	return cursor, err
+}
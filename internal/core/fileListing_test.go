@@ -0,0 +1,271 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeListing lists files from memory in name order, pageSize entries at a
+// time, the way object storage does.
+type fakeListing struct {
+	FileStorage
+	files    []models.FileInfo
+	pageSize int
+}
+
+func (storage fakeListing) ListFiles(_ context.Context, _, prefix, _, startAfter, token string) ([]models.FileInfo, string, error) {
+	matching := make([]models.FileInfo, 0)
+	for _, file := range storage.files {
+		if strings.HasPrefix(file.Name, prefix) && file.Name > startAfter {
+			matching = append(matching, file)
+		}
+	}
+	slices.SortFunc(matching, func(a, b models.FileInfo) int { return strings.Compare(a.Name, b.Name) })
+
+	start := 0
+	if token != "" {
+		start, _ = strconv.Atoi(token)
+	}
+	end := min(start+storage.pageSize, len(matching))
+	if end == len(matching) {
+		return matching[start:end], "", nil
+	}
+
+	return matching[start:end], strconv.Itoa(end), nil
+}
+
+func testFiles() []models.FileInfo {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	return []models.FileInfo{
+		{Name: "a.png", Size: 30, LastModified: base.Add(4 * time.Hour)},
+		{Name: "b.png", Size: 10, LastModified: base.Add(2 * time.Hour)},
+		{Name: "c.png", Size: 20, LastModified: base.Add(5 * time.Hour)},
+		{Name: "d.png", Size: 10, LastModified: base.Add(1 * time.Hour)},
+		{Name: "e.txt", Size: 50, LastModified: base.Add(3 * time.Hour)},
+		{Name: "f.png", Size: 20, LastModified: base.Add(2 * time.Hour)},
+		{Name: "g.png", Size: 40, LastModified: base.Add(6 * time.Hour)},
+	}
+}
+
+func fileNames(files []models.FileInfo) []string {
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	return names
+}
+
+func TestFilesCursor(t *testing.T) {
+	cursor := filesCursor{
+		Sort:      "size",
+		Desc:      true,
+		Prefix:    "docs/",
+		Delimiter: "-",
+		Recursive: true,
+		Filter:    "png",
+		Name:      "docs/a.png",
+		Size:      42,
+		Modified:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	got, err := decodeFilesCursor(encodeFilesCursor(cursor))
+	if err != nil {
+		t.Fatalf("decodeFilesCursor() error = %v", err)
+	}
+	if got != cursor {
+		t.Errorf("decodeFilesCursor() = %+v, want %+v", got, cursor)
+	}
+
+	invalid := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"n":"ab"}`))},
+		{"not JSON", base64.RawURLEncoding.EncodeToString([]byte("name"))},
+		{"wrong types", base64.RawURLEncoding.EncodeToString([]byte(`{"z":"big"}`))},
+	}
+
+	for _, test := range invalid {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := decodeFilesCursor(test.value); err == nil {
+				t.Errorf("decodeFilesCursor(%q) succeeded, want an error", test.value)
+			}
+		})
+	}
+}
+
+func TestGetFileListCursor(t *testing.T) {
+	service := &UserService{
+		repo:        fakeUsers{users: map[string]models.User{"alice": {Id: "1", Login: "alice"}}},
+		fileStorage: fakeListing{files: testFiles(), pageSize: 3},
+	}
+	first := models.GetFileListDTO{Login: "alice", Filter: "PNG", Limit: 2}
+
+	_, cursor, err := service.GetFileList(context.Background(), "alice", first)
+	if err != nil {
+		t.Fatalf("GetFileList() error = %v", err)
+	}
+	if cursor == "" {
+		t.Fatal("GetFileList() returned no cursor for the first page")
+	}
+
+	tests := []struct {
+		name    string
+		change  func(params *models.GetFileListDTO)
+		invalid bool
+	}{
+		{"same listing", func(*models.GetFileListDTO) {}, false},
+		{"filter in other case", func(params *models.GetFileListDTO) { params.Filter = "png" }, false},
+		{"default delimiter given", func(params *models.GetFileListDTO) { params.Delimiter = "/" }, false},
+		{"other limit", func(params *models.GetFileListDTO) { params.Limit = 5 }, false},
+		{"other filter", func(params *models.GetFileListDTO) { params.Filter = "txt" }, true},
+		{"no filter", func(params *models.GetFileListDTO) { params.Filter = "" }, true},
+		{"other delimiter", func(params *models.GetFileListDTO) { params.Delimiter = "-" }, true},
+		{"recursive", func(params *models.GetFileListDTO) { params.Recursive = true }, true},
+		{"other prefix", func(params *models.GetFileListDTO) { params.Prefix = "docs/" }, true},
+		{"other sort", func(params *models.GetFileListDTO) { params.Sort = "size" }, true},
+		{"other order", func(params *models.GetFileListDTO) { params.Order = "desc" }, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := first
+			params.Cursor = cursor
+			test.change(&params)
+
+			files, _, err := service.GetFileList(context.Background(), "alice", params)
+			if test.invalid {
+				if !errors.Is(err, customError.InvalidCursorError) {
+					t.Errorf("GetFileList() error = %v, want %v", err, customError.InvalidCursorError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetFileList() error = %v", err)
+			}
+			if len(files) == 0 || files[0].Name != "c.png" {
+				t.Errorf("GetFileList() = %q, want it to go on from c.png", fileNames(files))
+			}
+		})
+	}
+}
+
+func TestListFilesSorted(t *testing.T) {
+	service := &UserService{fileStorage: fakeListing{files: testFiles(), pageSize: 3}}
+	images := func(file models.FileInfo) bool { return strings.HasSuffix(file.Name, ".png") }
+
+	tests := []struct {
+		name  string
+		sort  string
+		desc  bool
+		limit int
+		want  []string
+	}{
+		{"size", "size", false, 2, []string{"b.png", "d.png", "c.png", "f.png", "a.png", "g.png"}},
+		{"size descending", "size", true, 4, []string{"g.png", "a.png", "f.png", "c.png", "d.png", "b.png"}},
+		{"last modified", "last_modified", false, 5, []string{"d.png", "b.png", "f.png", "a.png", "c.png", "g.png"}},
+		{"name descending", "name", true, 1, []string{"g.png", "f.png", "d.png", "c.png", "b.png", "a.png"}},
+		{"single page", "size", false, 10, []string{"b.png", "d.png", "c.png", "f.png", "a.png", "g.png"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := filesCursor{Sort: test.sort, Desc: test.desc}
+			after := query
+			hasCursor := false
+			names := make([]string, 0)
+
+			for pages := 0; ; pages++ {
+				if pages > len(test.want) {
+					t.Fatalf("listing did not end after %d pages", pages)
+				}
+
+				files, next, err := service.listFilesSorted(context.Background(), "bucket", "/", query, after, hasCursor, test.limit, images)
+				if err != nil {
+					t.Fatalf("listFilesSorted() error = %v", err)
+				}
+				if len(files) > test.limit {
+					t.Fatalf("listFilesSorted() returned %d files, limit is %d", len(files), test.limit)
+				}
+				names = append(names, fileNames(files)...)
+
+				if next == "" {
+					break
+				}
+				after, err = decodeFilesCursor(next)
+				if err != nil {
+					t.Fatalf("decodeFilesCursor() error = %v", err)
+				}
+				hasCursor = true
+			}
+
+			if !slices.Equal(names, test.want) {
+				t.Errorf("pages = %q, want %q", names, test.want)
+			}
+		})
+	}
+}
+
+func TestListFilesInOrder(t *testing.T) {
+	service := &UserService{fileStorage: fakeListing{files: testFiles(), pageSize: 2}}
+	all := func(models.FileInfo) bool { return true }
+	none := func(models.FileInfo) bool { return false }
+
+	tests := []struct {
+		name    string
+		limit   int
+		matches func(models.FileInfo) bool
+		want    []string
+	}{
+		{"one per page", 1, all, []string{"a.png", "b.png", "c.png", "d.png", "e.txt", "f.png", "g.png"}},
+		{"across storage pages", 3, all, []string{"a.png", "b.png", "c.png", "d.png", "e.txt", "f.png", "g.png"}},
+		{"filtered", 2, func(file models.FileInfo) bool { return file.Size <= 20 }, []string{"b.png", "c.png", "d.png", "f.png"}},
+		{"nothing matches", 2, none, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := filesCursor{Sort: "name"}
+			after := ""
+			names := make([]string, 0)
+
+			for pages := 0; ; pages++ {
+				if pages > len(testFiles()) {
+					t.Fatalf("listing did not end after %d pages", pages)
+				}
+
+				files, next, err := service.listFilesInOrder(context.Background(), "bucket", "/", query, after, test.limit, test.matches)
+				if err != nil {
+					t.Fatalf("listFilesInOrder() error = %v", err)
+				}
+				if len(files) > test.limit {
+					t.Fatalf("listFilesInOrder() returned %d files, limit is %d", len(files), test.limit)
+				}
+				names = append(names, fileNames(files)...)
+
+				if next == "" {
+					break
+				}
+				cursor, err := decodeFilesCursor(next)
+				if err != nil {
+					t.Fatalf("decodeFilesCursor() error = %v", err)
+				}
+				after = cursor.Name
+			}
+
+			if !slices.Equal(names, test.want) {
+				t.Errorf("pages = %q, want %q", names, test.want)
+			}
+		})
+	}
+}
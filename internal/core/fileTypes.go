@@ -0,0 +1,259 @@
+package core
+
+import (
+	"archive/zip"
+	"auth/pkg/customError"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	defaultAllowedTypes = "User:image/jpeg,image/png;Admin:image/jpeg,image/png,application/pdf,application/zip"
+	sniffSize           = 64 << 10
+)
+
+// fileFormat is a format that can be recognised by its content.
+type fileFormat struct {
+	extensions []string
+	// aliases are other content types clients declare for the format.
+	aliases []string
+	// container is the format this one is built on, e.g. a ZIP archive.
+	container string
+}
+
+var fileFormats = map[string]fileFormat{
+	"image/jpeg":                    {extensions: []string{".jpg", ".jpeg"}, aliases: []string{"image/jpg", "image/pjpeg"}},
+	"image/png":                     {extensions: []string{".png"}},
+	"image/gif":                     {extensions: []string{".gif"}},
+	"image/webp":                    {extensions: []string{".webp"}},
+	"image/svg+xml":                 {extensions: []string{".svg"}},
+	"application/pdf":               {extensions: []string{".pdf"}, aliases: []string{"application/x-pdf"}},
+	"application/zip":               {extensions: []string{".zip"}, aliases: []string{"application/x-zip-compressed"}},
+	"application/msword":            {extensions: []string{".doc"}},
+	"application/vnd.ms-excel":      {extensions: []string{".xls"}},
+	"application/vnd.ms-powerpoint": {extensions: []string{".ppt"}},
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": {
+		extensions: []string{".docx"}, container: "application/zip"},
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": {
+		extensions: []string{".xlsx"}, container: "application/zip"},
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": {
+		extensions: []string{".pptx"}, container: "application/zip"},
+}
+
+// Office documents in the old binary formats are all OLE compound files, which
+// only their extension tells apart.
+var compoundFileTypes = []string{"application/msword", "application/vnd.ms-excel", "application/vnd.ms-powerpoint"}
+
+// FileTypePolicy decides which files a user may upload. UPLOAD_ALLOWED_TYPES lists
+// the content types allowed per role as "Role:type,type;Role:type", where "*"
+// stands for every known type; a user may upload what any of their roles allows.
+// A file must have an extension of its type, its declared content type must agree,
+// and its content must be of that type.
+type FileTypePolicy struct {
+	allowed map[string][]string
+}
+
+func NewFileTypePolicy() *FileTypePolicy {
+	UPLOAD_ALLOWED_TYPES, ok := os.LookupEnv("UPLOAD_ALLOWED_TYPES")
+	if !ok {
+		UPLOAD_ALLOWED_TYPES = defaultAllowedTypes
+	}
+
+	policy := &FileTypePolicy{allowed: make(map[string][]string)}
+
+	for _, entry := range strings.Split(UPLOAD_ALLOWED_TYPES, ";") {
+		role, types, found := strings.Cut(entry, ":")
+		role = strings.TrimSpace(role)
+		if !found || role == "" {
+			if strings.TrimSpace(entry) != "" {
+				log.Printf("Invalid UPLOAD_ALLOWED_TYPES entry %q ignored", entry)
+			}
+			continue
+		}
+
+		for _, contentType := range strings.Split(types, ",") {
+			contentType = strings.ToLower(strings.TrimSpace(contentType))
+			if contentType == "*" {
+				for known := range fileFormats {
+					policy.allowed[role] = append(policy.allowed[role], known)
+				}
+				continue
+			}
+			if _, ok := fileFormats[contentType]; !ok {
+				log.Printf("Unknown upload content type %q for role %s ignored", contentType, role)
+				continue
+			}
+			policy.allowed[role] = append(policy.allowed[role], contentType)
+		}
+	}
+
+	return policy
+}
+
+// CheckName checks what is known before a file arrives: its name and the content
+// type the client declares. It returns the content type the file must have.
+func (policy *FileTypePolicy) CheckName(roles []string, fileName, declaredType string) (string, error) {
+
+	extension := strings.ToLower(path.Ext(fileName))
+	if extension == "" {
+		return "", &customError.FileRejected{Reason: "file name has no extension"}
+	}
+
+	contentType := ""
+	for candidate, format := range fileFormats {
+		if slices.Contains(format.extensions, extension) {
+			contentType = candidate
+			break
+		}
+	}
+	if contentType == "" {
+		return "", &customError.FileRejected{Reason: fmt.Sprintf("files with extension %s are not supported", extension)}
+	}
+
+	if !policy.allows(roles, contentType) {
+		return "", &customError.FileRejected{Reason: fmt.Sprintf("your roles may not upload %s files", contentType)}
+	}
+
+	declared, _, _ := mime.ParseMediaType(declaredType)
+	if declared != "" && declared != "application/octet-stream" && declared != contentType &&
+		!slices.Contains(fileFormats[contentType].aliases, declared) {
+		return "", &customError.FileRejected{
+			Reason: fmt.Sprintf("declared content type %s does not match extension %s", declared, extension)}
+	}
+
+	return contentType, nil
+}
+
+// Check checks a whole file and returns its content type.
+func (policy *FileTypePolicy) Check(roles []string, fileName, declaredType string, content io.ReaderAt, size int64) (string, error) {
+
+	contentType, err := policy.CheckName(roles, fileName, declaredType)
+	if err != nil {
+		return "", err
+	}
+
+	detected := detectFileType(content, size)
+	if detected == "" {
+		return "", &customError.FileRejected{Reason: fmt.Sprintf("content is not recognised as %s", contentType)}
+	}
+
+	if detected == contentType || fileFormats[detected].container == contentType {
+		return contentType, nil
+	}
+	if detected == compoundFileTypes[0] && slices.Contains(compoundFileTypes, contentType) {
+		return contentType, nil
+	}
+
+	return "", &customError.FileRejected{Reason: fmt.Sprintf("content is %s, not %s", detected, contentType)}
+}
+
+func (policy *FileTypePolicy) allows(roles []string, contentType string) bool {
+	for _, role := range roles {
+		if slices.Contains(policy.allowed[role], contentType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// detectFileType recognises a file by its magic number, looking into ZIP archives
+// for Office documents and into XML for an svg root element. It returns "" for
+// anything else. Compound files are reported as application/msword.
+func detectFileType(content io.ReaderAt, size int64) string {
+
+	head := make([]byte, min(size, sniffSize))
+	n, err := content.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return ""
+	}
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, []byte("\xFF\xD8\xFF")):
+		return "image/jpeg"
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1A\n")):
+		return "image/png"
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return "image/gif"
+	case len(head) >= 12 && bytes.HasPrefix(head, []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WEBP")):
+		return "image/webp"
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return "application/pdf"
+	case bytes.HasPrefix(head, []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")):
+		return compoundFileTypes[0]
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return detectZipType(content, size)
+	case isSVG(head):
+		return "image/svg+xml"
+	}
+
+	return ""
+}
+
+// detectZipType tells Office Open XML documents from other ZIP archives by the
+// parts listed in the central directory.
+func detectZipType(content io.ReaderAt, size int64) string {
+
+	archive, err := zip.NewReader(content, size)
+	if err != nil {
+		return ""
+	}
+
+	hasContentTypes := false
+	prefixes := make(map[string]bool)
+	for _, file := range archive.File {
+		if file.Name == "[Content_Types].xml" {
+			hasContentTypes = true
+		}
+		if folder, _, found := strings.Cut(file.Name, "/"); found {
+			prefixes[folder] = true
+		}
+	}
+
+	switch {
+	case hasContentTypes && prefixes["word"]:
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case hasContentTypes && prefixes["xl"]:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case hasContentTypes && prefixes["ppt"]:
+		return "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	}
+
+	return "application/zip"
+}
+
+// seekReaderAt reads at an offset by seeking, for bodies without ReadAt.
+type seekReaderAt struct {
+	io.ReadSeeker
+}
+
+func (reader seekReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	_, err := reader.Seek(offset, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.ReadFull(reader, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+func asReaderAt(body io.ReadSeeker) io.ReaderAt {
+	if readerAt, ok := body.(io.ReaderAt); ok {
+		return readerAt
+	}
+
+	return seekReaderAt{body}
+}
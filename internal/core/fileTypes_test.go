@@ -0,0 +1,62 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+// zipOf builds a ZIP archive holding empty files with the given names.
+func zipOf(t *testing.T, names ...string) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for _, name := range names {
+		if _, err := archive.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestDetectFileType(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"jpeg", []byte("\xFF\xD8\xFF\xE0\x00\x10JFIF"), "image/jpeg"},
+		{"png", []byte("\x89PNG\r\n\x1A\n\x00\x00\x00\rIHDR"), "image/png"},
+		{"gif87a", []byte("GIF87a\x01\x00"), "image/gif"},
+		{"gif89a", []byte("GIF89a\x01\x00"), "image/gif"},
+		{"webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{"riff without webp", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), ""},
+		{"pdf", []byte("%PDF-1.7\n"), "application/pdf"},
+		{"compound file", []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1\x00\x00"), "application/msword"},
+		{"docx", zipOf(t, "[Content_Types].xml", "_rels/.rels", "word/document.xml"), "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"xlsx", zipOf(t, "[Content_Types].xml", "xl/workbook.xml"), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{"pptx", zipOf(t, "[Content_Types].xml", "ppt/presentation.xml"), "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+		{"word folder without content types", zipOf(t, "word/document.xml"), "application/zip"},
+		{"zip", zipOf(t, "notes.txt"), "application/zip"},
+		{"empty zip", zipOf(t), "application/zip"},
+		{"truncated zip", []byte("PK\x03\x04\x14\x00"), ""},
+		{"svg", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"/>`), "image/svg+xml"},
+		{"svg with bom", []byte("\xEF\xBB\xBF<svg/>"), "image/svg+xml"},
+		{"other xml", []byte(`<?xml version="1.0"?><html/>`), ""},
+		{"text", []byte("hello"), ""},
+		{"empty", nil, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := detectFileType(bytes.NewReader(test.content), int64(len(test.content))); got != test.want {
+				t.Errorf("detectFileType() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,439 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxFolderFiles bounds the files moved, copied or deleted with one folder.
+	maxFolderFiles = 10000
+	// maxRenameAttempts bounds the numbered names tried to avoid a conflict.
+	maxRenameAttempts = 100
+)
+
+// Folders are virtual: a file named "a/b.png" is in folder "a/". An empty marker
+// object named after a folder keeps it listed while it holds no files.
+
+// validFileName tells whether name can name a file or, ending with "/", a folder:
+// it must be relative and have no empty, "." or ".." segments.
+func validFileName(name string) bool {
+	name = strings.TrimSuffix(name, "/")
+	if name == "" {
+		return false
+	}
+
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+
+	return true
+}
+
+func asFolder(name string) string {
+	if strings.HasSuffix(name, "/") {
+		return name
+	}
+
+	return name + "/"
+}
+
+// numberedName is the nth alternative to a taken name, "a (n).png" for "a.png"
+// and "a (n)/" for folder "a/".
+func numberedName(name string, n int) string {
+	if folder, ok := strings.CutSuffix(name, "/"); ok {
+		return fmt.Sprintf("%s (%d)/", folder, n)
+	}
+
+	extension := path.Ext(path.Base(name))
+
+	return fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, extension), n, extension)
+}
+
+// destinationName applies a conflict policy to a destination, which is in use
+// when taken says so. It returns the name to write to and whether that
+// overwrites what is there.
+func destinationName(name, conflict string, taken func(string) (bool, error), existsErr error) (string, bool, error) {
+
+	exists, err := taken(name)
+	if err != nil || !exists {
+		return name, false, err
+	}
+
+	switch conflict {
+	case models.ConflictOverwrite:
+		return name, true, nil
+	case models.ConflictAutoRename:
+		for n := 1; n <= maxRenameAttempts; n++ {
+			candidate := numberedName(name, n)
+			exists, err = taken(candidate)
+			if err != nil || !exists {
+				return candidate, false, err
+			}
+		}
+	}
+
+	return "", false, existsErr
+}
+
+// CreateFolder creates an empty folder of login and returns its name, which ends with "/".
+func (service *UserService) CreateFolder(ctx context.Context, login, folder string) (string, error) {
+
+	if !validFileName(folder) {
+		return "", customError.InvalidFileNameError
+	}
+	folder = asFolder(folder)
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return "", customError.UnexistingLoginError
+	}
+
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
+
+	exists, err := service.folderExists(ctx, bucketName, folder)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", customError.ExistingFolderError
+	}
+
+	err = service.fileStorage.CreateFolder(ctx, bucketName, folder)
+	if err != nil {
+		return "", err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditFolderCreated, profileData.Login, map[string]string{"folder": folder})
+
+	return folder, nil
+}
+
+// DeleteFolder deletes a folder of login with everything in it and the shares and
+// links on it, and returns the number of files deleted.
+func (service *UserService) DeleteFolder(ctx context.Context, login, folder string) (int, error) {
+
+	if !validFileName(folder) {
+		return 0, customError.InvalidFileNameError
+	}
+	folder = asFolder(folder)
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return 0, customError.UnexistingLoginError
+	}
+
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
+
+	files, err := service.folderFiles(ctx, bucketName, folder)
+	if err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		return 0, customError.UnexistingFolderError
+	}
+
+	names := make([]string, 0, len(files))
+	var bytes, count int64
+	for _, file := range files {
+		names = append(names, file.Name)
+		if !file.Folder {
+			bytes += file.Size
+			count++
+		}
+	}
+
+	err = service.fileStorage.RemoveFiles(ctx, bucketName, names)
+	if err != nil {
+		return 0, err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditFolderDeleted, profileData.Login, map[string]string{
+		"folder": folder, "files": strconv.FormatInt(count, 10),
+	})
+
+	err = service.unshare(profileData.Id, folder)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), service.quotas.ReleaseFiles(profileData.Id, bytes, count)
+}
+
+// MoveFile renames a file of login, or a folder when source ends with "/", and
+// returns the name it got. A destination ending with "/" names the folder a file
+// is moved into. conflict says what happens when the destination exists. Shares
+// and links on the old name end.
+func (service *UserService) MoveFile(ctx context.Context, login, source, destination, conflict string) (string, error) {
+	return service.transfer(ctx, login, source, destination, conflict, true)
+}
+
+// CopyFile copies a file of login, or a folder when source ends with "/", and
+// returns the name of the copy. The copy counts against the storage quota.
+func (service *UserService) CopyFile(ctx context.Context, login, source, destination, conflict string) (string, error) {
+	return service.transfer(ctx, login, source, destination, conflict, false)
+}
+
+func (service *UserService) transfer(ctx context.Context, login, source, destination, conflict string, move bool) (string, error) {
+
+	switch conflict {
+	case "":
+		conflict = models.ConflictFail
+	case models.ConflictFail, models.ConflictOverwrite, models.ConflictAutoRename:
+	default:
+		return "", customError.InvalidConflictError
+	}
+
+	if !validFileName(source) || !validFileName(destination) {
+		return "", customError.InvalidFileNameError
+	}
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return "", customError.UnexistingLoginError
+	}
+
+	roles, err := service.repo.GetUserRolesByLogin(login)
+	if err != nil {
+		return "", err
+	}
+
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
+
+	var name string
+	if strings.HasSuffix(source, "/") {
+		name, err = service.transferFolder(ctx, profileData.Id, roles, bucketName, source, asFolder(destination), conflict, move)
+	} else {
+		if strings.HasSuffix(destination, "/") {
+			destination += path.Base(source)
+		}
+		name, err = service.transferFile(ctx, profileData.Id, roles, bucketName, source, destination, conflict, move)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	action := models.AuditFileCopied
+	if move {
+		action = models.AuditFileMoved
+	}
+	service.audit.RecordAction(ctx, action, profileData.Login, map[string]string{
+		"source": source, "destination": name, "conflict": conflict,
+	})
+
+	return name, nil
+}
+
+func (service *UserService) transferFile(ctx context.Context, profileId string, roles []string, bucketName, source, destination, conflict string,
+	move bool) (string, error) {
+
+	info, err := service.fileStorage.GetFile(ctx, bucketName, source)
+	if err != nil {
+		return "", customError.UnexistingFileError
+	}
+
+	// The extension decides the type of a file, so a new name must keep it.
+	_, err = service.fileTypes.CheckName(roles, destination, info.ContentType)
+	if err != nil {
+		return "", err
+	}
+
+	if source == destination && conflict == models.ConflictOverwrite {
+		return source, nil
+	}
+
+	var replacedSize int64
+	taken := func(name string) (bool, error) {
+		existing, err := service.fileStorage.GetFile(ctx, bucketName, name)
+		replacedSize = existing.Size
+		return err == nil, nil
+	}
+
+	destination, overwrite, err := destinationName(destination, conflict, taken, customError.ExistingFileError)
+	if err != nil {
+		return "", err
+	}
+
+	if !move {
+		err = service.quotas.Reserve(profileId, roles, info.Size)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = service.fileStorage.CopyFile(ctx, bucketName, source, destination)
+	if err != nil {
+		if !move {
+			service.quotas.Release(profileId, info.Size)
+		}
+		return "", err
+	}
+
+	if overwrite {
+		err = service.quotas.Release(profileId, replacedSize)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if move {
+		err = service.fileStorage.DeleteFile(ctx, bucketName, source)
+		if err != nil {
+			return "", err
+		}
+
+		err = service.unshare(profileId, source)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return destination, nil
+}
+
+// transferFolder copies every file below source to the same name below
+// destination, and deletes the sources of a move once all are copied. A failure
+// part way leaves the copies made so far, and the sources of a move untouched.
+func (service *UserService) transferFolder(ctx context.Context, profileId string, roles []string, bucketName, source, destination, conflict string,
+	move bool) (string, error) {
+
+	if strings.HasPrefix(destination, source) || strings.HasPrefix(source, destination) {
+		return "", customError.FolderOverlapError
+	}
+
+	files, err := service.folderFiles(ctx, bucketName, source)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", customError.UnexistingFolderError
+	}
+
+	taken := func(name string) (bool, error) {
+		return service.folderExists(ctx, bucketName, name)
+	}
+
+	destination, overwrite, err := destinationName(destination, conflict, taken, customError.ExistingFolderError)
+	if err != nil {
+		return "", err
+	}
+
+	replaced := make(map[string]int64)
+	if overwrite {
+		existing, err := service.folderFiles(ctx, bucketName, destination)
+		if err != nil {
+			return "", err
+		}
+		for _, file := range existing {
+			if !file.Folder {
+				replaced[file.Name] = file.Size
+			}
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	var bytes, count int64
+	for _, file := range files {
+		names = append(names, file.Name)
+		if !file.Folder {
+			bytes += file.Size
+			count++
+		}
+	}
+
+	if !move {
+		err = service.quotas.ReserveFiles(profileId, roles, bytes, count)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var copiedBytes, copied, replacedBytes, replacedCount int64
+	for _, file := range files {
+		target := destination + strings.TrimPrefix(file.Name, source)
+
+		err = service.fileStorage.CopyFile(ctx, bucketName, file.Name, target)
+		if err != nil {
+			break
+		}
+
+		if file.Folder {
+			continue
+		}
+		copiedBytes += file.Size
+		copied++
+		if size, ok := replaced[target]; ok {
+			replacedBytes += size
+			replacedCount++
+		}
+	}
+
+	if !move && err != nil {
+		service.quotas.ReleaseFiles(profileId, bytes-copiedBytes, count-copied)
+	}
+	if replacedCount > 0 {
+		releaseErr := service.quotas.ReleaseFiles(profileId, replacedBytes, replacedCount)
+		if err == nil {
+			err = releaseErr
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if move {
+		err = service.fileStorage.RemoveFiles(ctx, bucketName, names)
+		if err != nil {
+			return "", err
+		}
+
+		err = service.unshare(profileId, source)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return destination, nil
+}
+
+// folderExists tells whether a folder has a marker or any file.
+func (service *UserService) folderExists(ctx context.Context, bucketName, folder string) (bool, error) {
+
+	entries, _, err := service.fileStorage.ListFiles(ctx, bucketName, folder, "/", "", "")
+	if err != nil {
+		return false, err
+	}
+
+	return len(entries) > 0, nil
+}
+
+// folderFiles returns every file and folder marker below folder, including its own marker.
+func (service *UserService) folderFiles(ctx context.Context, bucketName, folder string) ([]models.FileInfo, error) {
+
+	files := make([]models.FileInfo, 0)
+	token := ""
+
+	for {
+		entries, next, err := service.fileStorage.ListFiles(ctx, bucketName, folder, "", "", token)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(files)+len(entries) > maxFolderFiles {
+			return nil, customError.FolderTooLargeError
+		}
+		files = append(files, entries...)
+
+		if next == "" {
+			return files, nil
+		}
+		token = next
+	}
+}
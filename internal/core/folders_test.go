@@ -0,0 +1,101 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"errors"
+	"testing"
+)
+
+func TestValidFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"a.png", true},
+		{"a/b.png", true},
+		{"a/", true},
+		{"", false},
+		{"/", false},
+		{"/a.png", false},
+		{"a//b.png", false},
+		{"a/./b.png", false},
+		{"a/../b.png", false},
+		{"..", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := validFileName(test.name); got != test.want {
+				t.Errorf("validFileName(%q) = %v, want %v", test.name, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNumberedName(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want string
+	}{
+		{"a.png", 1, "a (1).png"},
+		{"a", 1, "a (1)"},
+		{"a/", 1, "a (1)/"},
+		{"a/b/", 3, "a/b (3)/"},
+		{"dir.x/file", 2, "dir.x/file (2)"},
+		{"a.tar.gz", 1, "a.tar (1).gz"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := numberedName(test.name, test.n); got != test.want {
+				t.Errorf("numberedName(%q, %d) = %q, want %q", test.name, test.n, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDestinationName(t *testing.T) {
+	errStorage := errors.New("storage unavailable")
+
+	takenNames := func(names ...string) func(string) (bool, error) {
+		return func(name string) (bool, error) {
+			for _, taken := range names {
+				if name == taken {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+	}
+
+	tests := []struct {
+		name          string
+		conflict      string
+		taken         func(string) (bool, error)
+		want          string
+		wantOverwrite bool
+		wantErr       error
+	}{
+		{"free", models.ConflictFail, takenNames(), "a.png", false, nil},
+		{"fail", models.ConflictFail, takenNames("a.png"), "", false, customError.ExistingFileError},
+		{"overwrite", models.ConflictOverwrite, takenNames("a.png"), "a.png", true, nil},
+		{"auto-rename", models.ConflictAutoRename, takenNames("a.png"), "a (1).png", false, nil},
+		{"auto-rename skips taken", models.ConflictAutoRename, takenNames("a.png", "a (1).png", "a (2).png"), "a (3).png", false, nil},
+		{"auto-rename gives up", models.ConflictAutoRename, func(string) (bool, error) { return true, nil }, "", false, customError.ExistingFileError},
+		{"error", models.ConflictOverwrite, func(string) (bool, error) { return false, errStorage }, "a.png", false, errStorage},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, overwrite, err := destinationName("a.png", test.conflict, test.taken, customError.ExistingFileError)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("destinationName() error = %v, want %v", err, test.wantErr)
+			}
+			if got != test.want || overwrite != test.wantOverwrite {
+				t.Errorf("destinationName() = %q, %v, want %q, %v", got, overwrite, test.want, test.wantOverwrite)
+			}
+		})
+	}
+}
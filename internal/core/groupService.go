@@ -0,0 +1,294 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"slices"
+	"strings"
+)
+
+type GroupsRepository interface {
+	GetGroupByName(name string) (models.Group, error)
+	GetGroupMembers(groupId string) ([]string, error)
+	GetGroupRoles(groupId string) ([]string, error)
+
+	CreateGroup(name string) error
+	RenameGroup(groupId, newName string) error
+	DeleteGroup(groupId string) error
+	AddMember(groupId, profileId string) error
+	RemoveMember(groupId, profileId string) error
+	AddRole(groupId, roleId string) error
+	RemoveRole(groupId, roleId string) error
+}
+
+type GroupService struct {
+	repo      GroupsRepository
+	usersRepo UsersRepository
+	audit     *AuditLog
+}
+
+// NewGroupService creates the service. Changes to groups, which grant roles to
+// their members, are recorded in audit.
+func NewGroupService(repo GroupsRepository, usersRepo UsersRepository, audit *AuditLog) *GroupService {
+	return &GroupService{
+		repo:      repo,
+		usersRepo: usersRepo,
+		audit:     audit,
+	}
+}
+
+func (service *GroupService) CreateGroup(ctx context.Context, name string) error {
+
+	_, err := service.repo.GetGroupByName(name)
+	if err == nil {
+		return customError.ExistingGroupError
+	}
+
+	err = service.repo.CreateGroup(name)
+	if err != nil {
+		return err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditGroupCreated, name, nil)
+
+	return nil
+}
+
+func (service *GroupService) RenameGroup(ctx context.Context, name, newName string) error {
+
+	group, err := service.repo.GetGroupByName(name)
+	if err != nil {
+		return customError.UnexistingGroupError
+	}
+
+	_, err = service.repo.GetGroupByName(newName)
+	if err == nil {
+		return customError.ExistingGroupError
+	}
+
+	err = service.repo.RenameGroup(group.Id, newName)
+	if err != nil {
+		return err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditGroupRenamed, newName, map[string]string{"old_name": name})
+
+	return nil
+}
+
+func (service *GroupService) DeleteGroup(ctx context.Context, name string) error {
+
+	group, err := service.repo.GetGroupByName(name)
+	if err != nil {
+		return customError.UnexistingGroupError
+	}
+
+	err = service.repo.DeleteGroup(group.Id)
+	if err != nil {
+		return err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditGroupDeleted, name, nil)
+
+	return nil
+}
+
+func (service *GroupService) AddMembers(ctx context.Context, name, loginsString string) (map[string]string, error) {
+
+	group, err := service.repo.GetGroupByName(name)
+	if err != nil {
+		return nil, customError.UnexistingGroupError
+	}
+
+	members, err := service.repo.GetGroupMembers(group.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	membersStatus := make(map[string]string)
+	var added []string
+	defer func() {
+		if len(added) > 0 {
+			service.audit.RecordAction(ctx, models.AuditMembersAdded, name, map[string]string{"logins": strings.Join(added, " ")})
+		}
+	}()
+
+	for _, login := range strings.Fields(loginsString) {
+		profileData, err := service.usersRepo.GetUserByLogin(login)
+		if err != nil {
+			membersStatus[login] = customError.UnexistingLoginError.Error()
+			continue
+		}
+
+		if slices.Contains(members, profileData.Login) {
+			membersStatus[login] = "user is already a member of this group"
+			continue
+		}
+
+		err = service.repo.AddMember(group.Id, profileData.Id)
+		if err != nil {
+			return membersStatus, err
+		}
+
+		members = append(members, profileData.Login)
+		added = append(added, profileData.Login)
+		membersStatus[login] = "user was successfully added"
+	}
+
+	return membersStatus, nil
+}
+
+func (service *GroupService) RemoveMembers(ctx context.Context, name, loginsString string) (map[string]string, error) {
+
+	group, err := service.repo.GetGroupByName(name)
+	if err != nil {
+		return nil, customError.UnexistingGroupError
+	}
+
+	members, err := service.repo.GetGroupMembers(group.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	membersStatus := make(map[string]string)
+	var removed []string
+	defer func() {
+		if len(removed) > 0 {
+			service.audit.RecordAction(ctx, models.AuditMembersRemoved, name, map[string]string{"logins": strings.Join(removed, " ")})
+		}
+	}()
+
+	for _, login := range strings.Fields(loginsString) {
+		profileData, err := service.usersRepo.GetUserByLogin(login)
+		if err != nil {
+			membersStatus[login] = customError.UnexistingLoginError.Error()
+			continue
+		}
+
+		if !slices.Contains(members, profileData.Login) {
+			membersStatus[login] = "user is not a member of this group"
+			continue
+		}
+
+		err = service.repo.RemoveMember(group.Id, profileData.Id)
+		if err != nil {
+			return membersStatus, err
+		}
+
+		removed = append(removed, profileData.Login)
+		membersStatus[login] = "user was successfully removed"
+	}
+
+	return membersStatus, nil
+}
+
+func (service *GroupService) AddRoles(ctx context.Context, name, rolesString string) (map[string]string, error) {
+
+	group, err := service.repo.GetGroupByName(name)
+	if err != nil {
+		return nil, customError.UnexistingGroupError
+	}
+
+	oldRoles, err := service.repo.GetGroupRoles(group.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	existingRoles, err := service.usersRepo.GetRolesListAsMap()
+	if err != nil {
+		return nil, err
+	}
+
+	rolesStatus := make(map[string]string)
+	var added []string
+	defer func() {
+		if len(added) > 0 {
+			service.audit.RecordAction(ctx, models.AuditGroupRolesAdded, name, map[string]string{"roles": strings.Join(added, " ")})
+		}
+	}()
+
+	for _, role := range parseRoles(rolesString) {
+		if !existingRoles[role] {
+			rolesStatus[role] = "this role does not exist"
+			continue
+		}
+
+		if slices.Contains(oldRoles, role) {
+			rolesStatus[role] = "group already has this role"
+			continue
+		}
+
+		id, err := service.usersRepo.GetRoleIdByName(role)
+		if err != nil {
+			rolesStatus[role] = err.Error()
+			continue
+		}
+
+		err = service.repo.AddRole(group.Id, id)
+		if err != nil {
+			return rolesStatus, err
+		}
+
+		oldRoles = append(oldRoles, role)
+		added = append(added, role)
+		rolesStatus[role] = "role was successfully added"
+	}
+
+	return rolesStatus, nil
+}
+
+func (service *GroupService) RemoveRoles(ctx context.Context, name, rolesString string) (map[string]string, error) {
+
+	group, err := service.repo.GetGroupByName(name)
+	if err != nil {
+		return nil, customError.UnexistingGroupError
+	}
+
+	oldRoles, err := service.repo.GetGroupRoles(group.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	rolesStatus := make(map[string]string)
+	var removed []string
+	defer func() {
+		if len(removed) > 0 {
+			service.audit.RecordAction(ctx, models.AuditGroupRolesRemoved, name, map[string]string{"roles": strings.Join(removed, " ")})
+		}
+	}()
+
+	for _, role := range parseRoles(rolesString) {
+		if !slices.Contains(oldRoles, role) {
+			rolesStatus[role] = "group does not have this role"
+			continue
+		}
+
+		id, err := service.usersRepo.GetRoleIdByName(role)
+		if err != nil {
+			rolesStatus[role] = err.Error()
+			continue
+		}
+
+		err = service.repo.RemoveRole(group.Id, id)
+		if err != nil {
+			return rolesStatus, err
+		}
+
+		removed = append(removed, role)
+		rolesStatus[role] = "role was successfully removed"
+	}
+
+	return rolesStatus, nil
+}
+
+// parseRoles splits a space separated list of roles and normalizes every name
+// the same way AddRoles does for users.
+func parseRoles(rolesString string) []string {
+	roles := strings.Fields(rolesString)
+	for i, el := range roles {
+		roles[i] = strings.Title(strings.ToLower(el))
+	}
+
+	return roles
+}
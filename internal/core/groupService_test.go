@@ -0,0 +1,207 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// fakeAuditStore keeps the events appended to it.
+type fakeAuditStore struct {
+	AuditStore
+	events *[]models.AuditEvent
+}
+
+func (store fakeAuditStore) AppendAudit(event models.AuditEvent, _ func(models.AuditEvent) string) (models.AuditEvent, error) {
+	*store.events = append(*store.events, event)
+	return event, nil
+}
+
+func newTestAuditLog() (*AuditLog, *[]models.AuditEvent) {
+	events := make([]models.AuditEvent, 0)
+	return NewAuditLog(fakeAuditStore{events: &events}), &events
+}
+
+// fakeRoles adds the known roles to fakeUsers.
+type fakeRoles struct {
+	fakeUsers
+}
+
+func (repo fakeRoles) GetRolesListAsMap() (map[string]bool, error) {
+	return map[string]bool{"User": true, "Admin": true, "Editor": true}, nil
+}
+
+func (repo fakeRoles) GetRoleIdByName(role string) (string, error) {
+	return "role-" + role, nil
+}
+
+// fakeGroups holds one group, "staff", in memory.
+type fakeGroups struct {
+	GroupsRepository
+	members *[]string
+	roles   *[]string
+	failOn  string
+}
+
+func (repo fakeGroups) GetGroupByName(name string) (models.Group, error) {
+	if name != "staff" {
+		return models.Group{}, customError.UnexistingGroupError
+	}
+	return models.Group{Id: "g1", Name: name}, nil
+}
+
+func (repo fakeGroups) GetGroupMembers(string) ([]string, error) {
+	return slices.Clone(*repo.members), nil
+}
+
+func (repo fakeGroups) GetGroupRoles(string) ([]string, error) {
+	return slices.Clone(*repo.roles), nil
+}
+
+func (repo fakeGroups) AddMember(_, profileId string) error {
+	if profileId == repo.failOn {
+		return errors.New("database unavailable")
+	}
+	*repo.members = append(*repo.members, profileId)
+	return nil
+}
+
+func (repo fakeGroups) RemoveMember(_, profileId string) error {
+	*repo.members = slices.DeleteFunc(*repo.members, func(member string) bool { return member == profileId })
+	return nil
+}
+
+func (repo fakeGroups) AddRole(_, roleId string) error {
+	*repo.roles = append(*repo.roles, roleId)
+	return nil
+}
+
+func (repo fakeGroups) RemoveRole(_, roleId string) error {
+	*repo.roles = slices.DeleteFunc(*repo.roles, func(role string) bool { return "role-"+role == roleId })
+	return nil
+}
+
+func (repo fakeGroups) DeleteGroup(string) error {
+	return nil
+}
+
+func TestGroupServiceAudit(t *testing.T) {
+	users := fakeRoles{fakeUsers{users: map[string]models.User{
+		"alice": {Id: "alice", Login: "alice"},
+		"bob":   {Id: "bob", Login: "bob"},
+		"carol": {Id: "carol", Login: "carol"},
+	}}}
+	ctx := context.WithValue(context.Background(), models.AuditRequestKey{}, models.AuditRequest{Actor: "admin"})
+
+	tests := []struct {
+		name        string
+		failOn      string
+		change      func(service *GroupService) error
+		wantErr     bool
+		wantAction  string
+		wantDetails map[string]string
+	}{
+		{
+			name: "members added",
+			change: func(service *GroupService) error {
+				_, err := service.AddMembers(ctx, "staff", "bob nobody alice carol")
+				return err
+			},
+			wantAction:  models.AuditMembersAdded,
+			wantDetails: map[string]string{"logins": "bob carol"},
+		},
+		{
+			name:   "members added before a failure",
+			failOn: "carol",
+			change: func(service *GroupService) error {
+				_, err := service.AddMembers(ctx, "staff", "bob carol")
+				return err
+			},
+			wantErr:     true,
+			wantAction:  models.AuditMembersAdded,
+			wantDetails: map[string]string{"logins": "bob"},
+		},
+		{
+			name: "members removed",
+			change: func(service *GroupService) error {
+				_, err := service.RemoveMembers(ctx, "staff", "alice bob")
+				return err
+			},
+			wantAction:  models.AuditMembersRemoved,
+			wantDetails: map[string]string{"logins": "alice"},
+		},
+		{
+			name: "roles added",
+			change: func(service *GroupService) error {
+				_, err := service.AddRoles(ctx, "staff", "editor user ghost admin")
+				return err
+			},
+			wantAction:  models.AuditGroupRolesAdded,
+			wantDetails: map[string]string{"roles": "Editor Admin"},
+		},
+		{
+			name: "roles removed",
+			change: func(service *GroupService) error {
+				_, err := service.RemoveRoles(ctx, "staff", "User Admin")
+				return err
+			},
+			wantAction:  models.AuditGroupRolesRemoved,
+			wantDetails: map[string]string{"roles": "User"},
+		},
+		{
+			name:       "group deleted",
+			change:     func(service *GroupService) error { return service.DeleteGroup(ctx, "staff") },
+			wantAction: models.AuditGroupDeleted,
+		},
+		{
+			name: "nothing changed",
+			change: func(service *GroupService) error {
+				_, err := service.AddMembers(ctx, "staff", "alice nobody")
+				return err
+			},
+		},
+		{
+			name:    "unknown group",
+			change:  func(service *GroupService) error { return service.DeleteGroup(ctx, "ghosts") },
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			audit, events := newTestAuditLog()
+			members, roles := []string{"alice"}, []string{"User"}
+			service := NewGroupService(fakeGroups{members: &members, roles: &roles, failOn: test.failOn}, users, audit)
+
+			err := test.change(service)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("change error = %v, want error %v", err, test.wantErr)
+			}
+
+			if test.wantAction == "" {
+				if len(*events) != 0 {
+					t.Errorf("recorded %+v, want nothing", *events)
+				}
+				return
+			}
+			if len(*events) != 1 {
+				t.Fatalf("recorded %d events, want 1", len(*events))
+			}
+			event := (*events)[0]
+			if event.Action != test.wantAction || event.Actor != "admin" || event.Target != "staff" {
+				t.Errorf("recorded %s by %s on %s, want %s by admin on staff", event.Action, event.Actor, event.Target, test.wantAction)
+			}
+			if len(event.Details) != len(test.wantDetails) {
+				t.Errorf("details = %v, want %v", event.Details, test.wantDetails)
+			}
+			for key, value := range test.wantDetails {
+				if event.Details[key] != value {
+					t.Errorf("details = %v, want %v", event.Details, test.wantDetails)
+				}
+			}
+		})
+	}
+}
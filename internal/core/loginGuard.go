@@ -0,0 +1,183 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"time"
+)
+
+type LoginAttemptStore interface {
+	GetAttempts(key string) (models.LoginAttempts, error)
+	RecordFailure(key string, at time.Time, window time.Duration) (models.LoginAttempts, error)
+	Block(key string, until time.Time) error
+	ResetAttempts(key string) error
+}
+
+// attemptPolicy tells after how many failures inside the window logins are
+// slowed down with exponential back-off and after how many they are locked out.
+type attemptPolicy struct {
+	backoffAfter int
+	lockoutAfter int
+}
+
+// LoginGuard throttles password guessing. Failures are counted per login and per
+// client IP, every counter with its own policy.
+type LoginGuard struct {
+	store           LoginAttemptStore
+	loginPolicy     attemptPolicy
+	ipPolicy        attemptPolicy
+	window          time.Duration
+	backoffBase     time.Duration
+	backoffMax      time.Duration
+	lockoutDuration time.Duration
+}
+
+func NewLoginGuard(store LoginAttemptStore) *LoginGuard {
+	return &LoginGuard{
+		store: store,
+		loginPolicy: attemptPolicy{
+			backoffAfter: envInt("LOGIN_BACKOFF_AFTER", 5),
+			lockoutAfter: envInt("LOGIN_LOCKOUT_AFTER", 10),
+		},
+		ipPolicy: attemptPolicy{
+			backoffAfter: envInt("LOGIN_IP_BACKOFF_AFTER", 20),
+			lockoutAfter: envInt("LOGIN_IP_LOCKOUT_AFTER", 50),
+		},
+		window:          envDuration("LOGIN_ATTEMPT_WINDOW", time.Hour),
+		backoffBase:     envDuration("LOGIN_BACKOFF_BASE", time.Second),
+		backoffMax:      envDuration("LOGIN_BACKOFF_MAX", 15*time.Minute),
+		lockoutDuration: envDuration("LOGIN_LOCKOUT_DURATION", 30*time.Minute),
+	}
+}
+
+// Window is how long a failure counts against a login or IP.
+func (guard *LoginGuard) Window() time.Duration {
+	return guard.window
+}
+
+// Check returns customError.TooManyAttempts while either the login or the IP is blocked.
+func (guard *LoginGuard) Check(login, ip string) error {
+	var retryAfter time.Duration
+
+	for _, key := range attemptKeys(login, ip) {
+		attempts, err := guard.store.GetAttempts(key)
+		if err != nil {
+			return err
+		}
+
+		if wait := time.Until(attempts.BlockedUntil); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter > 0 {
+		return &customError.TooManyAttempts{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// Failure records a failed login and blocks the login or IP once it crossed its policy.
+func (guard *LoginGuard) Failure(login, ip string) error {
+	now := time.Now()
+
+	for _, key := range attemptKeys(login, ip) {
+		attempts, err := guard.store.RecordFailure(key, now, guard.window)
+		if err != nil {
+			return err
+		}
+
+		policy := guard.loginPolicy
+		if key == ipKey(ip) {
+			policy = guard.ipPolicy
+		}
+
+		delay := guard.delay(policy, attempts.Failures)
+		if delay > 0 {
+			err = guard.store.Block(key, now.Add(delay))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Failures returns the failed attempts within the attempt window of the login or
+// of the IP, whichever has more.
+func (guard *LoginGuard) Failures(login, ip string) (int, error) {
+	failures := 0
+
+	for _, key := range attemptKeys(login, ip) {
+		attempts, err := guard.store.GetAttempts(key)
+		if err != nil {
+			return 0, err
+		}
+
+		if time.Since(attempts.LastFailure) <= guard.window {
+			failures = max(failures, attempts.Failures)
+		}
+	}
+
+	return failures, nil
+}
+
+// Success forgets failures of the login. The IP counter is kept, so one known
+// password does not let a client keep guessing others.
+func (guard *LoginGuard) Success(login string) error {
+	return guard.store.ResetAttempts(loginKey(login))
+}
+
+func (guard *LoginGuard) Clear(login, ip string) error {
+	if login == "" && ip == "" {
+		return customError.NoLockoutTargetError
+	}
+
+	for _, key := range attemptKeys(login, ip) {
+		err := guard.store.ResetAttempts(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (guard *LoginGuard) delay(policy attemptPolicy, failures int) time.Duration {
+	if policy.lockoutAfter > 0 && failures >= policy.lockoutAfter {
+		return guard.lockoutDuration
+	}
+
+	if policy.backoffAfter == 0 || failures < policy.backoffAfter {
+		return 0
+	}
+
+	delay := guard.backoffBase
+	for i := policy.backoffAfter; i < failures && delay < guard.backoffMax; i++ {
+		delay *= 2
+	}
+
+	return min(delay, guard.backoffMax)
+}
+
+func attemptKeys(login, ip string) []string {
+	var keys []string
+
+	if login != "" {
+		keys = append(keys, loginKey(login))
+	}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+
+	return keys
+}
+
+func loginKey(login string) string {
+	return "login:" + login
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,229 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeAttemptStore counts failures in memory the way the stores do.
+type fakeAttemptStore map[string]models.LoginAttempts
+
+func (store fakeAttemptStore) GetAttempts(key string) (models.LoginAttempts, error) {
+	return store[key], nil
+}
+
+func (store fakeAttemptStore) RecordFailure(key string, at time.Time, window time.Duration) (models.LoginAttempts, error) {
+	attempts := store[key]
+	if attempts.LastFailure.Before(at.Add(-window)) {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailure = at
+	store[key] = attempts
+
+	return attempts, nil
+}
+
+func (store fakeAttemptStore) Block(key string, until time.Time) error {
+	attempts := store[key]
+	attempts.BlockedUntil = until
+	store[key] = attempts
+
+	return nil
+}
+
+func (store fakeAttemptStore) ResetAttempts(key string) error {
+	delete(store, key)
+
+	return nil
+}
+
+func newTestGuard(store LoginAttemptStore) *LoginGuard {
+	return &LoginGuard{
+		store:           store,
+		loginPolicy:     attemptPolicy{backoffAfter: 3, lockoutAfter: 6},
+		ipPolicy:        attemptPolicy{backoffAfter: 0, lockoutAfter: 4},
+		window:          time.Hour,
+		backoffBase:     time.Second,
+		backoffMax:      10 * time.Second,
+		lockoutDuration: 30 * time.Minute,
+	}
+}
+
+func TestLoginGuardDelay(t *testing.T) {
+	guard := newTestGuard(nil)
+
+	tests := []struct {
+		name     string
+		policy   attemptPolicy
+		failures int
+		want     time.Duration
+	}{
+		{"no failures", guard.loginPolicy, 0, 0},
+		{"below back-off", guard.loginPolicy, 2, 0},
+		{"first back-off", guard.loginPolicy, 3, time.Second},
+		{"doubled", guard.loginPolicy, 4, 2 * time.Second},
+		{"doubled again", guard.loginPolicy, 5, 4 * time.Second},
+		{"lockout", guard.loginPolicy, 6, 30 * time.Minute},
+		{"past lockout", guard.loginPolicy, 20, 30 * time.Minute},
+		{"capped", attemptPolicy{backoffAfter: 1}, 50, 10 * time.Second},
+		{"back-off off", guard.ipPolicy, 3, 0},
+		{"lockout only", guard.ipPolicy, 4, 30 * time.Minute},
+		{"all off", attemptPolicy{}, 100, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := guard.delay(test.policy, test.failures); got != test.want {
+				t.Errorf("delay(%d) = %s, want %s", test.failures, got, test.want)
+			}
+		})
+	}
+}
+
+func TestLoginGuard(t *testing.T) {
+	type attempt struct{ login, ip string }
+
+	tests := []struct {
+		name         string
+		failures     []attempt
+		success      string
+		clearLogin   string
+		clearIP      string
+		checkLogin   string
+		checkIP      string
+		wantBlocked  bool
+		wantFailures int
+	}{
+		{
+			name:         "below back-off",
+			failures:     []attempt{{"bob", "a"}, {"bob", "a"}},
+			checkLogin:   "bob",
+			checkIP:      "a",
+			wantFailures: 2,
+		},
+		{
+			name:         "login backed off from any IP",
+			failures:     []attempt{{"bob", "a"}, {"bob", "b"}, {"bob", "c"}},
+			checkLogin:   "bob",
+			checkIP:      "d",
+			wantBlocked:  true,
+			wantFailures: 3,
+		},
+		{
+			name:         "IP locked out for any login",
+			failures:     []attempt{{"a1", "a"}, {"a2", "a"}, {"a3", "a"}, {"a4", "a"}},
+			checkLogin:   "carol",
+			checkIP:      "a",
+			wantBlocked:  true,
+			wantFailures: 4,
+		},
+		{
+			name:         "other login and IP",
+			failures:     []attempt{{"bob", "a"}, {"bob", "a"}, {"bob", "a"}},
+			checkLogin:   "carol",
+			checkIP:      "b",
+			wantFailures: 0,
+		},
+		{
+			name:         "success keeps the IP count",
+			failures:     []attempt{{"bob", "a"}, {"bob", "a"}, {"bob", "a"}},
+			success:      "bob",
+			checkLogin:   "bob",
+			checkIP:      "a",
+			wantFailures: 3,
+		},
+		{
+			name:         "success unblocks the login",
+			failures:     []attempt{{"bob", "a"}, {"bob", "a"}, {"bob", "a"}},
+			success:      "bob",
+			checkLogin:   "bob",
+			checkIP:      "b",
+			wantFailures: 0,
+		},
+		{
+			name:         "cleared login",
+			failures:     []attempt{{"bob", "a"}, {"bob", "b"}, {"bob", "c"}, {"bob", "d"}},
+			clearLogin:   "bob",
+			checkLogin:   "bob",
+			checkIP:      "e",
+			wantFailures: 0,
+		},
+		{
+			name:         "cleared IP",
+			failures:     []attempt{{"a1", "a"}, {"a2", "a"}, {"a3", "a"}, {"a4", "a"}},
+			clearIP:      "a",
+			checkLogin:   "carol",
+			checkIP:      "a",
+			wantFailures: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			guard := newTestGuard(fakeAttemptStore{})
+
+			for _, failure := range test.failures {
+				if err := guard.Failure(failure.login, failure.ip); err != nil {
+					t.Fatalf("Failure() error = %v", err)
+				}
+			}
+			if test.success != "" {
+				if err := guard.Success(test.success); err != nil {
+					t.Fatalf("Success() error = %v", err)
+				}
+			}
+			if test.clearLogin != "" || test.clearIP != "" {
+				if err := guard.Clear(test.clearLogin, test.clearIP); err != nil {
+					t.Fatalf("Clear() error = %v", err)
+				}
+			}
+
+			err := guard.Check(test.checkLogin, test.checkIP)
+			var attemptsErr *customError.TooManyAttempts
+			if blocked := errors.As(err, &attemptsErr); blocked != test.wantBlocked {
+				t.Errorf("Check() error = %v, want blocked %v", err, test.wantBlocked)
+			}
+			if test.wantBlocked && (attemptsErr.RetryAfter <= 0 || attemptsErr.RetryAfter > 30*time.Minute) {
+				t.Errorf("Check() retry after %s", attemptsErr.RetryAfter)
+			}
+
+			failures, err := guard.Failures(test.checkLogin, test.checkIP)
+			if err != nil || failures != test.wantFailures {
+				t.Errorf("Failures() = %d, %v, want %d", failures, err, test.wantFailures)
+			}
+		})
+	}
+}
+
+func TestLoginGuardWindow(t *testing.T) {
+	store := fakeAttemptStore{}
+	guard := newTestGuard(store)
+
+	// Failures older than the window neither count nor add up with new ones.
+	store[loginKey("bob")] = models.LoginAttempts{Failures: 5, LastFailure: time.Now().Add(-2 * time.Hour)}
+
+	if failures, _ := guard.Failures("bob", ""); failures != 0 {
+		t.Errorf("Failures() = %d for stale failures, want 0", failures)
+	}
+	if err := guard.Failure("bob", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.Check("bob", ""); err != nil {
+		t.Errorf("Check() error = %v after one fresh failure, want nil", err)
+	}
+	if failures, _ := guard.Failures("bob", ""); failures != 1 {
+		t.Errorf("Failures() = %d, want 1", failures)
+	}
+}
+
+func TestLoginGuardClearNothing(t *testing.T) {
+	guard := newTestGuard(fakeAttemptStore{})
+
+	if err := guard.Clear("", ""); !errors.Is(err, customError.NoLockoutTargetError) {
+		t.Errorf("Clear() error = %v, want %v", err, customError.NoLockoutTargetError)
+	}
+}
@@ -0,0 +1,284 @@
+package core
+
+import (
+	"auth/pkg/customError"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	hashArgon2id = "argon2id"
+	hashBcrypt   = "bcrypt"
+)
+
+var (
+	unknownHashError   = errors.New("stored password hash has unknown format")
+	unknownPepperError = errors.New("stored password hash uses a pepper that is not configured")
+)
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  int
+	keyLength   uint32
+}
+
+// PasswordHasher hashes passwords with the algorithm set by PASSWORD_HASH_ALGORITHM,
+// argon2id (default) or bcrypt. Argon2id hashes are stored as PHC strings
+// ($argon2id$v=19$m=...,t=...,p=...$salt$hash), bcrypt ones in their own $2a$ format.
+// Both are verified regardless of the configured algorithm.
+//
+// When peppers are configured the password is replaced by its HMAC-SHA256 keyed
+// with the current pepper before hashing. The pepper version is kept in the hash,
+// as the keyid parameter of argon2id or a $bcrypt$keyid=N prefix of bcrypt, so
+// hashes made with older peppers still verify until they are rehashed.
+type PasswordHasher struct {
+	algorithm     string
+	argon2        argon2Params
+	bcryptCost    int
+	peppers       map[int][]byte
+	pepperVersion int
+}
+
+// NewPasswordHasher creates a hasher. peppers maps pepper versions to keys and
+// pepperVersion is the one new hashes use; with no peppers passwords are hashed as they are.
+func NewPasswordHasher(peppers map[int][]byte, pepperVersion int) *PasswordHasher {
+	hasher := &PasswordHasher{
+		peppers:       peppers,
+		pepperVersion: pepperVersion,
+		algorithm:     hashArgon2id,
+		argon2: argon2Params{
+			memory:      uint32(envInt("ARGON2_MEMORY", 64*1024)),
+			iterations:  uint32(max(envInt("ARGON2_ITERATIONS", 3), 1)),
+			parallelism: uint8(min(max(envInt("ARGON2_PARALLELISM", 2), 1), 255)),
+			saltLength:  max(envInt("ARGON2_SALT_LENGTH", 16), 8),
+			keyLength:   uint32(max(envInt("ARGON2_KEY_LENGTH", 32), 16)),
+		},
+		bcryptCost: min(max(envInt("BCRYPT_COST", 14), bcrypt.MinCost), bcrypt.MaxCost),
+	}
+
+	PASSWORD_HASH_ALGORITHM, ok := os.LookupEnv("PASSWORD_HASH_ALGORITHM")
+	if ok {
+		switch strings.ToLower(PASSWORD_HASH_ALGORITHM) {
+		case hashArgon2id:
+		case hashBcrypt:
+			hasher.algorithm = hashBcrypt
+		default:
+			log.Printf("Unknown PASSWORD_HASH_ALGORITHM %q, using %s", PASSWORD_HASH_ALGORITHM, hashArgon2id)
+		}
+	}
+
+	return hasher
+}
+
+func (hasher *PasswordHasher) Hash(password string) (string, error) {
+	input, err := hasher.pepper(password, hasher.pepperVersion)
+	if err != nil {
+		return "", err
+	}
+
+	if hasher.algorithm == hashBcrypt {
+		hash, err := bcrypt.GenerateFromPassword(input, hasher.bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		if hasher.pepperVersion != 0 {
+			return fmt.Sprintf("$%s$keyid=%d%s", hashBcrypt, hasher.pepperVersion, hash), nil
+		}
+		return string(hash), nil
+	}
+
+	params := hasher.argon2
+
+	salt := make([]byte, params.saltLength)
+	_, err = rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey(input, salt, params.iterations, params.memory, params.parallelism, params.keyLength)
+
+	keyId := ""
+	if hasher.pepperVersion != 0 {
+		keyId = fmt.Sprintf(",keyid=%d", hasher.pepperVersion)
+	}
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d%s$%s$%s", hashArgon2id, argon2.Version, params.memory, params.iterations, params.parallelism, keyId,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify checks password against a stored hash. needsRehash is true when the hash
+// was made with another algorithm, parameters or pepper than the configured ones.
+func (hasher *PasswordHasher) Verify(password, encoded string) (needsRehash bool, err error) {
+	if strings.HasPrefix(encoded, "$"+hashArgon2id+"$") {
+		return hasher.verifyArgon2(password, encoded)
+	}
+
+	pepperVersion := 0
+	if strings.HasPrefix(encoded, "$"+hashBcrypt+"$") {
+		_, err = fmt.Sscanf(encoded, "$"+hashBcrypt+"$keyid=%d", &pepperVersion)
+		if err != nil || pepperVersion == 0 {
+			return false, unknownHashError
+		}
+		encoded = encoded[strings.Index(encoded[1:], "$")+1:]
+		encoded = encoded[strings.Index(encoded[1:], "$")+1:]
+	}
+
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, unknownHashError
+	}
+
+	input, err := hasher.pepper(password, pepperVersion)
+	if err != nil {
+		return false, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(encoded), input)
+	if err != nil {
+		return false, customError.IncorrectPasswordError
+	}
+
+	return hasher.algorithm != hashBcrypt || cost != hasher.bcryptCost || pepperVersion != hasher.pepperVersion, nil
+}
+
+func (hasher *PasswordHasher) verifyArgon2(password, encoded string) (bool, error) {
+	var version int
+	var params argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false, unknownHashError
+	}
+
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return false, unknownHashError
+	}
+
+	paramsText, keyIdText, _ := strings.Cut(parts[3], ",keyid=")
+
+	_, err = fmt.Sscanf(paramsText, "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism)
+	if err != nil {
+		return false, unknownHashError
+	}
+
+	pepperVersion := 0
+	if keyIdText != "" {
+		pepperVersion, err = strconv.Atoi(keyIdText)
+		if err != nil || pepperVersion == 0 {
+			return false, unknownHashError
+		}
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, unknownHashError
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, unknownHashError
+	}
+
+	params.saltLength = len(salt)
+	params.keyLength = uint32(len(key))
+
+	input, err := hasher.pepper(password, pepperVersion)
+	if err != nil {
+		return false, err
+	}
+
+	candidate := argon2.IDKey(input, salt, params.iterations, params.memory, params.parallelism, params.keyLength)
+	if subtle.ConstantTimeCompare(key, candidate) != 1 {
+		return false, customError.IncorrectPasswordError
+	}
+
+	return hasher.algorithm != hashArgon2id || params != hasher.argon2 || pepperVersion != hasher.pepperVersion, nil
+}
+
+// pepper returns the HMAC of password keyed with the pepper of version,
+// or the password itself for version 0.
+func (hasher *PasswordHasher) pepper(password string, version int) ([]byte, error) {
+	if version == 0 {
+		return []byte(password), nil
+	}
+
+	key, ok := hasher.peppers[version]
+	if !ok {
+		return nil, unknownPepperError
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(password))
+
+	return mac.Sum(nil), nil
+}
+
+// LoadPeppers reads the peppers from the file named by PASSWORD_PEPPER_FILE, one
+// "version:base64key" per line, or from PASSWORD_PEPPERS, the same entries separated
+// by commas. New hashes use PASSWORD_PEPPER_VERSION, by default the highest version.
+// Without either variable no pepper is used.
+func LoadPeppers() (map[int][]byte, int, error) {
+	var entries []string
+
+	if PASSWORD_PEPPER_FILE, ok := os.LookupEnv("PASSWORD_PEPPER_FILE"); ok {
+		content, err := os.ReadFile(PASSWORD_PEPPER_FILE)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = strings.Split(string(content), "\n")
+	} else if PASSWORD_PEPPERS, ok := os.LookupEnv("PASSWORD_PEPPERS"); ok {
+		entries = strings.Split(PASSWORD_PEPPERS, ",")
+	}
+
+	peppers := make(map[int][]byte)
+	current := 0
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		versionText, keyText, found := strings.Cut(entry, ":")
+		version, err := strconv.Atoi(versionText)
+		if !found || err != nil || version <= 0 {
+			return nil, 0, fmt.Errorf("pepper entry %q is not in version:base64key format", versionText)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(keyText)
+		if err != nil || len(key) < 16 {
+			return nil, 0, fmt.Errorf("pepper %d must be at least 16 base64 encoded bytes", version)
+		}
+
+		peppers[version] = key
+		current = max(current, version)
+	}
+
+	if len(peppers) == 0 {
+		return nil, 0, nil
+	}
+
+	if PASSWORD_PEPPER_VERSION, ok := os.LookupEnv("PASSWORD_PEPPER_VERSION"); ok {
+		version, err := strconv.Atoi(PASSWORD_PEPPER_VERSION)
+		if err != nil || peppers[version] == nil {
+			return nil, 0, fmt.Errorf("PASSWORD_PEPPER_VERSION %q is not a configured pepper", PASSWORD_PEPPER_VERSION)
+		}
+		current = version
+	}
+
+	return peppers, current, nil
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"auth/pkg/customError"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testHasher makes a hasher with cheap argon2id parameters.
+func testHasher(algorithm string, pepperVersion int, memory uint32) *PasswordHasher {
+	return &PasswordHasher{
+		algorithm:     algorithm,
+		argon2:        argon2Params{memory: memory, iterations: 1, parallelism: 1, saltLength: 8, keyLength: 16},
+		bcryptCost:    bcrypt.MinCost,
+		peppers:       map[int][]byte{1: []byte("old pepper"), 2: []byte("new pepper"), 3: []byte("lost pepper")},
+		pepperVersion: pepperVersion,
+	}
+}
+
+func mustHash(t *testing.T, hasher *PasswordHasher, password string) string {
+	t.Helper()
+
+	hash, err := hasher.Hash(password)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	return hash
+}
+
+func TestPasswordHasherVerify(t *testing.T) {
+	hasher := testHasher(hashArgon2id, 2, 64)
+	hasher.peppers = map[int][]byte{1: []byte("old pepper"), 2: []byte("new pepper")}
+
+	current := mustHash(t, hasher, "secret")
+	parts := strings.Split(current, "$")
+	withPart := func(index int, value string) string {
+		changed := append([]string(nil), parts...)
+		changed[index] = value
+		return strings.Join(changed, "$")
+	}
+
+	tests := []struct {
+		name        string
+		encoded     string
+		password    string
+		needsRehash bool
+		wantErr     error
+	}{
+		{"current", current, "secret", false, nil},
+		{"wrong password", current, "Secret", false, customError.IncorrectPasswordError},
+		{"older pepper", mustHash(t, testHasher(hashArgon2id, 1, 64), "secret"), "secret", true, nil},
+		{"no pepper", mustHash(t, testHasher(hashArgon2id, 0, 64), "secret"), "secret", true, nil},
+		{"other parameters", mustHash(t, testHasher(hashArgon2id, 2, 32), "secret"), "secret", true, nil},
+		{"unknown pepper", mustHash(t, testHasher(hashArgon2id, 3, 64), "secret"), "secret", false, unknownPepperError},
+		{"missing part", strings.Join(parts[:5], "$"), "secret", false, unknownHashError},
+		{"extra part", current + "$x", "secret", false, unknownHashError},
+		{"other version", withPart(2, "v=16"), "secret", false, unknownHashError},
+		{"bad parameters", withPart(3, "m=64,t=x,p=1,keyid=2"), "secret", false, unknownHashError},
+		{"zero keyid", withPart(3, "m=64,t=1,p=1,keyid=0"), "secret", false, unknownHashError},
+		{"bad keyid", withPart(3, "m=64,t=1,p=1,keyid=two"), "secret", false, unknownHashError},
+		{"bad salt", withPart(4, "not base64!"), "secret", false, unknownHashError},
+		{"bad key", withPart(5, "not base64!"), "secret", false, unknownHashError},
+		{"bcrypt", mustHash(t, testHasher(hashBcrypt, 0, 64), "secret"), "secret", true, nil},
+		{"peppered bcrypt", mustHash(t, testHasher(hashBcrypt, 2, 64), "secret"), "secret", true, nil},
+		{"wrong bcrypt password", mustHash(t, testHasher(hashBcrypt, 0, 64), "secret"), "Secret", false, customError.IncorrectPasswordError},
+		{"zero bcrypt keyid", "$bcrypt$keyid=0" + mustHash(t, testHasher(hashBcrypt, 0, 64), "secret"), "secret", false, unknownHashError},
+		{"unknown format", "secret", "secret", false, unknownHashError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			needsRehash, err := hasher.Verify(test.password, test.encoded)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, test.wantErr)
+			}
+			if needsRehash != test.needsRehash {
+				t.Errorf("Verify() needsRehash = %v, want %v", needsRehash, test.needsRehash)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherHash(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher *PasswordHasher
+		prefix string
+	}{
+		{"argon2id", testHasher(hashArgon2id, 0, 64), "$argon2id$v=19$m=64,t=1,p=1$"},
+		{"peppered argon2id", testHasher(hashArgon2id, 2, 64), "$argon2id$v=19$m=64,t=1,p=1,keyid=2$"},
+		{"bcrypt", testHasher(hashBcrypt, 0, 64), "$2a$04$"},
+		{"peppered bcrypt", testHasher(hashBcrypt, 2, 64), "$bcrypt$keyid=2$2a$04$"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first := mustHash(t, test.hasher, "secret")
+			if !strings.HasPrefix(first, test.prefix) {
+				t.Errorf("Hash() = %q, want prefix %q", first, test.prefix)
+			}
+			if second := mustHash(t, test.hasher, "secret"); second == first {
+				t.Errorf("Hash() gave %q twice, want a new salt each time", first)
+			}
+
+			needsRehash, err := test.hasher.Verify("secret", first)
+			if err != nil || needsRehash {
+				t.Errorf("Verify() = %v, %v, want false, nil", needsRehash, err)
+			}
+		})
+	}
+}
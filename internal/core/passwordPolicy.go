@@ -0,0 +1,164 @@
+package core
+
+import (
+	"auth/pkg/customError"
+	"fmt"
+	"github.com/ccojocar/zxcvbn-go"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// characterClasses are the classes PASSWORD_REQUIRED_CLASSES may list.
+var characterClasses = map[string]func(r rune) bool{
+	"lower":  unicode.IsLower,
+	"upper":  unicode.IsUpper,
+	"digit":  unicode.IsDigit,
+	"symbol": func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r) },
+}
+
+var strengthScores = []string{"very weak", "weak", "fair", "strong", "very strong"}
+
+// maxStrengthRunes bounds the part of a password zxcvbn estimates, as its time
+// grows faster than the length; a password that long is strong anyway.
+const maxStrengthRunes = 256
+
+// BreachedPasswords tells how many times a password was seen in known breaches.
+type BreachedPasswords interface {
+	Count(password string) (int, error)
+}
+
+// PasswordPolicy decides whether a password may be set. It is configured with
+// PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH, PASSWORD_REQUIRED_CLASSES (comma separated
+// lower, upper, digit and symbol), PASSWORD_ALLOW_LOGIN, PASSWORD_MIN_SCORE,
+// a zxcvbn strength score from 0 to 4, and BREACHED_PASSWORDS_MIN_COUNT, the number of
+// breaches a password may appear in before it is rejected.
+//
+// Breach screening fails open: when the breached passwords cannot be read the
+// failure is logged and the password is judged by the other rules only, so a
+// broken file does not stop every registration and password change.
+type PasswordPolicy struct {
+	minLength       int
+	maxLength       int
+	requiredClasses []string
+	allowLogin      bool
+	minScore        int
+	breached        BreachedPasswords
+	minBreachCount  int
+}
+
+// NewPasswordPolicy creates a policy. breached may be nil, then passwords are not
+// screened against breaches.
+func NewPasswordPolicy(breached BreachedPasswords) *PasswordPolicy {
+	policy := &PasswordPolicy{
+		minLength:      envInt("PASSWORD_MIN_LENGTH", 8),
+		maxLength:      envInt("PASSWORD_MAX_LENGTH", 64),
+		minScore:       min(envInt("PASSWORD_MIN_SCORE", 2), len(strengthScores)-1),
+		breached:       breached,
+		minBreachCount: max(envInt("BREACHED_PASSWORDS_MIN_COUNT", 1), 1),
+	}
+
+	PASSWORD_ALLOW_LOGIN, _ := os.LookupEnv("PASSWORD_ALLOW_LOGIN")
+	policy.allowLogin = PASSWORD_ALLOW_LOGIN == "true"
+
+	PASSWORD_REQUIRED_CLASSES, _ := os.LookupEnv("PASSWORD_REQUIRED_CLASSES")
+	for _, class := range strings.Split(PASSWORD_REQUIRED_CLASSES, ",") {
+		class = strings.ToLower(strings.TrimSpace(class))
+		if class == "" {
+			continue
+		}
+		if _, ok := characterClasses[class]; !ok {
+			log.Printf("Unknown password character class %q ignored", class)
+			continue
+		}
+		policy.requiredClasses = append(policy.requiredClasses, class)
+	}
+
+	return policy
+}
+
+// MinBreachCount is the number of breaches a password may appear in before it is rejected.
+func (policy *PasswordPolicy) MinBreachCount() int {
+	return policy.minBreachCount
+}
+
+// Check returns a *customError.WeakPassword listing every rule the password breaks.
+func (policy *PasswordPolicy) Check(login, password string) error {
+	var failures []customError.PolicyFailure
+
+	length := utf8.RuneCountInString(password)
+
+	if length < policy.minLength {
+		failures = append(failures, customError.PolicyFailure{
+			Rule:    "min_length",
+			Message: fmt.Sprintf("password must be at least %d characters long", policy.minLength),
+		})
+	}
+
+	if policy.maxLength > 0 && length > policy.maxLength {
+		failures = append(failures, customError.PolicyFailure{
+			Rule:    "max_length",
+			Message: fmt.Sprintf("password must be at most %d characters long", policy.maxLength),
+		})
+	}
+
+	for _, class := range policy.requiredClasses {
+		if !strings.ContainsFunc(password, characterClasses[class]) {
+			failures = append(failures, customError.PolicyFailure{
+				Rule:    "class_" + class,
+				Message: fmt.Sprintf("password must contain a %s character", class),
+			})
+		}
+	}
+
+	if !policy.allowLogin && login != "" && strings.Contains(strings.ToLower(password), strings.ToLower(login)) {
+		failures = append(failures, customError.PolicyFailure{
+			Rule:    "contains_login",
+			Message: "password must not contain the login",
+		})
+	}
+
+	// Strength is only worth estimating for passwords of sane length.
+	if policy.minScore > 0 && (policy.maxLength == 0 || length <= policy.maxLength) {
+		score := zxcvbn.PasswordStrength(firstRunes(password, maxStrengthRunes), []string{login}).Score
+		if score < policy.minScore {
+			failures = append(failures, customError.PolicyFailure{
+				Rule:    "strength",
+				Message: fmt.Sprintf("password is %s, it must be at least %s", strengthScores[score], strengthScores[policy.minScore]),
+			})
+		}
+	}
+
+	if policy.breached != nil {
+		count, err := policy.breached.Count(password)
+		if err != nil {
+			// Fails open, see PasswordPolicy.
+			log.Printf("Breached password lookup failed: %v", err)
+		} else if count >= policy.minBreachCount {
+			failures = append(failures, customError.PolicyFailure{
+				Rule:    "breached",
+				Message: "password appeared in a known data breach",
+			})
+		}
+	}
+
+	if len(failures) > 0 {
+		return &customError.WeakPassword{Failures: failures}
+	}
+
+	return nil
+}
+
+// firstRunes returns the first n runes of s.
+func firstRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+
+	return s
+}
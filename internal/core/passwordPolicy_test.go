@@ -0,0 +1,90 @@
+package core
+
+import (
+	"auth/pkg/customError"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeBreaches counts the passwords it lists as seen once.
+type fakeBreaches map[string]bool
+
+func (breaches fakeBreaches) Count(password string) (int, error) {
+	if breaches[password] {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := &PasswordPolicy{
+		minLength:       8,
+		maxLength:       64,
+		requiredClasses: []string{"digit"},
+		minScore:        2,
+		breached:        fakeBreaches{"correct horse 1 battery": true},
+		minBreachCount:  1,
+	}
+	unlimited := *policy
+	unlimited.maxLength = 0
+
+	tests := []struct {
+		name      string
+		policy    *PasswordPolicy
+		login     string
+		password  string
+		wantRules []string
+	}{
+		{"strong", policy, "alice", "violet-Kettle-49-drums", nil},
+		{"short", policy, "alice", "x7", []string{"min_length", "strength"}},
+		{"long", policy, "alice", strings.Repeat("ab3", 30), []string{"max_length"}},
+		{"no digit", policy, "alice", "violet-Kettle-drums", []string{"class_digit"}},
+		{"contains login", policy, "alice", "violet-ALICE-49-drums", []string{"contains_login"}},
+		{"weak", policy, "alice", "password1", []string{"strength"}},
+		{"breached", policy, "alice", "correct horse 1 battery", []string{"breached"}},
+		{"unlimited length", &unlimited, "alice", strings.Repeat("Qx7#", 25000), nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.policy.Check(test.login, test.password)
+
+			var rules []string
+			var weak *customError.WeakPassword
+			if errors.As(err, &weak) {
+				for _, failure := range weak.Failures {
+					rules = append(rules, failure.Rule)
+				}
+			} else if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+
+			if !slices.Equal(rules, test.wantRules) {
+				t.Errorf("Check() broke %q, want %q", rules, test.wantRules)
+			}
+		})
+	}
+}
+
+func TestFirstRunes(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"password", 4, "pass"},
+		{"pass", 8, "pass"},
+		{"pass", 4, "pass"},
+		{"pass", 0, ""},
+		{"", 3, ""},
+		{"żółw", 2, "żó"},
+	}
+
+	for _, test := range tests {
+		if got := firstRunes(test.s, test.n); got != test.want {
+			t.Errorf("firstRunes(%q, %d) = %q, want %q", test.s, test.n, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultPresignMaxExpiry = 15 * time.Minute
+
+// presignExpiry bounds the lifetime a client asked for by PRESIGN_MAX_EXPIRY,
+// which is also used when it asked for none.
+func presignExpiry(requested time.Duration) time.Duration {
+	maxExpiry := envDuration("PRESIGN_MAX_EXPIRY", defaultPresignMaxExpiry)
+	if requested <= 0 || requested > maxExpiry {
+		return maxExpiry
+	}
+
+	return requested
+}
+
+// presignConfirmWindow is how long after its URLs expire a presigned upload can
+// still be confirmed. Staged files are kept until then.
+const presignConfirmWindow = time.Hour
+
+type PresignedUploadsRepository interface {
+	CreatePresignedUpload(upload models.PresignedUpload) error
+	ClaimPresignedUpload(id, profileId string, after time.Time) (models.PresignedUpload, error)
+	ReleasePresignedUpload(id string) error
+	DeletePresignedUpload(id string) error
+	GetExpiredPresignedUploads(before time.Time) ([]models.PresignedUpload, error)
+}
+
+// presignedKey is where a presigned upload is staged.
+func presignedKey(id string) string {
+	return "presigned/" + id
+}
+
+// PresignUpload signs a direct upload of a new file of login, exactly size bytes
+// of declaredType for a PUT and at most size bytes for a POST. The file is sent
+// to staging and only reaches the bucket of login once ConfirmUpload accepts it.
+func (service *UploadService) PresignUpload(ctx context.Context, login, fileName, declaredType string, size int64, expires time.Duration) (models.PresignedUpload, error) {
+
+	if size <= 0 || size > service.presignMaxSize {
+		return models.PresignedUpload{}, customError.FileTooLargeError
+	}
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return models.PresignedUpload{}, customError.UnexistingLoginError
+	}
+
+	roles, err := service.repo.GetUserRolesByLogin(login)
+	if err != nil {
+		return models.PresignedUpload{}, err
+	}
+
+	contentType, err := service.fileTypes.CheckName(roles, fileName, declaredType)
+	if err != nil {
+		return models.PresignedUpload{}, err
+	}
+
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
+
+	_, err = service.fileStorage.GetFile(ctx, bucketName, fileName)
+	if err == nil {
+		return models.PresignedUpload{}, customError.ExistingFileError
+	}
+
+	// The space is only claimed once the upload is confirmed.
+	err = service.quotas.Check(profileData.Id, roles, size)
+	if err != nil {
+		return models.PresignedUpload{}, err
+	}
+
+	random := make([]byte, 16)
+	_, err = rand.Read(random)
+	if err != nil {
+		return models.PresignedUpload{}, err
+	}
+
+	now := time.Now()
+	expiry := presignExpiry(expires)
+	upload := models.PresignedUpload{
+		Id:          hex.EncodeToString(random),
+		ProfileId:   profileData.Id,
+		Login:       profileData.Login,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        size,
+		PutHeaders:  map[string]string{"Content-Type": contentType, "Content-Length": strconv.FormatInt(size, 10)},
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(expiry),
+	}
+
+	putURL, err := service.staging.PresignStagedPut(ctx, presignedKey(upload.Id), contentType, size, expiry)
+	if err != nil {
+		return models.PresignedUpload{}, err
+	}
+
+	postURL, formData, err := service.staging.PresignStagedPost(ctx, presignedKey(upload.Id), contentType, size, expiry)
+	if err != nil {
+		return models.PresignedUpload{}, err
+	}
+
+	upload.PutURL, upload.PostURL, upload.FormData = putURL.String(), postURL.String(), formData
+
+	err = service.presigned.CreatePresignedUpload(upload)
+	if err != nil {
+		return models.PresignedUpload{}, err
+	}
+
+	return upload, nil
+}
+
+// ConfirmUpload accepts the file sent for the presigned upload id of login and
+// moves it into their bucket. It runs the same checks as UploadFile and claims the
+// space from the storage quota. Each upload is accepted at most once; one that
+// breaks the rules is dropped, one that failed otherwise can be confirmed again.
+// The returned upload carries the stored content type and size.
+func (service *UploadService) ConfirmUpload(ctx context.Context, login, id string) (models.PresignedUpload, error) {
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return models.PresignedUpload{}, customError.UnexistingLoginError
+	}
+
+	upload, err := service.presigned.ClaimPresignedUpload(id, profileData.Id, time.Now().Add(-presignConfirmWindow))
+	if err != nil {
+		return models.PresignedUpload{}, customError.UnexistingUploadError
+	}
+
+	// Once claimed, the file must be either accepted or released, even if the client goes away.
+	ctx = context.WithoutCancel(ctx)
+
+	roles, err := service.repo.GetUserRolesByLogin(login)
+	if err == nil {
+		upload.ContentType, upload.Size, err = service.acceptStaged(ctx, upload.ProfileId, roles, presignedKey(upload.Id),
+			upload.Login, upload.FileName, upload.ContentType, upload.Size)
+	}
+	if err != nil {
+		if uploadRejected(err) {
+			service.staging.RemoveStaged(ctx, presignedKey(upload.Id))
+		} else {
+			service.presigned.ReleasePresignedUpload(upload.Id)
+		}
+
+		return models.PresignedUpload{}, err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditFileUploaded, upload.Login, map[string]string{
+		"file": upload.FileName, "size": strconv.FormatInt(upload.Size, 10), "content_type": upload.ContentType, "via": "presigned_url",
+	})
+
+	return upload, nil
+}
+
+// prunePresignedUploads forgets presigned uploads that can no longer be confirmed
+// and drops whatever was staged for them.
+func (service *UploadService) prunePresignedUploads(ctx context.Context) error {
+
+	uploads, err := service.presigned.GetExpiredPresignedUploads(time.Now().Add(-presignConfirmWindow))
+	if err != nil {
+		return err
+	}
+
+	for _, upload := range uploads {
+		service.staging.RemoveStaged(ctx, presignedKey(upload.Id))
+
+		err = service.presigned.DeletePresignedUpload(upload.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PresignDownload signs a direct download of a file of login and returns the URL
+// and when it stops working.
+func (service *UserService) PresignDownload(ctx context.Context, login, fileName string, expires time.Duration) (string, time.Time, error) {
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return "", time.Time{}, customError.UnexistingLoginError
+	}
+
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
+
+	_, err = service.fileStorage.GetFile(ctx, bucketName, fileName)
+	if err != nil {
+		return "", time.Time{}, customError.UnexistingFileError
+	}
+
+	expiry := presignExpiry(expires)
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(fileName)})
+
+	getURL, err := service.fileStorage.PresignGet(ctx, bucketName, fileName, disposition, expiry)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return getURL.String(), time.Now().Add(expiry), nil
+}
@@ -0,0 +1,198 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func (staging *fakeStaging) PresignStagedPut(_ context.Context, key, _ string, _ int64, _ time.Duration) (*url.URL, error) {
+	return url.Parse("https://storage.example/staging/" + key)
+}
+
+func (staging *fakeStaging) PresignStagedPost(_ context.Context, key, _ string, _ int64, _ time.Duration) (*url.URL, map[string]string, error) {
+	postURL, err := url.Parse("https://storage.example/staging")
+	return postURL, map[string]string{"key": key}, err
+}
+
+// fakePresigned keeps presigned uploads in memory; a claimed one cannot be
+// claimed again until it is released.
+type fakePresigned struct {
+	PresignedUploadsRepository
+	uploads  map[string]models.PresignedUpload
+	claimed  map[string]bool
+	released []string
+}
+
+func newFakePresigned() *fakePresigned {
+	return &fakePresigned{uploads: make(map[string]models.PresignedUpload), claimed: make(map[string]bool)}
+}
+
+func (repo *fakePresigned) CreatePresignedUpload(upload models.PresignedUpload) error {
+	repo.uploads[upload.Id] = upload
+	return nil
+}
+
+func (repo *fakePresigned) ClaimPresignedUpload(id, profileId string, after time.Time) (models.PresignedUpload, error) {
+	upload, ok := repo.uploads[id]
+	if !ok || repo.claimed[id] || upload.ProfileId != profileId || upload.ExpiresAt.Before(after) {
+		return models.PresignedUpload{}, errors.New("no such upload")
+	}
+	repo.claimed[id] = true
+
+	return upload, nil
+}
+
+func (repo *fakePresigned) ReleasePresignedUpload(id string) error {
+	repo.released = append(repo.released, id)
+	repo.claimed[id] = false
+	return nil
+}
+
+func newTestPresignService() (*UploadService, *fakeStaging, *fakePresigned, *fakeQuotas, *[]models.AuditEvent) {
+	service, staging, _, quotas, events := newTestUploadService(minUploadPartSize)
+	presigned := newFakePresigned()
+	service.presigned = presigned
+	service.presignMaxSize = 6 << 20
+
+	return service, staging, presigned, quotas, events
+}
+
+func TestPresignUploadSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int64
+		tooLarge bool
+	}{
+		{"empty", 0, true},
+		{"negative", -1, true},
+		{"above the proxy body limit", 6 << 20, false},
+		{"above PRESIGN_MAX_SIZE", 6<<20 + 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, _, presigned, _, _ := newTestPresignService()
+
+			upload, err := service.PresignUpload(context.Background(), "alice", "a.png", "image/png", test.size, 0)
+			if test.tooLarge {
+				if !errors.Is(err, customError.FileTooLargeError) {
+					t.Errorf("PresignUpload() error = %v, want %v", err, customError.FileTooLargeError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PresignUpload() error = %v", err)
+			}
+			if _, ok := presigned.uploads[upload.Id]; !ok || upload.Size != test.size {
+				t.Errorf("PresignUpload() = %+v, want it recorded with size %d", upload, test.size)
+			}
+		})
+	}
+}
+
+func TestConfirmUpload(t *testing.T) {
+	tests := []struct {
+		name     string
+		staged   string
+		size     int64
+		fileName string
+		moveErr  error
+		wantErr  error
+		removed  bool
+		released bool
+	}{
+		{name: "accepted", staged: testPNG, size: int64(len(testPNG)), fileName: "a.png"},
+		{name: "larger than presigned", staged: testPNG, size: 4, fileName: "a.png",
+			wantErr: customError.FileTooLargeError, removed: true},
+		{name: "not what the name says", staged: "%PDF-1.7", size: 8, fileName: "a.png",
+			wantErr: customError.TypeNotAllowed, removed: true},
+		{name: "move fails", staged: testPNG, size: int64(len(testPNG)), fileName: "a.png", moveErr: errStorage,
+			wantErr: errStorage, released: true},
+		{name: "nothing staged", size: int64(len(testPNG)), fileName: "a.png",
+			wantErr: customError.UnexistingFileError, released: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, staging, presigned, quotas, events := newTestPresignService()
+			staging.moveErr = test.moveErr
+
+			upload, err := service.PresignUpload(context.Background(), "alice", test.fileName, "", test.size, 0)
+			if err != nil {
+				t.Fatalf("PresignUpload() error = %v", err)
+			}
+			if test.staged != "" {
+				staging.staged[presignedKey(upload.Id)] = []byte(test.staged)
+			}
+
+			confirmed, err := service.ConfirmUpload(context.Background(), "alice", upload.Id)
+
+			removed := slices.Contains(staging.removed, presignedKey(upload.Id))
+			released := slices.Contains(presigned.released, upload.Id)
+			if removed != test.removed || released != test.released {
+				t.Errorf("staged object removed = %v, upload released = %v, want %v and %v", removed, released, test.removed, test.released)
+			}
+
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("ConfirmUpload() error = %v, want %v", err, test.wantErr)
+				}
+				if usage := quotas.usage["1"]; usage != (models.StorageQuota{}) {
+					t.Errorf("usage = %+v, want nothing claimed", usage)
+				}
+				if len(*events) != 0 {
+					t.Errorf("audit events = %+v, want none", *events)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConfirmUpload() error = %v", err)
+			}
+
+			if got := string(staging.stored["alice-1/"+test.fileName]); got != test.staged {
+				t.Errorf("stored file = %q, want %q", got, test.staged)
+			}
+			if confirmed.ContentType != "image/png" || confirmed.Size != int64(len(test.staged)) {
+				t.Errorf("ConfirmUpload() = %+v, want the stored type and size", confirmed)
+			}
+			if usage := quotas.usage["1"]; usage.UsedBytes != int64(len(test.staged)) || usage.UsedObjects != 1 {
+				t.Errorf("usage = %+v, want the file counted", usage)
+			}
+			if len(*events) != 1 || (*events)[0].Action != models.AuditFileUploaded || (*events)[0].Details["via"] != "presigned_url" {
+				t.Errorf("audit events = %+v, want one presigned upload", *events)
+			}
+
+			_, err = service.ConfirmUpload(context.Background(), "alice", upload.Id)
+			if !errors.Is(err, customError.UnexistingUploadError) {
+				t.Errorf("second ConfirmUpload() error = %v, want %v", err, customError.UnexistingUploadError)
+			}
+		})
+	}
+}
+
+func TestConfirmUploadOfOthers(t *testing.T) {
+	service, _, presigned, _, _ := newTestPresignService()
+	service.repo = fakeUsers{
+		users: map[string]models.User{"alice": {Id: "1", Login: "alice"}, "bob": {Id: "2", Login: "bob"}},
+		roles: map[string][]string{"alice": {"User"}, "bob": {"User"}},
+	}
+
+	upload, err := service.PresignUpload(context.Background(), "alice", "a.png", "", 10, 0)
+	if err != nil {
+		t.Fatalf("PresignUpload() error = %v", err)
+	}
+
+	_, err = service.ConfirmUpload(context.Background(), "bob", upload.Id)
+	if !errors.Is(err, customError.UnexistingUploadError) {
+		t.Errorf("ConfirmUpload() error = %v, want %v", err, customError.UnexistingUploadError)
+	}
+	if presigned.claimed[upload.Id] {
+		t.Error("upload of alice was claimed by bob")
+	}
+}
@@ -0,0 +1,315 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultStorageQuotas     = "*:1GiB:10000;Admin:0:0"
+	defaultReconcileInterval = 6 * time.Hour
+)
+
+type QuotaRepository interface {
+	GetQuota(profileId string) (models.StorageQuota, models.QuotaOverride, error)
+	ReserveQuota(profileId string, bytes, objects, maxBytes, maxObjects int64) (bool, error)
+	ReleaseQuota(profileId string, bytes, objects int64) error
+	SetQuotaOverride(profileId string, override models.QuotaOverride) error
+	SetQuotaUsage(profileId string, bytes, objects int64) error
+	ListAccounts() ([]models.User, error)
+}
+
+type quotaLimit struct {
+	bytes   int64
+	objects int64
+}
+
+// StorageQuotas limits how many bytes and files each user stores. STORAGE_QUOTAS
+// lists the limits per role as "Role:size:objects;Role:size:objects", where the
+// role "*" applies to everybody, sizes may end in KiB, MiB, GiB or TiB and 0 means
+// unlimited. A user gets the most generous limits of their roles, unless an Admin
+// set limits for them.
+//
+// Usage is tracked in the database as files come and go, and is corrected by
+// counting the buckets every QUOTA_RECONCILE_INTERVAL.
+type StorageQuotas struct {
+	repo     QuotaRepository
+	limits   map[string]quotaLimit
+	interval time.Duration
+}
+
+func NewStorageQuotas(repo QuotaRepository) *StorageQuotas {
+	STORAGE_QUOTAS, ok := os.LookupEnv("STORAGE_QUOTAS")
+	if !ok {
+		STORAGE_QUOTAS = defaultStorageQuotas
+	}
+
+	quotas := &StorageQuotas{
+		repo:     repo,
+		limits:   make(map[string]quotaLimit),
+		interval: envDuration("QUOTA_RECONCILE_INTERVAL", defaultReconcileInterval),
+	}
+
+	for _, entry := range strings.Split(STORAGE_QUOTAS, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		fields := strings.Split(entry, ":")
+		if len(fields) != 3 || strings.TrimSpace(fields[0]) == "" {
+			log.Printf("Invalid STORAGE_QUOTAS entry %q ignored", entry)
+			continue
+		}
+
+		bytes, err := parseSize(fields[1])
+		if err != nil {
+			log.Printf("Invalid STORAGE_QUOTAS size %q ignored", fields[1])
+			continue
+		}
+		objects, err := strconv.ParseInt(strings.TrimSpace(fields[2]), 10, 64)
+		if err != nil || objects < 0 {
+			log.Printf("Invalid STORAGE_QUOTAS object count %q ignored", fields[2])
+			continue
+		}
+
+		quotas.limits[strings.TrimSpace(fields[0])] = quotaLimit{bytes: bytes, objects: objects}
+	}
+
+	return quotas
+}
+
+// parseSize reads a number of bytes with an optional binary unit.
+func parseSize(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+
+	multiplier := int64(1)
+	for i, unit := range []string{"KiB", "MiB", "GiB", "TiB"} {
+		if number, found := strings.CutSuffix(value, unit); found {
+			value, multiplier = strings.TrimSpace(number), 1<<(10*(i+1))
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+
+	return size * multiplier, nil
+}
+
+// roleLimits are the most generous limits of roles, 0 being more generous than any other.
+func (quotas *StorageQuotas) roleLimits(roles []string) quotaLimit {
+	limit, found := quotas.limits["*"]
+
+	for _, role := range roles {
+		roleLimit, ok := quotas.limits[role]
+		if !ok {
+			continue
+		}
+		if !found {
+			limit, found = roleLimit, true
+			continue
+		}
+		limit.bytes = moreGenerous(limit.bytes, roleLimit.bytes)
+		limit.objects = moreGenerous(limit.objects, roleLimit.objects)
+	}
+
+	return limit
+}
+
+func moreGenerous(a, b int64) int64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+
+	return max(a, b)
+}
+
+// Get returns the usage of a user along with the limits that apply to them.
+func (quotas *StorageQuotas) Get(profileId string, roles []string) (models.StorageQuota, error) {
+
+	quota, override, err := quotas.repo.GetQuota(profileId)
+	if err != nil {
+		return models.StorageQuota{}, err
+	}
+
+	limit := quotas.roleLimits(roles)
+	quota.MaxBytes, quota.MaxObjects = limit.bytes, limit.objects
+	if override.MaxBytes != nil {
+		quota.MaxBytes, quota.Overridden = *override.MaxBytes, true
+	}
+	if override.MaxObjects != nil {
+		quota.MaxObjects, quota.Overridden = *override.MaxObjects, true
+	}
+
+	return quota, nil
+}
+
+// Check tells early whether a file of size bytes would fit, without claiming the space.
+func (quotas *StorageQuotas) Check(profileId string, roles []string, size int64) error {
+
+	quota, err := quotas.Get(profileId, roles)
+	if err != nil {
+		return err
+	}
+
+	return exceeded(quota, size, 1)
+}
+
+// Reserve claims the space of a new file of size bytes. The claim is checked and
+// made in one statement, so concurrent uploads cannot together pass the limits.
+func (quotas *StorageQuotas) Reserve(profileId string, roles []string, size int64) error {
+	return quotas.ReserveFiles(profileId, roles, size, 1)
+}
+
+// ReserveFiles claims the space of several new files at once.
+func (quotas *StorageQuotas) ReserveFiles(profileId string, roles []string, bytes, objects int64) error {
+
+	limit := quotas.roleLimits(roles)
+
+	reserved, err := quotas.repo.ReserveQuota(profileId, bytes, objects, limit.bytes, limit.objects)
+	if err != nil {
+		return err
+	}
+	if reserved {
+		return nil
+	}
+
+	quota, err := quotas.Get(profileId, roles)
+	if err != nil {
+		return err
+	}
+
+	err = exceeded(quota, bytes, objects)
+	if err == nil {
+		err = customError.QuotaExceededError
+	}
+
+	return err
+}
+
+// Release gives back the space of a file of size bytes that is gone or never made it.
+func (quotas *StorageQuotas) Release(profileId string, size int64) error {
+	return quotas.ReleaseFiles(profileId, size, 1)
+}
+
+func (quotas *StorageQuotas) ReleaseFiles(profileId string, bytes, objects int64) error {
+	return quotas.repo.ReleaseQuota(profileId, bytes, objects)
+}
+
+func (quotas *StorageQuotas) SetOverride(profileId string, override models.QuotaOverride) error {
+	return quotas.repo.SetQuotaOverride(profileId, override)
+}
+
+func (quotas *StorageQuotas) SetUsage(profileId string, bytes, objects int64) error {
+	return quotas.repo.SetQuotaUsage(profileId, bytes, objects)
+}
+
+func exceeded(quota models.StorageQuota, bytes, objects int64) error {
+	if quota.MaxBytes > 0 && quota.UsedBytes+bytes > quota.MaxBytes {
+		return &customError.QuotaExceeded{Resource: "bytes", Used: quota.UsedBytes, Max: quota.MaxBytes}
+	}
+	if quota.MaxObjects > 0 && quota.UsedObjects+objects > quota.MaxObjects {
+		return &customError.QuotaExceeded{Resource: "files", Used: quota.UsedObjects, Max: quota.MaxObjects}
+	}
+
+	return nil
+}
+
+// GetQuota returns what login stores and may store.
+func (service *UserService) GetQuota(login string) (models.StorageQuota, error) {
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return models.StorageQuota{}, customError.UnexistingLoginError
+	}
+
+	roles, err := service.repo.GetUserRolesByLogin(login)
+	if err != nil {
+		return models.StorageQuota{}, err
+	}
+
+	return service.quotas.Get(profileData.Id, roles)
+}
+
+// SetQuotaOverride sets the limits of login; nil limits come from their roles again.
+func (service *UserService) SetQuotaOverride(ctx context.Context, login string, override models.QuotaOverride) error {
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return customError.UnexistingLoginError
+	}
+
+	err = service.quotas.SetOverride(profileData.Id, override)
+	if err != nil {
+		return err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditQuotaChanged, profileData.Login, map[string]string{
+		"max_bytes": formatLimit(override.MaxBytes), "max_objects": formatLimit(override.MaxObjects),
+	})
+
+	return nil
+}
+
+func formatLimit(limit *int64) string {
+	if limit == nil {
+		return "role default"
+	}
+
+	return strconv.FormatInt(*limit, 10)
+}
+
+// ReconcileQuotas replaces the tracked usage of every account with what its bucket
+// holds, correcting drift from failed requests and files changed outside the API.
+// Files stored while a bucket is being counted may be missed until the next run.
+func (service *UserService) ReconcileQuotas(ctx context.Context) error {
+
+	accounts, err := service.quotas.repo.ListAccounts()
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		bucketName := fmt.Sprintf("%s-%s", strings.ToLower(account.Login), account.Id)
+
+		bytes, objects, err := service.fileStorage.BucketUsage(ctx, bucketName)
+		if err != nil {
+			log.Printf("Counting bucket of %s failed: %v", account.Login, err)
+			continue
+		}
+
+		err = service.quotas.SetUsage(account.Id, bytes, objects)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (service *UserService) RunQuotaReconciler(ctx context.Context) {
+	ticker := time.NewTicker(service.quotas.interval)
+	defer ticker.Stop()
+
+	for {
+		err := service.ReconcileQuotas(ctx)
+		if err != nil {
+			log.Printf("Reconciling storage quotas failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
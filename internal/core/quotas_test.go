@@ -0,0 +1,276 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"errors"
+	"testing"
+)
+
+// fakeQuotas keeps usage and overrides in memory and reserves space the way
+// the storage_quota statements do.
+type fakeQuotas struct {
+	QuotaRepository
+	usage     map[string]models.StorageQuota
+	overrides map[string]models.QuotaOverride
+}
+
+func newFakeQuotas() *fakeQuotas {
+	return &fakeQuotas{usage: make(map[string]models.StorageQuota), overrides: make(map[string]models.QuotaOverride)}
+}
+
+func (repo *fakeQuotas) GetQuota(profileId string) (models.StorageQuota, models.QuotaOverride, error) {
+	return repo.usage[profileId], repo.overrides[profileId], nil
+}
+
+func (repo *fakeQuotas) ReserveQuota(profileId string, bytes, objects, maxBytes, maxObjects int64) (bool, error) {
+	override := repo.overrides[profileId]
+	if override.MaxBytes != nil {
+		maxBytes = *override.MaxBytes
+	}
+	if override.MaxObjects != nil {
+		maxObjects = *override.MaxObjects
+	}
+
+	quota := repo.usage[profileId]
+	if maxBytes != 0 && quota.UsedBytes+bytes > maxBytes || maxObjects != 0 && quota.UsedObjects+objects > maxObjects {
+		return false, nil
+	}
+	quota.UsedBytes += bytes
+	quota.UsedObjects += objects
+	repo.usage[profileId] = quota
+
+	return true, nil
+}
+
+func (repo *fakeQuotas) ReleaseQuota(profileId string, bytes, objects int64) error {
+	quota := repo.usage[profileId]
+	quota.UsedBytes = max(quota.UsedBytes-bytes, 0)
+	quota.UsedObjects = max(quota.UsedObjects-objects, 0)
+	repo.usage[profileId] = quota
+
+	return nil
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		invalid bool
+	}{
+		{value: "0", want: 0},
+		{value: "1024", want: 1024},
+		{value: " 2KiB ", want: 2 << 10},
+		{value: "5 MiB", want: 5 << 20},
+		{value: "1GiB", want: 1 << 30},
+		{value: "3TiB", want: 3 << 40},
+		{value: "", invalid: true},
+		{value: "-1", invalid: true},
+		{value: "1GB", invalid: true},
+		{value: "1.5GiB", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := parseSize(test.value)
+			if test.invalid {
+				if err == nil {
+					t.Errorf("parseSize(%q) = %d, want an error", test.value, got)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Errorf("parseSize(%q) = %d, %v, want %d", test.value, got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestNewStorageQuotas(t *testing.T) {
+	t.Setenv("STORAGE_QUOTAS", "*:1GiB:100; Editor : 2GiB : 50 ;Admin:0:0;Bad:1;Worse:lots:1;Worst:1:-1;:1:1;")
+
+	quotas := NewStorageQuotas(newFakeQuotas())
+
+	want := map[string]quotaLimit{
+		"*":      {bytes: 1 << 30, objects: 100},
+		"Editor": {bytes: 2 << 30, objects: 50},
+		"Admin":  {bytes: 0, objects: 0},
+	}
+	if len(quotas.limits) != len(want) {
+		t.Errorf("limits = %v, want %v", quotas.limits, want)
+	}
+	for role, limit := range want {
+		if quotas.limits[role] != limit {
+			t.Errorf("limits[%q] = %+v, want %+v", role, quotas.limits[role], limit)
+		}
+	}
+}
+
+func TestRoleLimits(t *testing.T) {
+	quotas := &StorageQuotas{limits: map[string]quotaLimit{
+		"*":      {bytes: 100, objects: 10},
+		"Editor": {bytes: 500, objects: 5},
+		"Admin":  {bytes: 0, objects: 0},
+	}}
+	noDefault := &StorageQuotas{limits: map[string]quotaLimit{"Editor": {bytes: 500, objects: 5}}}
+
+	tests := []struct {
+		name   string
+		quotas *StorageQuotas
+		roles  []string
+		want   quotaLimit
+	}{
+		{"default", quotas, []string{"User"}, quotaLimit{bytes: 100, objects: 10}},
+		{"most generous of each", quotas, []string{"User", "Editor"}, quotaLimit{bytes: 500, objects: 10}},
+		{"unlimited wins", quotas, []string{"Editor", "Admin"}, quotaLimit{bytes: 0, objects: 0}},
+		{"role without default", noDefault, []string{"Editor"}, quotaLimit{bytes: 500, objects: 5}},
+		{"nothing applies", noDefault, []string{"User"}, quotaLimit{bytes: 0, objects: 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.quotas.roleLimits(test.roles); got != test.want {
+				t.Errorf("roleLimits(%q) = %+v, want %+v", test.roles, got, test.want)
+			}
+		})
+	}
+}
+
+func TestReserveFiles(t *testing.T) {
+	limits := map[string]quotaLimit{"*": {bytes: 100, objects: 3}, "Admin": {bytes: 0, objects: 0}}
+	ten, one := int64(10), int64(1)
+
+	type step struct {
+		reserve  bool
+		bytes    int64
+		objects  int64
+		resource string
+	}
+
+	tests := []struct {
+		name     string
+		roles    []string
+		override models.QuotaOverride
+		steps    []step
+		want     models.StorageQuota
+	}{
+		{
+			name:  "fills up to the limit",
+			roles: []string{"User"},
+			steps: []step{{reserve: true, bytes: 60, objects: 1}, {reserve: true, bytes: 40, objects: 1}},
+			want:  models.StorageQuota{UsedBytes: 100, UsedObjects: 2},
+		},
+		{
+			name:  "too many bytes",
+			roles: []string{"User"},
+			steps: []step{{reserve: true, bytes: 60, objects: 1}, {reserve: true, bytes: 41, objects: 1, resource: "bytes"}},
+			want:  models.StorageQuota{UsedBytes: 60, UsedObjects: 1},
+		},
+		{
+			name:  "too many files at once",
+			roles: []string{"User"},
+			steps: []step{{reserve: true, bytes: 1, objects: 1}, {reserve: true, bytes: 3, objects: 3, resource: "files"}},
+			want:  models.StorageQuota{UsedBytes: 1, UsedObjects: 1},
+		},
+		{
+			name:  "released space is reused",
+			roles: []string{"User"},
+			steps: []step{{reserve: true, bytes: 90, objects: 1}, {bytes: 50, objects: 1}, {reserve: true, bytes: 60, objects: 1}},
+			want:  models.StorageQuota{UsedBytes: 100, UsedObjects: 1},
+		},
+		{
+			name:  "release does not go below zero",
+			roles: []string{"User"},
+			steps: []step{{reserve: true, bytes: 5, objects: 1}, {bytes: 50, objects: 2}},
+			want:  models.StorageQuota{},
+		},
+		{
+			name:  "unlimited role",
+			roles: []string{"User", "Admin"},
+			steps: []step{{reserve: true, bytes: 1 << 40, objects: 1000}},
+			want:  models.StorageQuota{UsedBytes: 1 << 40, UsedObjects: 1000},
+		},
+		{
+			name:     "override is tighter than the role",
+			roles:    []string{"User"},
+			override: models.QuotaOverride{MaxBytes: &ten},
+			steps:    []step{{reserve: true, bytes: 10, objects: 1}, {reserve: true, bytes: 1, objects: 1, resource: "bytes"}},
+			want:     models.StorageQuota{UsedBytes: 10, UsedObjects: 1},
+		},
+		{
+			name:     "override limits an unlimited role",
+			roles:    []string{"Admin"},
+			override: models.QuotaOverride{MaxObjects: &one},
+			steps:    []step{{reserve: true, bytes: 1, objects: 1}, {reserve: true, bytes: 1, objects: 1, resource: "files"}},
+			want:     models.StorageQuota{UsedBytes: 1, UsedObjects: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newFakeQuotas()
+			repo.overrides["1"] = test.override
+			quotas := &StorageQuotas{repo: repo, limits: limits}
+
+			for i, step := range test.steps {
+				if !step.reserve {
+					if err := quotas.ReleaseFiles("1", step.bytes, step.objects); err != nil {
+						t.Fatalf("step %d: ReleaseFiles() error = %v", i, err)
+					}
+					continue
+				}
+
+				err := quotas.ReserveFiles("1", test.roles, step.bytes, step.objects)
+				if step.resource == "" {
+					if err != nil {
+						t.Fatalf("step %d: ReserveFiles() error = %v", i, err)
+					}
+					continue
+				}
+				var exceededErr *customError.QuotaExceeded
+				if !errors.As(err, &exceededErr) || exceededErr.Resource != step.resource {
+					t.Fatalf("step %d: ReserveFiles() error = %v, want %s exceeded", i, err, step.resource)
+				}
+				if !errors.Is(err, customError.QuotaExceededError) {
+					t.Errorf("step %d: ReserveFiles() error = %v, does not match %v", i, err, customError.QuotaExceededError)
+				}
+			}
+
+			if got := repo.usage["1"]; got != test.want {
+				t.Errorf("usage = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestExceeded(t *testing.T) {
+	tests := []struct {
+		name     string
+		quota    models.StorageQuota
+		bytes    int64
+		objects  int64
+		resource string
+	}{
+		{"fits exactly", models.StorageQuota{UsedBytes: 90, MaxBytes: 100, UsedObjects: 9, MaxObjects: 10}, 10, 1, ""},
+		{"unlimited", models.StorageQuota{UsedBytes: 1 << 40, UsedObjects: 1 << 20}, 1 << 40, 1, ""},
+		{"bytes over", models.StorageQuota{UsedBytes: 90, MaxBytes: 100}, 11, 1, "bytes"},
+		{"files over", models.StorageQuota{UsedObjects: 10, MaxObjects: 10}, 0, 1, "files"},
+		{"bytes checked first", models.StorageQuota{MaxBytes: 1, MaxObjects: 1}, 2, 2, "bytes"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := exceeded(test.quota, test.bytes, test.objects)
+			if test.resource == "" {
+				if err != nil {
+					t.Errorf("exceeded() = %v, want nil", err)
+				}
+				return
+			}
+			var exceededErr *customError.QuotaExceeded
+			if !errors.As(err, &exceededErr) || exceededErr.Resource != test.resource {
+				t.Errorf("exceeded() = %v, want %s exceeded", err, test.resource)
+			}
+		})
+	}
+}
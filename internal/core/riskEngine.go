@@ -0,0 +1,195 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"math"
+	"time"
+)
+
+// Points each factor adds to the risk score of a login, which is capped at 100.
+const (
+	riskImpossibleTravel = 60
+	riskNewCountry       = 25
+	riskNewDevice        = 10
+	riskNewIP            = 5
+	riskUnusualHour      = 10
+	riskPerFailure       = 5
+	riskMaxFailures      = 25
+)
+
+const (
+	// Closer logins are not checked for travel, GeoIP positions are not more precise.
+	minTravelDistance = 300.0
+	// Time-of-day patterns need some history to mean anything.
+	minHourHistory = 5
+	usualHourSpan  = 2
+	earthRadius    = 6371.0
+)
+
+type GeoLocator interface {
+	Locate(ip string) (models.GeoLocation, error)
+}
+
+// RiskEngine scores logins against the earlier sessions of the account. A login
+// scoring RISK_BLOCK_SCORE is refused, RISK_STEP_UP_SCORE requires additional
+// verification and RISK_FLAG_SCORE is let in but flagged; 0 turns a level off.
+// Step-up is off by default: with no second factor to ask for yet it refuses the
+// login with StepUpRequiredError, which would shut out users who travel or use a VPN.
+// Without a GeoIP database location factors are skipped.
+type RiskEngine struct {
+	geo         GeoLocator
+	flagScore   int
+	stepUpScore int
+	blockScore  int
+	maxSpeed    float64
+}
+
+// NewRiskEngine creates an engine. geo may be nil when no GeoIP database is configured.
+func NewRiskEngine(geo GeoLocator) *RiskEngine {
+	return &RiskEngine{
+		geo:         geo,
+		flagScore:   envInt("RISK_FLAG_SCORE", 30),
+		stepUpScore: envInt("RISK_STEP_UP_SCORE", 0),
+		blockScore:  envInt("RISK_BLOCK_SCORE", 90),
+		maxSpeed:    float64(envInt("RISK_MAX_TRAVEL_SPEED", 900)),
+	}
+}
+
+func (engine *RiskEngine) Locate(ip string) models.GeoLocation {
+	if engine.geo == nil {
+		return models.GeoLocation{}
+	}
+
+	location, err := engine.geo.Locate(ip)
+	if err != nil {
+		return models.GeoLocation{}
+	}
+
+	return location
+}
+
+// Assess scores login, a session about to start, given the earlier sessions of
+// the account, newest first, and the recent failed attempts for the login.
+func (engine *RiskEngine) Assess(login models.Session, history []models.Session, failures int) models.RiskAssessment {
+	assessment := models.RiskAssessment{Location: login.Location}
+
+	add := func(factor string, points int) {
+		assessment.Factors = append(assessment.Factors, factor)
+		assessment.Score += points
+	}
+
+	if failures > 0 {
+		add("failed_attempts", min(failures*riskPerFailure, riskMaxFailures))
+	}
+
+	if len(history) > 0 {
+		// Countries are only compared with sessions that were located, so enabling
+		// the GeoIP database does not make every account look like it moved.
+		newDevice, newIP, newCountry, located := true, true, login.Location.Country != "", false
+		for _, seen := range history {
+			if seen.Device == login.Device {
+				newDevice = false
+			}
+			if seen.IP == login.IP {
+				newIP = false
+			}
+			if seen.Location.Country != "" {
+				located = true
+				if seen.Location.Country == login.Location.Country {
+					newCountry = false
+				}
+			}
+		}
+
+		if newDevice {
+			add("new_device", riskNewDevice)
+		}
+		if newIP {
+			add("new_ip", riskNewIP)
+		}
+		if newCountry && located {
+			add("new_country", riskNewCountry)
+		}
+		if engine.impossibleTravel(login, history) {
+			add("impossible_travel", riskImpossibleTravel)
+		}
+		if unusualHour(login.CreatedAt, history) {
+			add("unusual_hour", riskUnusualHour)
+		}
+	}
+
+	assessment.Score = min(assessment.Score, 100)
+	assessment.Action = engine.action(assessment.Score)
+
+	return assessment
+}
+
+func (engine *RiskEngine) action(score int) string {
+	switch {
+	case engine.blockScore > 0 && score >= engine.blockScore:
+		return models.RiskBlock
+	case engine.stepUpScore > 0 && score >= engine.stepUpScore:
+		return models.RiskStepUp
+	case engine.flagScore > 0 && score >= engine.flagScore:
+		return models.RiskFlag
+	default:
+		return models.RiskAllow
+	}
+}
+
+// impossibleTravel tells whether getting from the last located session to login
+// would take a speed above RISK_MAX_TRAVEL_SPEED km/h.
+func (engine *RiskEngine) impossibleTravel(login models.Session, history []models.Session) bool {
+	if !login.Location.HasCoordinates {
+		return false
+	}
+
+	for _, seen := range history {
+		if !seen.Location.HasCoordinates {
+			continue
+		}
+
+		distance := haversine(seen.Location, login.Location)
+		if distance < minTravelDistance {
+			return false
+		}
+
+		hours := login.CreatedAt.Sub(seen.CreatedAt).Hours()
+
+		return hours <= 0 || distance/hours > engine.maxSpeed
+	}
+
+	return false
+}
+
+// unusualHour tells whether none of the earlier logins happened within two hours
+// of the time of day of at, in UTC.
+func unusualHour(at time.Time, history []models.Session) bool {
+	if len(history) < minHourHistory {
+		return false
+	}
+
+	hour := at.UTC().Hour()
+	for _, seen := range history {
+		diff := seen.CreatedAt.UTC().Hour() - hour
+		if diff < 0 {
+			diff = -diff
+		}
+		if min(diff, 24-diff) <= usualHourSpan {
+			return false
+		}
+	}
+
+	return true
+}
+
+// haversine returns the great-circle distance between two locations in km.
+func haversine(from, to models.GeoLocation) float64 {
+	lat1, lat2 := from.Latitude*math.Pi/180, to.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (to.Longitude - from.Longitude) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRiskEngineAssess(t *testing.T) {
+	engine := &RiskEngine{flagScore: 30, blockScore: 90, maxSpeed: 900}
+
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	paris := models.GeoLocation{Country: "FR", Latitude: 48.8566, Longitude: 2.3522, HasCoordinates: true}
+	lyon := models.GeoLocation{Country: "FR", Latitude: 45.764, Longitude: 4.8357, HasCoordinates: true}
+	newYork := models.GeoLocation{Country: "US", Latitude: 40.7128, Longitude: -74.006, HasCoordinates: true}
+
+	session := func(device, ip string, location models.GeoLocation, at time.Time) models.Session {
+		return models.Session{Device: device, IP: ip, Location: location, CreatedAt: at}
+	}
+	usual := []models.Session{session("laptop", "192.0.2.1", paris, now.Add(-24*time.Hour))}
+	daily := func(days int, hour int) []models.Session {
+		history := make([]models.Session, 0, days)
+		for day := 1; day <= days; day++ {
+			at := time.Date(2024, 3, 1-day, hour, 0, 0, 0, time.UTC)
+			history = append(history, session("laptop", "192.0.2.1", paris, at))
+		}
+		return history
+	}
+
+	tests := []struct {
+		name        string
+		login       models.Session
+		history     []models.Session
+		failures    int
+		wantScore   int
+		wantFactors []string
+		wantAction  string
+	}{
+		{"first login", session("laptop", "192.0.2.1", paris, now), nil, 0, 0, nil, models.RiskAllow},
+		{"failures", session("laptop", "192.0.2.1", paris, now), nil, 3, 15, []string{"failed_attempts"}, models.RiskAllow},
+		{"failures capped", session("laptop", "192.0.2.1", paris, now), nil, 20, 25, []string{"failed_attempts"}, models.RiskAllow},
+		{"known login", session("laptop", "192.0.2.1", paris, now), usual, 0, 0, nil, models.RiskAllow},
+		{"new device", session("phone", "192.0.2.1", paris, now), usual, 0, 10, []string{"new_device"}, models.RiskAllow},
+		{"new ip", session("laptop", "192.0.2.2", paris, now), usual, 0, 5, []string{"new_ip"}, models.RiskAllow},
+		{
+			"new country",
+			session("phone", "198.51.100.1", models.GeoLocation{Country: "DE"}, now), usual, 0,
+			40, []string{"new_device", "new_ip", "new_country"}, models.RiskFlag,
+		},
+		{
+			"history not located",
+			session("laptop", "192.0.2.1", models.GeoLocation{Country: "DE"}, now),
+			[]models.Session{session("laptop", "192.0.2.1", models.GeoLocation{}, now.Add(-time.Hour))}, 0,
+			0, nil, models.RiskAllow,
+		},
+		{
+			"impossible travel",
+			session("laptop", "192.0.2.1", newYork, now),
+			[]models.Session{session("laptop", "192.0.2.1", paris, now.Add(-time.Hour))}, 0,
+			85, []string{"new_country", "impossible_travel"}, models.RiskFlag,
+		},
+		{
+			"impossible travel with failures",
+			session("laptop", "192.0.2.1", newYork, now),
+			[]models.Session{session("laptop", "192.0.2.1", paris, now.Add(-time.Hour))}, 1,
+			90, []string{"failed_attempts", "new_country", "impossible_travel"}, models.RiskBlock,
+		},
+		{
+			"possible travel",
+			session("laptop", "192.0.2.1", lyon, now),
+			[]models.Session{session("laptop", "192.0.2.1", paris, now.Add(-time.Hour))}, 0,
+			0, nil, models.RiskAllow,
+		},
+		{
+			"travel to newer login is impossible",
+			session("laptop", "192.0.2.1", newYork, now),
+			[]models.Session{session("laptop", "192.0.2.1", paris, now.Add(time.Minute))}, 0,
+			85, []string{"new_country", "impossible_travel"}, models.RiskFlag,
+		},
+		{
+			"only the last located session counts",
+			session("laptop", "192.0.2.1", lyon, now),
+			[]models.Session{
+				session("laptop", "192.0.2.1", models.GeoLocation{}, now.Add(-time.Minute)),
+				session("laptop", "192.0.2.1", paris, now.Add(-time.Hour)),
+				session("laptop", "192.0.2.1", newYork, now.Add(-2*time.Hour)),
+			}, 0,
+			0, nil, models.RiskAllow,
+		},
+		{"unusual hour", session("laptop", "192.0.2.1", paris, now.Add(12*time.Hour)), daily(5, 10), 0, 10, []string{"unusual_hour"}, models.RiskAllow},
+		{"usual hour", session("laptop", "192.0.2.1", paris, now.Add(2*time.Hour)), daily(5, 10), 0, 0, nil, models.RiskAllow},
+		{"too little history for hours", session("laptop", "192.0.2.1", paris, now.Add(12*time.Hour)), daily(4, 10), 0, 0, nil, models.RiskAllow},
+		{"hours wrap at midnight", session("laptop", "192.0.2.1", paris, now.Add(15*time.Hour)), daily(5, 23), 0, 0, nil, models.RiskAllow},
+		{
+			"score capped",
+			session("phone", "198.51.100.1", newYork, now),
+			[]models.Session{session("laptop", "192.0.2.1", paris, now.Add(-time.Hour))}, 5,
+			100, []string{"failed_attempts", "new_device", "new_ip", "new_country", "impossible_travel"}, models.RiskBlock,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := engine.Assess(test.login, test.history, test.failures)
+			if got.Score != test.wantScore || got.Action != test.wantAction || !slices.Equal(got.Factors, test.wantFactors) {
+				t.Errorf("Assess() = %d %s %q, want %d %s %q", got.Score, got.Action, got.Factors,
+					test.wantScore, test.wantAction, test.wantFactors)
+			}
+			if got.Location != test.login.Location {
+				t.Errorf("Assess() location = %+v, want %+v", got.Location, test.login.Location)
+			}
+		})
+	}
+}
+
+func TestRiskEngineAction(t *testing.T) {
+	tests := []struct {
+		name   string
+		engine RiskEngine
+		score  int
+		want   string
+	}{
+		{"below flag", RiskEngine{flagScore: 30, blockScore: 90}, 29, models.RiskAllow},
+		{"flag", RiskEngine{flagScore: 30, blockScore: 90}, 30, models.RiskFlag},
+		{"step-up off", RiskEngine{flagScore: 30, blockScore: 90}, 89, models.RiskFlag},
+		{"block", RiskEngine{flagScore: 30, blockScore: 90}, 90, models.RiskBlock},
+		{"step-up", RiskEngine{flagScore: 30, stepUpScore: 60, blockScore: 90}, 60, models.RiskStepUp},
+		{"block over step-up", RiskEngine{flagScore: 30, stepUpScore: 60, blockScore: 90}, 95, models.RiskBlock},
+		{"block off", RiskEngine{flagScore: 30}, 100, models.RiskFlag},
+		{"all off", RiskEngine{}, 100, models.RiskAllow},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.engine.action(test.score); got != test.want {
+				t.Errorf("action(%d) = %q, want %q", test.score, got, test.want)
+			}
+		})
+	}
+}
@@ -3,14 +3,17 @@ package core
 import (
 	"auth/internal/core/domain/models"
 	"auth/pkg/customError"
+	"bytes"
 	"context"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/minio/minio-go/v7"
-	"golang.org/x/crypto/bcrypt"
 	"io"
+	"log"
+	"net/url"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -18,13 +21,22 @@ import (
 type UsersRepository interface {
 	GetUserByLogin(login string) (models.User, error)
 	GetUserRolesByLogin(login string) ([]string, error)
+	GetUserDirectRolesByLogin(login string) ([]string, error)
 	GetRolesListAsMap() (map[string]bool, error)
 	GetRoleIdByName(role string) (string, error)
+	ListUsers(query models.UsersQuery) ([]models.User, int, error)
+	GetUserStatus(login string) (string, error)
+	GetDeletedUserByLogin(login string) (models.User, error)
+	GetDeletedUsers(before time.Time) ([]models.User, error)
+	GetRoleHistory(profileId string) ([]models.RoleChange, error)
 
 	Register(login string, hashPassword []byte) error
-	Login(login, password string) error
-	Unregister(login string) error
+	UpdatePassword(login string, hashPassword []byte) error
+	Purge(login string, deletedBefore time.Time, removeFiles func() error) (bool, error)
 	AddRole(profileId, newRoleId string) error
+	SetUserStatus(login, status, reason string) error
+	MarkDeleted(login string) error
+	Restore(login string) error
 }
 
 type FileStorage interface {
@@ -32,60 +44,196 @@ type FileStorage interface {
 	RemoveBucket(ctx context.Context, bucketName string) error
 	RemoveObjects(ctx context.Context, bucketName string) error
 	UploadFile(ctx context.Context, bucketName, fileName string, file io.Reader, size int64, contentType string) error
-	DownloadFile(ctx context.Context, bucketName, fileName, path string) error
+	OpenFile(ctx context.Context, bucketName, fileName string) (io.ReadSeekCloser, minio.ObjectInfo, error)
 	DeleteFile(ctx context.Context, bucketName, fileName string) error
 	GetFile(ctx context.Context, bucketName, fileName string) (minio.ObjectInfo, error)
-	GetFileList(ctx context.Context, bucketName string) []string
+	GetFileList(ctx context.Context, bucketName string) ([]string, error)
+	ListFiles(ctx context.Context, bucketName, prefix, delimiter, startAfter, continuationToken string) ([]models.FileInfo, string, error)
+	BucketUsage(ctx context.Context, bucketName string) (int64, int64, error)
+	CreateFolder(ctx context.Context, bucketName, folder string) error
+	CopyFile(ctx context.Context, bucketName, source, destination string) error
+	RemoveFiles(ctx context.Context, bucketName string, fileNames []string) error
+	PresignGet(ctx context.Context, bucketName, fileName, disposition string, expiry time.Duration) (*url.URL, error)
 }
 
 type UserService struct {
-	repo        UsersRepository
-	fileStorage FileStorage
+	repo          UsersRepository
+	fileStorage   FileStorage
+	guard         *LoginGuard
+	policy        *PasswordPolicy
+	hasher        *PasswordHasher
+	sessions      SessionsRepository
+	notifier      LoginNotifier
+	risk          *RiskEngine
+	fileTypes     *FileTypePolicy
+	quotas        *StorageQuotas
+	shares        SharesRepository
+	shareLinks    ShareLinksRepository
+	audit         *AuditLog
+	deletionGrace time.Duration
 }
 
-func NewUserService(repo UsersRepository, fileStorage FileStorage) *UserService {
+// NewUserService creates the service. notifier may be nil when nobody is told about logins from new devices.
+// Changes made through the service are recorded in audit.
+func NewUserService(repo UsersRepository, fileStorage FileStorage, guard *LoginGuard, policy *PasswordPolicy, hasher *PasswordHasher,
+	sessions SessionsRepository, notifier LoginNotifier, risk *RiskEngine, fileTypes *FileTypePolicy, quotas *StorageQuotas,
+	shares SharesRepository, shareLinks ShareLinksRepository, audit *AuditLog) *UserService {
 	return &UserService{
-		repo:        repo,
-		fileStorage: fileStorage,
+		repo:          repo,
+		fileStorage:   fileStorage,
+		guard:         guard,
+		policy:        policy,
+		hasher:        hasher,
+		sessions:      sessions,
+		notifier:      notifier,
+		risk:          risk,
+		fileTypes:     fileTypes,
+		quotas:        quotas,
+		shares:        shares,
+		shareLinks:    shareLinks,
+		audit:         audit,
+		deletionGrace: deletionGracePeriod(),
 	}
 }
 
-func (service *UserService) RegisterUser(login, pass string) error {
+func (service *UserService) RegisterUser(ctx context.Context, login, pass string) error {
 
 	_, err := service.repo.GetUserByLogin(login)
 	if err == nil {
 		return customError.ExistingLoginError
 	}
 
-	hashPassword, err := bcrypt.GenerateFromPassword([]byte(pass), 14)
+	_, err = service.repo.GetDeletedUserByLogin(login)
+	if err == nil {
+		return customError.ExistingLoginError
+	}
+
+	err = service.checkPassword(login, pass)
+	if err != nil {
+		return err
+	}
+
+	hashPassword, err := service.hasher.Hash(pass)
 	if err != nil {
 		return err
 	}
 
-	err = service.repo.Register(login, hashPassword)
+	err = service.repo.Register(login, []byte(hashPassword))
 	if err != nil {
 		return err
 	}
 
+	service.audit.RecordAction(ctx, models.AuditRegister, login, nil)
+
 	return nil
 }
 
-func (service *UserService) LoginUser(login, pass string) (string, error) {
+// checkPassword applies the password policy. Every path that sets a password must call it.
+func (service *UserService) checkPassword(login, pass string) error {
+	return service.policy.Check(login, pass)
+}
+
+// LoginUser checks credentials and issues an access token. Failed attempts are
+// counted by the login guard, which rejects logins from blocked logins or IPs.
+// Every token belongs to a session recorded with the IP and user agent of the login.
+//
+// Logins with correct credentials are scored by the risk engine. Logins to block
+// or to step up, which the service has no second factor for yet, are refused like
+// a wrong password and counted as failures, so the answer does not tell that the
+// password was right. Every attempt is audited with the assessment, if it got
+// that far.
+func (service *UserService) LoginUser(ctx context.Context, login, pass, ip, userAgent string) (string, error) {
+
+	token, assessment, err := service.login(login, pass, ip, userAgent)
+
+	details := riskDetails(assessment)
+	if err != nil {
+		details["reason"] = err.Error()
+		service.audit.RecordAction(ctx, models.AuditLoginFailed, login, details)
+	} else {
+		service.audit.RecordAction(ctx, models.AuditLogin, login, details)
+	}
+
+	return token, err
+}
+
+func (service *UserService) login(login, pass, ip, userAgent string) (string, models.RiskAssessment, error) {
+
+	err := service.guard.Check(login, ip)
+	if err != nil {
+		return "", models.RiskAssessment{}, err
+	}
 
 	dbData, err := service.repo.GetUserByLogin(login)
 	if err != nil {
-		return "", customError.UnexistingLoginError
+		service.loginFailed(login, ip)
+		return "", models.RiskAssessment{}, customError.UnexistingLoginError
 	}
 
-	err = service.repo.Login(pass, dbData.Password)
+	needsRehash, err := service.hasher.Verify(pass, dbData.Password)
 	if err != nil {
-		return "", err
+		service.loginFailed(login, ip)
+		return "", models.RiskAssessment{}, err
+	}
+
+	if needsRehash {
+		service.rehashPassword(login, pass)
+	}
+
+	failures, err := service.guard.Failures(login, ip)
+	if err != nil {
+		log.Printf("Reading failed logins of %s failed: %v", login, err)
+	}
+
+	err = statusError(dbData.Status)
+	if err != nil {
+		return "", models.RiskAssessment{}, err
+	}
+
+	previous, err := service.sessions.GetSessions(dbData.Id)
+	if err != nil {
+		return "", models.RiskAssessment{}, err
+	}
+
+	session := models.Session{
+		ProfileId: dbData.Id,
+		IP:        ip,
+		UserAgent: userAgent,
+		Device:    deviceLabel(userAgent),
+		Location:  service.risk.Locate(ip),
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(tokenTTL),
+	}
+
+	// The password was right, so a login needing step-up is not a failure. A
+	// blocked one counts, as whoever made it is likely not the owner.
+	assessment := service.risk.Assess(session, previous, failures)
+	switch assessment.Action {
+	case models.RiskBlock:
+		service.loginFailed(login, ip)
+		return "", assessment, customError.LoginBlockedError
+	case models.RiskStepUp:
+		return "", assessment, customError.StepUpRequiredError
+	}
+
+	err = service.guard.Success(login)
+	if err != nil {
+		log.Printf("Resetting failed logins of %s failed: %v", login, err)
+	}
+
+	session.RiskScore, session.RiskAction = assessment.Score, assessment.Action
+
+	sessionId, err := service.startSession(dbData.Login, session, previous)
+	if err != nil {
+		return "", assessment, err
 	}
 
 	payload := jwt.MapClaims{
-		"exp":   time.Now().Add(time.Minute * 60).Unix(),
-		"login": dbData.Login,
-		"roles": dbData.Roles,
+		"jti":    sessionId,
+		"exp":    time.Now().Add(tokenTTL).Unix(),
+		"login":  dbData.Login,
+		"roles":  dbData.Roles,
+		"groups": dbData.Groups,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
@@ -94,35 +242,73 @@ func (service *UserService) LoginUser(login, pass string) (string, error) {
 
 	t, err := token.SignedString([]byte(JWT_SECRET_KEY))
 	if err != nil {
-		return "", err
+		return "", assessment, err
+	}
+
+	return t, assessment, nil
+}
+
+// rehashPassword stores the password hashed with the current algorithm and parameters.
+// Login goes on if it fails, the old hash stays valid.
+func (service *UserService) rehashPassword(login, pass string) {
+	hashPassword, err := service.hasher.Hash(pass)
+	if err == nil {
+		err = service.repo.UpdatePassword(login, []byte(hashPassword))
 	}
+	if err != nil {
+		log.Printf("Rehashing password of %s failed: %v", login, err)
+	}
+}
 
-	return t, err
+func (service *UserService) loginFailed(login, ip string) {
+	err := service.guard.Failure(login, ip)
+	if err != nil {
+		log.Printf("Recording failed login of %s failed: %v", login, err)
+	}
 }
 
-func (service *UserService) UnregisterUser(login string) error {
+func (service *UserService) ClearLockout(ctx context.Context, login, ip string) error {
+
+	err := service.guard.Clear(login, ip)
+	if err != nil {
+		return err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditLockoutCleared, login, map[string]string{"ip": ip})
+
+	return nil
+}
+
+// UnregisterUser marks an account deleted. The profile and its bucket are kept
+// until the deletion grace period ends and the purger removes them.
+func (service *UserService) UnregisterUser(ctx context.Context, login string) error {
 
 	_, err := service.repo.GetUserByLogin(login)
 	if err != nil {
 		return customError.UnexistingLoginError
 	}
 
-	err = service.repo.Unregister(login)
+	err = service.repo.MarkDeleted(login)
 	if err != nil {
 		return err
 	}
 
+	service.audit.RecordAction(ctx, models.AuditUnregister, login, nil)
+
 	return nil
 }
 
-func (service *UserService) AddRoles(login, newRolesString string) (map[string]string, error) {
+// AddRoles grants login the roles in the space separated newRolesString and
+// reports for each role whether it was added. The roles added are audited, even
+// when a later one fails.
+func (service *UserService) AddRoles(ctx context.Context, login, newRolesString string) (map[string]string, error) {
 
 	profileData, err := service.repo.GetUserByLogin(login)
 	if err != nil {
 		return nil, customError.UnexistingLoginError
 	}
 
-	oldRoles, err := service.repo.GetUserRolesByLogin(login)
+	oldRoles, err := service.repo.GetUserDirectRolesByLogin(login)
 	if err != nil {
 		return nil, customError.UnexistingLoginError
 	}
@@ -138,6 +324,13 @@ func (service *UserService) AddRoles(login, newRolesString string) (map[string]s
 	}
 
 	newRolesStatus := make(map[string]string)
+	var added []string
+	defer func() {
+		if len(added) > 0 {
+			slices.Sort(added)
+			service.audit.RecordAction(ctx, models.AuditRolesAdded, login, map[string]string{"roles": strings.Join(added, " ")})
+		}
+	}()
 
 	for i := 0; i < len(newRoles); i++ {
 		if !existingRoles[newRoles[i]] {
@@ -162,6 +355,7 @@ func (service *UserService) AddRoles(login, newRolesString string) (map[string]s
 		}
 
 		newRolesStatus[newRoles[i]] = "role was successfully added"
+		added = append(added, newRoles[i])
 	}
 
 	return newRolesStatus, nil
@@ -207,61 +401,108 @@ func (service *UserService) RemoveBucket(ctx context.Context, login string) erro
 		return err
 	}
 
-	return nil
+	return service.quotas.SetUsage(profileData.Id, 0, 0)
 }
 
-func (service *UserService) UploadFile(ctx context.Context, login, fileName string, file io.Reader, size int64, contentType string) error {
+// UploadFile stores a new file of login after checking it against the file type
+// policy and their storage quota, and returns its content type. SVG images are
+// stored sanitized.
+func (service *UserService) UploadFile(ctx context.Context, login, fileName string, file io.ReaderAt, size int64, declaredType string) (string, error) {
 
 	profileData, err := service.repo.GetUserByLogin(login)
 	if err != nil {
-		return customError.UnexistingLoginError
+		return "", customError.UnexistingLoginError
 	}
 
 	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
 
 	_, err = service.fileStorage.GetFile(ctx, bucketName, fileName)
 	if err == nil {
-		return customError.ExistingFileError
+		return "", customError.ExistingFileError
 	}
 
-	return service.fileStorage.UploadFile(ctx, bucketName, fileName, file, size, contentType)
-}
+	roles, err := service.repo.GetUserRolesByLogin(login)
+	if err != nil {
+		return "", err
+	}
 
-func (service *UserService) DownloadFile(ctx context.Context, login, fileName, path string) error {
+	contentType, err := service.fileTypes.Check(roles, fileName, declaredType, file, size)
+	if err != nil {
+		return "", err
+	}
 
-	profileData, err := service.repo.GetUserByLogin(login)
+	var body io.Reader = io.NewSectionReader(file, 0, size)
+	if contentType == "image/svg+xml" {
+		sanitized, err := sanitizeSVGFile(body, size)
+		if err != nil {
+			return "", err
+		}
+		body, size = bytes.NewReader(sanitized), int64(len(sanitized))
+	}
+
+	err = service.quotas.Reserve(profileData.Id, roles, size)
 	if err != nil {
-		return customError.UnexistingLoginError
+		return "", err
 	}
 
-	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
+	err = service.fileStorage.UploadFile(ctx, bucketName, fileName, body, size, contentType)
+	if err != nil {
+		service.quotas.Release(profileData.Id, size)
+		return "", err
+	}
 
-	_, err = service.fileStorage.GetFile(ctx, bucketName, fileName)
+	service.audit.RecordAction(ctx, models.AuditFileUploaded, profileData.Login, map[string]string{
+		"file": fileName, "size": strconv.FormatInt(size, 10), "content_type": contentType,
+	})
+
+	return contentType, nil
+}
+
+// DownloadFile opens a file of login for streaming, if requester may read it.
+// Seeking the returned body reads from another offset, so byte ranges can be
+// served; the caller must close it.
+func (service *UserService) DownloadFile(ctx context.Context, requester, login, fileName string) (io.ReadSeekCloser, minio.ObjectInfo, error) {
+
+	profileData, err := service.repo.GetUserByLogin(login)
 	if err != nil {
-		return customError.UnexistingFileError
+		return nil, minio.ObjectInfo{}, customError.UnexistingLoginError
 	}
 
-	if _, err := os.Stat(fmt.Sprintf("%s/%s", path, fileName)); err == nil {
-		return customError.ExistingFileError
+	err = service.authorize(requester, profileData, fileName, models.ShareRead)
+	if err != nil {
+		return nil, minio.ObjectInfo{}, err
 	}
 
-	err = service.fileStorage.DownloadFile(ctx, bucketName, fileName, path)
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
+
+	body, info, err := service.fileStorage.OpenFile(ctx, bucketName, fileName)
 	if err != nil {
-		return err
+		return nil, minio.ObjectInfo{}, customError.UnexistingFileError
 	}
 
-	return nil
+	return body, info, nil
 }
 
-func (service *UserService) DeleteFile(ctx context.Context, login, fileName string) error {
+// DeleteFile deletes a file of login, if requester may write it, along with its shares and links.
+func (service *UserService) DeleteFile(ctx context.Context, requester, login, fileName string) error {
 
 	profileData, err := service.repo.GetUserByLogin(login)
 	if err != nil {
 		return customError.UnexistingLoginError
 	}
 
+	err = service.authorize(requester, profileData, fileName, models.ShareReadWrite)
+	if err != nil {
+		return err
+	}
+
 	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
 
+	// Folder markers go with DeleteFolder.
+	if strings.HasSuffix(fileName, "/") {
+		return customError.UnexistingFileError
+	}
+
 	obj, err := service.fileStorage.GetFile(ctx, bucketName, fileName)
 	fmt.Println(obj, err)
 	if err != nil {
@@ -273,19 +514,12 @@ func (service *UserService) DeleteFile(ctx context.Context, login, fileName stri
 		return err
 	}
 
-	return nil
-}
-
-func (service *UserService) GetFileList(ctx context.Context, login string) ([]string, error) {
+	service.audit.RecordAction(ctx, models.AuditFileDeleted, profileData.Login, map[string]string{"file": fileName})
 
-	profileData, err := service.repo.GetUserByLogin(login)
+	err = service.unshare(profileData.Id, fileName)
 	if err != nil {
-		return make([]string, 0), customError.UnexistingLoginError
+		return err
 	}
 
-	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
-
-	list := service.fileStorage.GetFileList(ctx, bucketName)
-
-	return list, nil
+	return service.quotas.Release(profileData.Id, obj.Size)
 }
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeSessions returns the same earlier sessions for every account.
+type fakeSessions struct {
+	SessionsRepository
+	sessions []models.Session
+}
+
+func (repo fakeSessions) GetSessions(string) ([]models.Session, error) {
+	return repo.sessions, nil
+}
+
+func TestLoginUserRisk(t *testing.T) {
+	hasher := testHasher(hashArgon2id, 0, 64)
+	hash := mustHash(t, hasher, "secret")
+	history := []models.Session{{Device: "Firefox on Linux", IP: "192.0.2.1", CreatedAt: time.Now().Add(-time.Hour)}}
+
+	tests := []struct {
+		name         string
+		risk         *RiskEngine
+		password     string
+		wantErr      error
+		wantFailures int
+	}{
+		{"wrong password", &RiskEngine{}, "wrong", customError.IncorrectPasswordError, 1},
+		{"step-up", &RiskEngine{stepUpScore: 10}, "secret", customError.StepUpRequiredError, 0},
+		{"blocked", &RiskEngine{blockScore: 10}, "secret", customError.LoginBlockedError, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			audit, events := newTestAuditLog()
+			service := &UserService{
+				repo:     fakeUsers{users: map[string]models.User{"bob": {Id: "1", Login: "bob", Password: hash, Status: models.StatusActive}}},
+				guard:    newTestGuard(fakeAttemptStore{}),
+				hasher:   hasher,
+				sessions: fakeSessions{sessions: history},
+				risk:     test.risk,
+				audit:    audit,
+			}
+
+			_, err := service.LoginUser(context.Background(), "bob", test.password, "198.51.100.7", "curl/8.0")
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("LoginUser() error = %v, want %v", err, test.wantErr)
+			}
+
+			failures, err := service.guard.Failures("bob", "198.51.100.7")
+			if err != nil || failures != test.wantFailures {
+				t.Errorf("failures = %d, %v, want %d", failures, err, test.wantFailures)
+			}
+
+			if len(*events) != 1 || (*events)[0].Action != models.AuditLoginFailed || (*events)[0].Details["reason"] != test.wantErr.Error() {
+				t.Errorf("recorded %+v, want one failed login because %q", *events, test.wantErr)
+			}
+		})
+	}
+}
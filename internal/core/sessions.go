@@ -0,0 +1,173 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	tokenTTL                = time.Hour
+	defaultSessionRetention = 90 * 24 * time.Hour
+)
+
+type SessionsRepository interface {
+	CreateSession(session models.Session) (string, error)
+	GetSession(id string) (models.Session, error)
+	GetSessions(profileId string) ([]models.Session, error)
+	RevokeSession(id string) error
+	DeleteSessionsBefore(before time.Time) (int64, error)
+}
+
+// LoginNotifier is told when an account logs in from a device or an IP that none
+// of its sessions kept for SESSION_RETENTION used before. It must not block.
+type LoginNotifier interface {
+	NotifyNewLogin(login string, session models.Session, newDevice, newIP bool)
+}
+
+// startSession records a successful login and returns the session id, which
+// becomes the jti of the issued token. previous are the earlier sessions of the account.
+func (service *UserService) startSession(login string, session models.Session, previous []models.Session) (string, error) {
+
+	var err error
+	session.Id, err = service.sessions.CreateSession(session)
+	if err != nil {
+		return "", err
+	}
+
+	// The first login of an account has nothing to compare with.
+	if service.notifier == nil || len(previous) == 0 {
+		return session.Id, nil
+	}
+
+	newDevice, newIP := true, true
+	for _, seen := range previous {
+		if seen.Device == session.Device {
+			newDevice = false
+		}
+		if seen.IP == session.IP {
+			newIP = false
+		}
+	}
+
+	if newDevice || newIP {
+		service.notifier.NotifyNewLogin(login, session, newDevice, newIP)
+	}
+
+	return session.Id, nil
+}
+
+// CheckSession returns an error unless the session of a token exists and was not revoked.
+func (service *UserService) CheckSession(id string) error {
+	if id == "" {
+		return customError.InvalidTokenError
+	}
+
+	session, err := service.sessions.GetSession(id)
+	if err != nil {
+		return customError.InvalidTokenError
+	}
+
+	if !session.RevokedAt.IsZero() {
+		return customError.RevokedSessionError
+	}
+
+	return nil
+}
+
+// ListSessions returns the login history of an account, newest first.
+func (service *UserService) ListSessions(login string) ([]models.Session, error) {
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return nil, customError.UnexistingLoginError
+	}
+
+	return service.sessions.GetSessions(profileData.Id)
+}
+
+// RevokeSession makes the token of a session of login unusable before it expires.
+func (service *UserService) RevokeSession(ctx context.Context, login, id string) error {
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return customError.UnexistingLoginError
+	}
+
+	session, err := service.sessions.GetSession(id)
+	if err != nil || session.ProfileId != profileData.Id {
+		return customError.UnexistingSessionError
+	}
+
+	if !session.RevokedAt.IsZero() {
+		return nil
+	}
+
+	err = service.sessions.RevokeSession(id)
+	if err != nil {
+		return err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditSessionRevoked, profileData.Login, map[string]string{"session": id})
+
+	return nil
+}
+
+// PruneSessions forgets sessions that expired longer than SESSION_RETENTION ago.
+func (service *UserService) PruneSessions() error {
+	deleted, err := service.sessions.DeleteSessionsBefore(time.Now().Add(-envDuration("SESSION_RETENTION", defaultSessionRetention)))
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		log.Printf("Pruned %d old sessions", deleted)
+	}
+
+	return nil
+}
+
+// deviceLabel names the browser and operating system of a user agent, e.g.
+// "Firefox on Linux". It is coarse on purpose: minor browser updates must not
+// make a known device look new.
+func deviceLabel(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, candidate := range [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(userAgent, candidate[0]) {
+			browser = candidate[1]
+			break
+		}
+	}
+
+	system := "unknown OS"
+	for _, candidate := range [][2]string{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"CrOS", "ChromeOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, candidate[0]) {
+			system = candidate[1]
+			break
+		}
+	}
+
+	return browser + " on " + system
+}
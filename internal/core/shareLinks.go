@@ -0,0 +1,178 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/minio/minio-go/v7"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ShareLinksRepository interface {
+	CreateLink(link models.ShareLink) (models.ShareLink, error)
+	GetLink(id string) (models.ShareLink, error)
+	GetLinkByToken(tokenHash string) (models.ShareLink, error)
+	GetActiveLinks(ownerId string) ([]models.ShareLink, error)
+	ClaimDownload(id string) (bool, error)
+	RevokeLink(id string) error
+	DeleteLinks(ownerId, path string) error
+}
+
+// hashLinkToken is how a link token is stored. Tokens are random enough that a
+// plain hash cannot be reversed, and it lets links be found by their token.
+func hashLinkToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// linkUsable tells whether a link still serves downloads at now.
+func linkUsable(link models.ShareLink, now time.Time) bool {
+	return link.RevokedAt.IsZero() && (link.ExpiresAt.IsZero() || now.Before(link.ExpiresAt)) &&
+		(link.MaxDownloads == 0 || link.Downloads < link.MaxDownloads)
+}
+
+// CreateShareLink makes a public link to a file of login and returns it with its
+// token, which is not stored and cannot be shown again. A zero expires or
+// maxDownloads means no limit, an empty password none.
+func (service *UserService) CreateShareLink(ctx context.Context, login, fileName, password string, expires time.Duration,
+	maxDownloads int64) (models.ShareLink, string, error) {
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return models.ShareLink{}, "", customError.UnexistingLoginError
+	}
+
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
+
+	_, err = service.fileStorage.GetFile(ctx, bucketName, fileName)
+	if err != nil || strings.HasSuffix(fileName, "/") {
+		return models.ShareLink{}, "", customError.UnexistingFileError
+	}
+
+	random := make([]byte, 32)
+	_, err = rand.Read(random)
+	if err != nil {
+		return models.ShareLink{}, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(random)
+
+	link := models.ShareLink{
+		OwnerId:      profileData.Id,
+		OwnerLogin:   profileData.Login,
+		Path:         fileName,
+		TokenHash:    hashLinkToken(token),
+		MaxDownloads: maxDownloads,
+	}
+	if expires > 0 {
+		link.ExpiresAt = time.Now().Add(expires)
+	}
+	if password != "" {
+		link.PasswordHash, err = service.hasher.Hash(password)
+		if err != nil {
+			return models.ShareLink{}, "", err
+		}
+	}
+
+	link, err = service.shareLinks.CreateLink(link)
+	if err != nil {
+		return models.ShareLink{}, "", err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditLinkCreated, profileData.Login, map[string]string{
+		"link": link.Id, "file": link.Path, "protected": strconv.FormatBool(link.PasswordHash != ""),
+		"expires": strconv.Itoa(int(expires.Seconds())), "max_downloads": strconv.FormatInt(link.MaxDownloads, 10),
+	})
+
+	return link, token, nil
+}
+
+// ListShareLinks returns the links of login that still serve downloads.
+func (service *UserService) ListShareLinks(login string) ([]models.ShareLink, error) {
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return nil, customError.UnexistingLoginError
+	}
+
+	return service.shareLinks.GetActiveLinks(profileData.Id)
+}
+
+// RevokeShareLink stops a link from serving downloads, which only its owner and
+// Admins may do. It returns the revoked link.
+func (service *UserService) RevokeShareLink(ctx context.Context, requester, id string) (models.ShareLink, error) {
+
+	link, err := service.shareLinks.GetLink(id)
+	if err != nil {
+		return models.ShareLink{}, customError.UnexistingLinkError
+	}
+
+	if requester != link.OwnerLogin {
+		roles, err := service.repo.GetUserRolesByLogin(requester)
+		if err != nil || !slices.Contains(roles, "Admin") {
+			return models.ShareLink{}, customError.NoPermission
+		}
+	}
+
+	err = service.shareLinks.RevokeLink(id)
+	if err != nil {
+		return models.ShareLink{}, err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditLinkRevoked, link.OwnerLogin, map[string]string{"link": link.Id, "file": link.Path})
+
+	return link, nil
+}
+
+// OpenShareLink opens the file of a link for streaming and counts the download.
+// password must match when the link has one. The caller must close the body.
+func (service *UserService) OpenShareLink(ctx context.Context, token, password string) (io.ReadSeekCloser, minio.ObjectInfo, error) {
+
+	link, err := service.shareLinks.GetLinkByToken(hashLinkToken(token))
+	if err != nil {
+		return nil, minio.ObjectInfo{}, customError.UnexistingLinkError
+	}
+
+	if !linkUsable(link, time.Now()) {
+		return nil, minio.ObjectInfo{}, customError.LinkUnavailableError
+	}
+
+	if link.PasswordHash != "" {
+		if password == "" {
+			return nil, minio.ObjectInfo{}, customError.LinkPasswordError
+		}
+		_, err = service.hasher.Verify(password, link.PasswordHash)
+		if err != nil {
+			return nil, minio.ObjectInfo{}, customError.LinkPasswordError
+		}
+	}
+
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(link.OwnerLogin), link.OwnerId)
+
+	body, info, err := service.fileStorage.OpenFile(ctx, bucketName, link.Path)
+	if err != nil {
+		return nil, minio.ObjectInfo{}, customError.UnexistingFileError
+	}
+
+	// The limits are checked again as the download is counted, in case other
+	// downloads used the link up meanwhile.
+	claimed, err := service.shareLinks.ClaimDownload(link.Id)
+	if err != nil || !claimed {
+		body.Close()
+		if err == nil {
+			err = customError.LinkUnavailableError
+		}
+		return nil, minio.ObjectInfo{}, err
+	}
+
+	return body, info, nil
+}
@@ -0,0 +1,34 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"testing"
+	"time"
+)
+
+func TestLinkUsable(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		link models.ShareLink
+		want bool
+	}{
+		{"unlimited", models.ShareLink{}, true},
+		{"revoked", models.ShareLink{RevokedAt: now.Add(-time.Minute)}, false},
+		{"not expired", models.ShareLink{ExpiresAt: now.Add(time.Minute)}, true},
+		{"expired", models.ShareLink{ExpiresAt: now.Add(-time.Minute)}, false},
+		{"expires now", models.ShareLink{ExpiresAt: now}, false},
+		{"downloads left", models.ShareLink{MaxDownloads: 3, Downloads: 2}, true},
+		{"downloads used up", models.ShareLink{MaxDownloads: 3, Downloads: 3}, false},
+		{"no download limit", models.ShareLink{Downloads: 1000}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := linkUsable(test.link, now); got != test.want {
+				t.Errorf("linkUsable() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type SharesRepository interface {
+	CreateShare(share models.Share) (models.Share, error)
+	GetShare(id string) (models.Share, error)
+	GetShares(ownerId string) ([]models.Share, error)
+	GetSharedWith(profileId string) ([]models.Share, error)
+	FindShares(ownerId, profileId string, paths []string) ([]models.Share, error)
+	DeleteShare(id string) error
+	DeleteShares(ownerId, path string) error
+}
+
+// sharePaths are the paths a share reaching name can be on: name itself and every
+// folder above it.
+func sharePaths(name string) []string {
+	paths := []string{name}
+
+	for i := 0; i < len(name)-1; i++ {
+		if name[i] == '/' {
+			paths = append(paths, name[:i+1])
+		}
+	}
+
+	return paths
+}
+
+// authorize checks that requester may use name, a file or a folder of owner, with
+// permission. Owners and Admins may do anything, others need a share on name or
+// a folder above it.
+func (service *UserService) authorize(requester string, owner models.User, name, permission string) error {
+
+	if service.privileged(requester, owner) {
+		return nil
+	}
+
+	grantee, err := service.repo.GetUserByLogin(requester)
+	if err != nil {
+		return customError.NoPermission
+	}
+
+	shares, err := service.shares.FindShares(owner.Id, grantee.Id, sharePaths(name))
+	if err != nil {
+		return err
+	}
+
+	for _, share := range shares {
+		if share.Permission == models.ShareReadWrite || permission == models.ShareRead {
+			return nil
+		}
+	}
+
+	return customError.NoPermission
+}
+
+// authorizeListing checks that requester may list the names of owner starting
+// with prefix. Others than owners and Admins may only list a folder, a prefix
+// ending with "/", shared with them or below one. A share of a file never allows
+// listing, as a prefix naming it also matches other names.
+func (service *UserService) authorizeListing(requester string, owner models.User, prefix string) error {
+
+	if service.privileged(requester, owner) {
+		return nil
+	}
+
+	if !strings.HasSuffix(prefix, "/") {
+		return customError.NoPermission
+	}
+
+	return service.authorize(requester, owner, prefix, models.ShareRead)
+}
+
+// privileged tells whether requester is owner or an Admin, who may do anything
+// with the files of owner.
+func (service *UserService) privileged(requester string, owner models.User) bool {
+
+	if requester == owner.Login {
+		return true
+	}
+
+	roles, err := service.repo.GetUserRolesByLogin(requester)
+
+	return err == nil && slices.Contains(roles, "Admin")
+}
+
+// ShareFile gives a user or the members of a group access to a file of login, or
+// to a folder with everything in it when path ends with "/". Sharing again with
+// the same grantee changes the permission.
+func (service *UserService) ShareFile(ctx context.Context, login, path, user, group, permission string) (models.Share, error) {
+
+	if permission != models.ShareRead && permission != models.ShareReadWrite {
+		return models.Share{}, customError.InvalidPermissionError
+	}
+
+	if (user == "") == (group == "") {
+		return models.Share{}, customError.InvalidGranteeError
+	}
+
+	if !validFileName(path) {
+		return models.Share{}, customError.InvalidFileNameError
+	}
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return models.Share{}, customError.UnexistingLoginError
+	}
+
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
+
+	if strings.HasSuffix(path, "/") {
+		exists, err := service.folderExists(ctx, bucketName, path)
+		if err != nil {
+			return models.Share{}, err
+		}
+		if !exists {
+			return models.Share{}, customError.UnexistingFolderError
+		}
+	} else {
+		_, err = service.fileStorage.GetFile(ctx, bucketName, path)
+		if err != nil {
+			return models.Share{}, customError.UnexistingFileError
+		}
+	}
+
+	share := models.Share{
+		OwnerId:      profileData.Id,
+		OwnerLogin:   profileData.Login,
+		Path:         path,
+		GranteeGroup: group,
+		Permission:   permission,
+	}
+
+	if user != "" {
+		grantee, err := service.repo.GetUserByLogin(user)
+		if err != nil {
+			return models.Share{}, customError.UnexistingLoginError
+		}
+		if grantee.Id == profileData.Id {
+			return models.Share{}, customError.InvalidGranteeError
+		}
+		share.GranteeId, share.GranteeLogin = grantee.Id, grantee.Login
+	}
+
+	share, err = service.shares.CreateShare(share)
+	if err != nil {
+		return models.Share{}, err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditShareCreated, profileData.Login, map[string]string{
+		"path": share.Path, "user": share.GranteeLogin, "group": share.GranteeGroup, "permission": share.Permission,
+	})
+
+	return share, nil
+}
+
+// ListShares returns the shares login gave.
+func (service *UserService) ListShares(login string) ([]models.Share, error) {
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return nil, customError.UnexistingLoginError
+	}
+
+	return service.shares.GetShares(profileData.Id)
+}
+
+// ListSharedWith returns what others shared with login or a group of theirs.
+func (service *UserService) ListSharedWith(login string) ([]models.Share, error) {
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return nil, customError.UnexistingLoginError
+	}
+
+	return service.shares.GetSharedWith(profileData.Id)
+}
+
+// RevokeShare removes a share, which only its owner and Admins may do. It returns
+// the removed share.
+func (service *UserService) RevokeShare(ctx context.Context, requester, id string) (models.Share, error) {
+
+	share, err := service.shares.GetShare(id)
+	if err != nil {
+		return models.Share{}, customError.UnexistingShareError
+	}
+
+	if requester != share.OwnerLogin {
+		roles, err := service.repo.GetUserRolesByLogin(requester)
+		if err != nil || !slices.Contains(roles, "Admin") {
+			return models.Share{}, customError.NoPermission
+		}
+	}
+
+	err = service.shares.DeleteShare(id)
+	if err != nil {
+		return models.Share{}, err
+	}
+
+	service.audit.RecordAction(ctx, models.AuditShareRevoked, share.OwnerLogin, map[string]string{
+		"share": share.Id, "path": share.Path, "user": share.GranteeLogin, "group": share.GranteeGroup,
+	})
+
+	return share, nil
+}
+
+// unshare ends the shares and public links of an owner on path, and on everything
+// in it when it is a folder, once it is deleted or moved.
+func (service *UserService) unshare(ownerId, path string) error {
+
+	err := service.shares.DeleteShares(ownerId, path)
+	if err != nil {
+		return err
+	}
+
+	return service.shareLinks.DeleteLinks(ownerId, path)
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// fakeUsers serves users and roles from memory; other methods are not used.
+type fakeUsers struct {
+	UsersRepository
+	users map[string]models.User
+	roles map[string][]string
+}
+
+func (repo fakeUsers) GetUserByLogin(login string) (models.User, error) {
+	user, ok := repo.users[login]
+	if !ok {
+		return models.User{}, customError.UnexistingLoginError
+	}
+	return user, nil
+}
+
+func (repo fakeUsers) GetUserRolesByLogin(login string) ([]string, error) {
+	return repo.roles[login], nil
+}
+
+// fakeShares finds shares granted directly to a user.
+type fakeShares struct {
+	SharesRepository
+	shares []models.Share
+}
+
+func (repo fakeShares) FindShares(ownerId, profileId string, paths []string) ([]models.Share, error) {
+	found := make([]models.Share, 0)
+	for _, share := range repo.shares {
+		if share.OwnerId == ownerId && share.GranteeId == profileId && slices.Contains(paths, share.Path) {
+			found = append(found, share)
+		}
+	}
+	return found, nil
+}
+
+func TestSharePaths(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"a.png", []string{"a.png"}},
+		{"a/", []string{"a/"}},
+		{"a/b/c.png", []string{"a/b/c.png", "a/", "a/b/"}},
+		{"a/b/", []string{"a/b/", "a/"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := sharePaths(test.name); !slices.Equal(got, test.want) {
+				t.Errorf("sharePaths(%q) = %q, want %q", test.name, got, test.want)
+			}
+		})
+	}
+}
+
+func newSharingService() (*UserService, models.User) {
+	owner := models.User{Id: "1", Login: "owner"}
+	users := fakeUsers{
+		users: map[string]models.User{
+			"owner":  owner,
+			"admin":  {Id: "2", Login: "admin"},
+			"reader": {Id: "3", Login: "reader"},
+			"writer": {Id: "4", Login: "writer"},
+			"viewer": {Id: "5", Login: "viewer"},
+		},
+		roles: map[string][]string{"admin": {"User", "Admin"}},
+	}
+	shares := fakeShares{shares: []models.Share{
+		{OwnerId: "1", GranteeId: "3", Path: "docs/", Permission: models.ShareRead},
+		{OwnerId: "1", GranteeId: "4", Path: "docs/", Permission: models.ShareReadWrite},
+		{OwnerId: "1", GranteeId: "5", Path: "a.png", Permission: models.ShareRead},
+	}}
+
+	return &UserService{repo: users, shares: shares}, owner
+}
+
+func TestAuthorize(t *testing.T) {
+	service, owner := newSharingService()
+
+	tests := []struct {
+		name       string
+		requester  string
+		file       string
+		permission string
+		wantErr    error
+	}{
+		{"owner", "owner", "private.png", models.ShareReadWrite, nil},
+		{"admin", "admin", "private.png", models.ShareReadWrite, nil},
+		{"unknown user", "nobody", "docs/a.png", models.ShareRead, customError.NoPermission},
+		{"no share", "reader", "private.png", models.ShareRead, customError.NoPermission},
+		{"file in shared folder", "reader", "docs/a.png", models.ShareRead, nil},
+		{"file deep in shared folder", "reader", "docs/x/y/a.png", models.ShareRead, nil},
+		{"shared folder", "reader", "docs/", models.ShareRead, nil},
+		{"write with read share", "reader", "docs/a.png", models.ShareReadWrite, customError.NoPermission},
+		{"write with write share", "writer", "docs/a.png", models.ShareReadWrite, nil},
+		{"shared file", "viewer", "a.png", models.ShareRead, nil},
+		{"name sharing a prefix", "reader", "docs.png", models.ShareRead, customError.NoPermission},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := service.authorize(test.requester, owner, test.file, test.permission)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("authorize() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizeListing(t *testing.T) {
+	service, owner := newSharingService()
+
+	tests := []struct {
+		name      string
+		requester string
+		prefix    string
+		wantErr   error
+	}{
+		{"owner", "owner", "", nil},
+		{"admin", "admin", "private", nil},
+		{"whole bucket", "reader", "", customError.NoPermission},
+		{"shared folder", "reader", "docs/", nil},
+		{"subfolder of shared folder", "reader", "docs/x/", nil},
+		{"prefix without slash", "reader", "docs", customError.NoPermission},
+		{"prefix inside shared folder", "reader", "docs/a", customError.NoPermission},
+		{"shared file", "viewer", "a.png", customError.NoPermission},
+		{"folder of shared file", "viewer", "a/", customError.NoPermission},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := service.authorizeListing(test.requester, owner, test.prefix)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("authorizeListing() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package core
+
+import (
+	"auth/pkg/customError"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// svgElements are the elements an SVG may keep. Anything else, such as script,
+// foreignObject or elements of other namespaces, is dropped with its content.
+var svgElements = setOf(
+	"svg", "g", "defs", "desc", "title", "symbol", "use", "image", "switch", "a", "view", "style",
+	"path", "rect", "circle", "ellipse", "line", "polyline", "polygon", "text", "tspan", "textpath",
+	"lineargradient", "radialgradient", "stop", "pattern", "clippath", "mask", "marker",
+	"filter", "feblend", "fecolormatrix", "fecomponenttransfer", "fecomposite", "feconvolvematrix",
+	"fediffuselighting", "fedisplacementmap", "fedistantlight", "fedropshadow", "feflood", "fefunca",
+	"fefuncb", "fefuncg", "fefuncr", "fegaussianblur", "feimage", "femerge", "femergenode", "femorphology",
+	"feoffset", "fepointlight", "fespecularlighting", "fespotlight", "fetile", "feturbulence",
+	"animate", "animatetransform", "animatemotion", "set", "mpath",
+)
+
+// svgAnimations set other attributes, which must be safe to set themselves.
+var svgAnimations = setOf("animate", "animatetransform", "animatemotion", "set")
+
+// svgAttributes are the unprefixed attributes an SVG element may keep. Links are
+// checked apart.
+var svgAttributes = setOf(
+	"id", "class", "style", "lang", "version", "baseprofile", "transform", "viewbox", "preserveaspectratio",
+	"width", "height", "x", "y", "x1", "y1", "x2", "y2", "cx", "cy", "r", "rx", "ry", "fx", "fy", "fr",
+	"d", "points", "pathlength", "href",
+	"fill", "fill-opacity", "fill-rule", "stroke", "stroke-width", "stroke-linecap", "stroke-linejoin",
+	"stroke-miterlimit", "stroke-dasharray", "stroke-dashoffset", "stroke-opacity", "opacity", "color",
+	"display", "visibility", "overflow", "clip-path", "clip-rule", "clippathunits", "mask", "maskunits",
+	"maskcontentunits", "marker-start", "marker-mid", "marker-end", "markerwidth", "markerheight",
+	"markerunits", "refx", "refy", "orient", "filter", "filterunits", "primitiveunits",
+	"in", "in2", "result", "stddeviation", "dx", "dy", "rotate", "operator", "k1", "k2", "k3", "k4", "mode",
+	"type", "values", "tablevalues", "slope", "intercept", "amplitude", "exponent", "offset", "scale",
+	"xchannelselector", "ychannelselector", "radius", "basefrequency", "numoctaves", "seed", "stitchtiles",
+	"surfacescale", "diffuseconstant", "specularconstant", "specularexponent", "kernelmatrix",
+	"kernelunitlength", "order", "divisor", "bias", "targetx", "targety", "edgemode", "preservealpha",
+	"azimuth", "elevation", "pointsatx", "pointsaty", "pointsatz", "limitingconeangle",
+	"flood-color", "flood-opacity", "lighting-color", "stop-color", "stop-opacity",
+	"gradientunits", "gradienttransform", "spreadmethod", "patternunits", "patterncontentunits", "patterntransform",
+	"font-family", "font-size", "font-style", "font-weight", "font-variant", "font-stretch", "text-anchor",
+	"dominant-baseline", "alignment-baseline", "baseline-shift", "letter-spacing", "word-spacing",
+	"text-decoration", "writing-mode", "textlength", "lengthadjust", "startoffset", "method", "spacing", "side",
+	"direction", "unicode-bidi", "color-interpolation", "color-interpolation-filters", "shape-rendering",
+	"text-rendering", "image-rendering", "vector-effect", "paint-order", "mix-blend-mode", "isolation",
+	"requiredfeatures", "systemlanguage",
+	"attributename", "attributetype", "begin", "dur", "end", "repeatcount", "repeatdur", "from", "to", "by",
+	"calcmode", "keytimes", "keysplines", "keypoints", "additive", "accumulate", "restart", "min", "max",
+)
+
+// svgNamespaces are the namespaces an SVG may declare, by prefix; "" is the default one.
+var svgNamespaces = map[string]string{
+	"":      "http://www.w3.org/2000/svg",
+	"svg":   "http://www.w3.org/2000/svg",
+	"xlink": "http://www.w3.org/1999/xlink",
+}
+
+// svgSafeImages are the data URLs an SVG may embed.
+var svgSafeImages = []string{"data:image/png;", "data:image/jpeg;", "data:image/gif;", "data:image/webp;"}
+
+const maxSVGSize = 10 << 20
+
+// isSVG tells whether head starts an XML document whose root element is svg.
+func isSVG(head []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(bytes.TrimPrefix(head, []byte("\xEF\xBB\xBF"))))
+
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return false
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			return strings.EqualFold(t.Name.Local, "svg")
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) > 0 {
+				return false
+			}
+		}
+	}
+}
+
+// SanitizeSVG rewrites an SVG keeping only known drawing elements and attributes,
+// so nothing can run code or load other documents. Links may only point to
+// fragments of the document itself or embedded images, animations may not set
+// links, and stylesheets may not import, script or load anything. Comments and
+// DTDs, which could declare entities, are dropped, as are elements inside a
+// stylesheet, whose whole text is checked at once.
+func SanitizeSVG(data []byte) ([]byte, error) {
+
+	// RawToken below keeps namespace prefixes as written but does not match
+	// start and end elements, so the document is checked first.
+	checker := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := checker.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, &customError.FileRejected{Reason: "SVG is not well-formed XML: " + err.Error()}
+		}
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var out bytes.Buffer
+	dropped := 0
+	// style gathers the text of an open style element, which is only written
+	// once all of it is known to be safe.
+	var style *bytes.Buffer
+
+	for {
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, &customError.FileRejected{Reason: "SVG is not well-formed XML: " + err.Error()}
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if dropped > 0 || style != nil || !safeSVGElement(t) {
+				dropped++
+				continue
+			}
+			if strings.EqualFold(t.Name.Local, "style") {
+				style = new(bytes.Buffer)
+			}
+
+			out.WriteString("<" + qualifiedName(t.Name))
+			for _, attr := range t.Attr {
+				if !safeSVGAttr(attr) {
+					continue
+				}
+				out.WriteString(" " + qualifiedName(attr.Name) + `="`)
+				xml.EscapeText(&out, []byte(attr.Value))
+				out.WriteString(`"`)
+			}
+			out.WriteString(">")
+		case xml.EndElement:
+			if dropped > 0 {
+				dropped--
+				continue
+			}
+			if style != nil {
+				if !unsafeCSS(style.String()) {
+					xml.EscapeText(&out, style.Bytes())
+				}
+				style = nil
+			}
+			out.WriteString("</" + qualifiedName(t.Name) + ">")
+		case xml.CharData:
+			if dropped > 0 {
+				continue
+			}
+			if style != nil {
+				style.Write(t)
+				continue
+			}
+			xml.EscapeText(&out, t)
+		case xml.ProcInst:
+			if t.Target == "xml" && out.Len() == 0 {
+				out.WriteString("<?xml " + string(t.Inst) + "?>")
+			}
+		}
+	}
+
+	if dropped != 0 || !isSVG(out.Bytes()) {
+		return nil, &customError.FileRejected{Reason: "SVG is not well-formed XML"}
+	}
+
+	return out.Bytes(), nil
+}
+
+// sanitizeSVGFile reads a whole SVG of size bytes, which must fit in memory, and sanitizes it.
+func sanitizeSVGFile(file io.Reader, size int64) ([]byte, error) {
+	if size > maxSVGSize {
+		return nil, &customError.FileRejected{Reason: fmt.Sprintf("SVG images may be at most %d MiB", maxSVGSize>>20)}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, size))
+	if err != nil {
+		return nil, err
+	}
+
+	return SanitizeSVG(data)
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+func setOf(items ...string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+
+	return set
+}
+
+// normalizeSVGValue lower-cases a value and removes the whitespace and control
+// characters browsers skip, so that "java&#9;script:" is seen as "javascript:".
+func normalizeSVGValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || r == '\u00ad' || r == '\u200b' || r == '\ufeff' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, value)
+}
+
+func safeSVGElement(element xml.StartElement) bool {
+	local := strings.ToLower(element.Name.Local)
+
+	if element.Name.Space != "" && element.Name.Space != "svg" || !svgElements[local] {
+		return false
+	}
+
+	if svgAnimations[local] {
+		for _, attr := range element.Attr {
+			if strings.EqualFold(attr.Name.Local, "attributename") {
+				target := normalizeSVGValue(attr.Value)
+				target = target[strings.LastIndex(target, ":")+1:]
+				if target == "href" || target == "style" || !svgAttributes[target] {
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+func safeSVGAttr(attr xml.Attr) bool {
+	local := strings.ToLower(attr.Name.Local)
+	value := normalizeSVGValue(attr.Value)
+
+	switch attr.Name.Space {
+	case "":
+		if local == "xmlns" {
+			return attr.Value == svgNamespaces[""]
+		}
+	case "xmlns":
+		namespace, ok := svgNamespaces[attr.Name.Local]
+		return ok && attr.Value == namespace
+	case "xml":
+		return local == "space" || local == "lang"
+	case "xlink":
+		if local != "href" && local != "title" {
+			return false
+		}
+	default:
+		return false
+	}
+
+	if local == "href" {
+		if strings.HasPrefix(value, "#") {
+			return true
+		}
+		for _, prefix := range svgSafeImages {
+			if strings.HasPrefix(value, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if local == "style" {
+		return !unsafeCSS(attr.Value)
+	}
+
+	return (svgAttributes[local] || attr.Name.Space == "xlink") && !unsafeSVGValue(value)
+}
+
+// unsafeSVGValue tells whether a normalized value names a script or loads
+// something from outside the document.
+func unsafeSVGValue(value string) bool {
+	if strings.Contains(value, "javascript:") || strings.Contains(value, "vbscript:") || strings.Contains(value, "data:") {
+		return true
+	}
+
+	for rest := value; ; {
+		_, after, found := strings.Cut(rest, "url(")
+		if !found {
+			return false
+		}
+		if !strings.HasPrefix(strings.TrimLeft(after, `"'`), "#") {
+			return true
+		}
+		rest = after
+	}
+}
+
+// unsafeCSS tells whether a stylesheet may import, script or load anything. CSS
+// escapes are refused outright, as they could spell any of these.
+func unsafeCSS(css string) bool {
+	css = normalizeSVGValue(css)
+
+	return strings.Contains(css, "@import") || strings.Contains(css, "expression(") || strings.Contains(css, "\\") ||
+		strings.Contains(css, "behavior:") || strings.Contains(css, "-moz-binding") || unsafeSVGValue(css)
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"auth/pkg/customError"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeSVG(t *testing.T) {
+	const open = `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">`
+
+	tests := []struct {
+		name     string
+		input    string
+		rejected bool
+		keeps    []string
+		drops    []string
+	}{
+		{
+			name:  "plain drawing",
+			input: open + `<rect width="10" height="10" fill="red"/></svg>`,
+			keeps: []string{`<rect width="10" height="10" fill="red">`, `xmlns:xlink="http://www.w3.org/1999/xlink"`},
+		},
+		{
+			name:  "script",
+			input: open + `<script>alert(1)</script><circle r="1"/></svg>`,
+			keeps: []string{"<circle"},
+			drops: []string{"script", "alert"},
+		},
+		{
+			name:  "foreignObject",
+			input: open + `<foreignObject><div xmlns="http://www.w3.org/1999/xhtml">x</div></foreignObject></svg>`,
+			drops: []string{"foreignObject", "div"},
+		},
+		{
+			name:  "event handler",
+			input: open + `<rect onclick="alert(1)" onload="alert(2)" width="1"/></svg>`,
+			keeps: []string{`width="1"`},
+			drops: []string{"onclick", "onload", "alert"},
+		},
+		{
+			name:  "script link with tab",
+			input: open + `<use xlink:href="java&#9;script:alert(1)"/><a href=" JavaScript:alert(2)">x</a></svg>`,
+			keeps: []string{"<use>", "<a>"},
+			drops: []string{"href", "alert"},
+		},
+		{
+			name:  "fragment and image links",
+			input: open + `<use xlink:href="#shape"/><image href="data:image/png;base64,AAAA"/></svg>`,
+			keeps: []string{`xlink:href="#shape"`, `href="data:image/png;base64,AAAA"`},
+		},
+		{
+			name:  "svg data URL",
+			input: open + `<image href="data:image/svg+xml;base64,AAAA"/></svg>`,
+			drops: []string{"href"},
+		},
+		{
+			name:  "animated link",
+			input: open + `<a><animate attributeName="href" to="java&#9;script:alert(1)"/><set attributeName="xlink:href" to="javascript:alert(2)"/>x</a></svg>`,
+			keeps: []string{"<a>x</a>"},
+			drops: []string{"animate", "set", "alert"},
+		},
+		{
+			name:  "animated event handler",
+			input: open + `<animate attributeName="onbegin" to="alert(1)"/></svg>`,
+			drops: []string{"animate", "alert"},
+		},
+		{
+			name:  "animated fill",
+			input: open + `<animate attributeName="fill" from="red" to="blue" dur="1s"/></svg>`,
+			keeps: []string{`<animate attributeName="fill" from="red" to="blue" dur="1s">`},
+		},
+		{
+			name:  "local and remote url",
+			input: open + `<rect fill="url(#g)"/><rect fill="url(https://example.com/x.svg#g)"/></svg>`,
+			keeps: []string{`fill="url(#g)"`},
+			drops: []string{"example.com"},
+		},
+		{
+			name:  "style import",
+			input: open + `<style>@import url(https://example.com/x.css);</style></svg>`,
+			keeps: []string{"<style></style>"},
+			drops: []string{"import", "example.com"},
+		},
+		{
+			name:  "element inside style",
+			input: open + `<style>a{}<g></g>@import url(https://evil.example/x.css)</style></svg>`,
+			keeps: []string{"<style></style>"},
+			drops: []string{"<g>", "import", "evil.example"},
+		},
+		{
+			name:  "style split by a comment",
+			input: open + `<style>@im<!-- x -->port url(https://example.com/x.css);</style></svg>`,
+			keeps: []string{"<style></style>"},
+			drops: []string{"port", "example.com"},
+		},
+		{
+			name:  "safe style",
+			input: open + `<style>rect{fill:red}</style></svg>`,
+			keeps: []string{"<style>rect{fill:red}</style>"},
+		},
+		{
+			name:  "escaped style attribute",
+			input: open + `<rect style="background:u\72l(https://example.com)"/><rect style="fill:red"/></svg>`,
+			keeps: []string{`style="fill:red"`},
+			drops: []string{"example.com"},
+		},
+		{
+			name:  "foreign namespace",
+			input: open + `<rect xmlns:ev="http://www.w3.org/2001/xml-events" ev:event="click"/></svg>`,
+			drops: []string{"ev:", "xml-events"},
+		},
+		{
+			name:  "doctype and comment",
+			input: `<?xml version="1.0"?><!DOCTYPE svg [<!ENTITY x "y">]><!-- note -->` + open + `</svg>`,
+			keeps: []string{`<?xml version="1.0"?>`},
+			drops: []string{"DOCTYPE", "ENTITY", "note"},
+		},
+		{name: "malformed", input: open + `<rect></svg>`, rejected: true},
+		{name: "unclosed", input: open + `<rect/>`, rejected: true},
+		{name: "not an svg", input: `<html><body/></html>`, rejected: true},
+		{name: "svg inside a dropped root", input: `<html><svg/></html>`, rejected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := SanitizeSVG([]byte(test.input))
+			if test.rejected {
+				if !errors.Is(err, customError.TypeNotAllowed) {
+					t.Fatalf("SanitizeSVG() error = %v, want a rejection", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SanitizeSVG() error = %v", err)
+			}
+
+			got := string(output)
+			for _, want := range test.keeps {
+				if !strings.Contains(got, want) {
+					t.Errorf("SanitizeSVG() = %s, want it to keep %s", got, want)
+				}
+			}
+			for _, unwanted := range test.drops {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("SanitizeSVG() = %s, want it to drop %s", got, unwanted)
+				}
+			}
+		})
+	}
+}
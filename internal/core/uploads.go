@@ -0,0 +1,505 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/minio/minio-go/v7"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultUploadMaxSize  = 1 << 30
+	defaultUploadsPerUser = 5
+	defaultUploadExpiry   = 24 * time.Hour
+	minUploadPartSize     = 5 << 20
+	maxUploadParts        = 10000
+	// maxPresignedSize is the largest object storage takes in one PUT or POST.
+	maxPresignedSize = 5 << 30
+)
+
+type UploadsRepository interface {
+	CreateUpload(upload models.Upload, limit int) (bool, error)
+	GetUpload(id string) (models.Upload, error)
+	UpdateUpload(upload models.Upload) error
+	DeleteUpload(id string) error
+	GetExpiredUploads(before time.Time) ([]models.Upload, error)
+}
+
+// UploadStaging keeps the data of resumable uploads until they are complete.
+// Parts must be at least 5 MiB, except for the last one.
+type UploadStaging interface {
+	StartUpload(ctx context.Context, id string) (string, error)
+	PutUploadPart(ctx context.Context, id, multipartId string, partNumber int, data io.Reader, size int64) (string, error)
+	PutUploadTail(ctx context.Context, id string, data io.Reader, size int64) error
+	OpenUploadTail(ctx context.Context, id string) (io.ReadCloser, error)
+	AssembleUpload(ctx context.Context, id, multipartId string, parts []string) error
+	AbortUpload(ctx context.Context, id, multipartId string) error
+
+	PresignStagedPut(ctx context.Context, key, contentType string, size int64, expiry time.Duration) (*url.URL, error)
+	PresignStagedPost(ctx context.Context, key, contentType string, maxSize int64, expiry time.Duration) (*url.URL, map[string]string, error)
+	OpenStaged(ctx context.Context, key string) (io.ReadSeekCloser, minio.ObjectInfo, error)
+	MoveStaged(ctx context.Context, key, bucketName, fileName, contentType string) error
+	RemoveStaged(ctx context.Context, key string)
+}
+
+// UploadService runs resumable uploads of files too large to send in one request,
+// and uploads sent straight to storage with presigned URLs.
+// Chunks may have any size; they are collected into parts of TUS_PART_SIZE
+// before being staged, so at most one part per request is held in memory.
+// Resumable uploads may be up to TUS_MAX_SIZE bytes and presigned ones up to
+// PRESIGN_MAX_SIZE, by default 1 GiB each.
+type UploadService struct {
+	repo        UsersRepository
+	fileStorage FileStorage
+	staging     UploadStaging
+	uploads     UploadsRepository
+	presigned   PresignedUploadsRepository
+	fileTypes   *FileTypePolicy
+	quotas      *StorageQuotas
+	audit       *AuditLog
+
+	maxSize        int64
+	presignMaxSize int64
+	perUser        int
+	expiry         time.Duration
+	partSize       int64
+
+	mu     sync.Mutex
+	active map[string]bool
+}
+
+func NewUploadService(repo UsersRepository, fileStorage FileStorage, staging UploadStaging, uploads UploadsRepository,
+	presigned PresignedUploadsRepository, fileTypes *FileTypePolicy, quotas *StorageQuotas, audit *AuditLog) *UploadService {
+	maxSize := int64(envInt("TUS_MAX_SIZE", defaultUploadMaxSize))
+
+	// A multipart upload has at most 10000 parts, so large limits need larger parts.
+	partSize := max(int64(envInt("TUS_PART_SIZE", minUploadPartSize)), minUploadPartSize, (maxSize+maxUploadParts-1)/maxUploadParts)
+
+	return &UploadService{
+		repo:           repo,
+		fileStorage:    fileStorage,
+		staging:        staging,
+		uploads:        uploads,
+		presigned:      presigned,
+		fileTypes:      fileTypes,
+		quotas:         quotas,
+		audit:          audit,
+		maxSize:        maxSize,
+		presignMaxSize: min(int64(envInt("PRESIGN_MAX_SIZE", defaultUploadMaxSize)), maxPresignedSize),
+		perUser:        envInt("TUS_MAX_UPLOADS", defaultUploadsPerUser),
+		expiry:         envDuration("TUS_UPLOAD_EXPIRY", defaultUploadExpiry),
+		partSize:       partSize,
+		active:         make(map[string]bool),
+	}
+}
+
+// MaxSize is the largest upload accepted, in bytes.
+func (service *UploadService) MaxSize() int64 {
+	return service.maxSize
+}
+
+// CreateUpload starts a resumable upload of length bytes that becomes fileName
+// of login once complete. metadata is kept to be reported back to the client.
+func (service *UploadService) CreateUpload(ctx context.Context, login, fileName, declaredType string, length int64, metadata string) (models.Upload, error) {
+
+	if length > service.maxSize {
+		return models.Upload{}, customError.FileTooLargeError
+	}
+
+	profileData, err := service.repo.GetUserByLogin(login)
+	if err != nil {
+		return models.Upload{}, customError.UnexistingLoginError
+	}
+
+	roles, err := service.repo.GetUserRolesByLogin(login)
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	contentType, err := service.fileTypes.CheckName(roles, fileName, declaredType)
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	// The space is only claimed once the file is complete and verified.
+	err = service.quotas.Check(profileData.Id, roles, length)
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(profileData.Login), profileData.Id)
+
+	_, err = service.fileStorage.GetFile(ctx, bucketName, fileName)
+	if err == nil {
+		return models.Upload{}, customError.ExistingFileError
+	}
+
+	random := make([]byte, 16)
+	_, err = rand.Read(random)
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	now := time.Now()
+	upload := models.Upload{
+		Id:          hex.EncodeToString(random),
+		ProfileId:   profileData.Id,
+		Login:       profileData.Login,
+		FileName:    fileName,
+		ContentType: contentType,
+		Metadata:    metadata,
+		Length:      length,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(service.expiry),
+	}
+
+	upload.MultipartId, err = service.staging.StartUpload(ctx, upload.Id)
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	created, err := service.uploads.CreateUpload(upload, service.perUser)
+	if err != nil || !created {
+		service.staging.AbortUpload(ctx, upload.Id, upload.MultipartId)
+		if err == nil {
+			err = customError.TooManyUploadsError
+		}
+		return models.Upload{}, err
+	}
+
+	return upload, nil
+}
+
+func (service *UploadService) GetUpload(id string) (models.Upload, error) {
+
+	upload, err := service.uploads.GetUpload(id)
+	if err != nil {
+		return models.Upload{}, customError.UnexistingUploadError
+	}
+
+	if upload.ExpiresAt.Before(time.Now()) {
+		return models.Upload{}, customError.UploadExpiredError
+	}
+
+	return upload, nil
+}
+
+// AppendUpload writes a chunk starting at offset, which must be where the upload
+// stands. Whatever arrived is kept even if reading body fails, so the client can
+// resume from the returned offset. When the last byte arrives the file is checked
+// and moved into the bucket of its owner, and the returned upload has
+// Offset == Length and the stored content type.
+func (service *UploadService) AppendUpload(ctx context.Context, id string, offset int64, body io.Reader) (models.Upload, error) {
+
+	if !service.lock(id) {
+		return models.Upload{}, customError.UploadBusyError
+	}
+	defer service.unlock(id)
+
+	upload, err := service.GetUpload(id)
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	if offset != upload.Offset {
+		return upload, customError.UploadOffsetError
+	}
+
+	buffer := make([]byte, max(service.partSize, upload.TailSize))
+	filled := 0
+
+	if upload.TailSize > 0 {
+		tail, err := service.staging.OpenUploadTail(ctx, id)
+		if err != nil {
+			return upload, err
+		}
+		filled, err = io.ReadFull(tail, buffer[:upload.TailSize])
+		tail.Close()
+		if err != nil {
+			return upload, err
+		}
+	}
+
+	// Bytes before stored are in parts, the filled ones after it only in memory.
+	stored := upload.Offset - upload.TailSize
+	body = io.LimitReader(body, upload.Length-upload.Offset)
+	upload.ExpiresAt = time.Now().Add(service.expiry)
+
+	var readErr error
+	for {
+		n, err := io.ReadFull(body, buffer[filled:])
+		filled += n
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				readErr = err
+			}
+			break
+		}
+
+		err = service.putPart(ctx, &upload, buffer)
+		if err != nil {
+			return upload, err
+		}
+		stored += int64(filled)
+		filled = 0
+	}
+
+	if stored+int64(filled) == upload.Length {
+		if filled > 0 {
+			err = service.putPart(ctx, &upload, buffer[:filled])
+			if err != nil {
+				return upload, err
+			}
+		}
+
+		return upload, service.finishUpload(ctx, &upload)
+	}
+
+	if filled > 0 {
+		err = service.staging.PutUploadTail(ctx, id, bytes.NewReader(buffer[:filled]), int64(filled))
+		if err != nil {
+			return upload, errors.Join(err, readErr)
+		}
+	}
+
+	upload.Offset = stored + int64(filled)
+	upload.TailSize = int64(filled)
+
+	err = service.uploads.UpdateUpload(upload)
+	if err != nil {
+		return upload, err
+	}
+
+	return upload, readErr
+}
+
+// putPart stages data as the next part and saves the progress, so that a failure
+// later in the same request loses nothing before it.
+func (service *UploadService) putPart(ctx context.Context, upload *models.Upload, data []byte) error {
+
+	etag, err := service.staging.PutUploadPart(ctx, upload.Id, upload.MultipartId, len(upload.Parts)+1, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+
+	upload.Parts = append(upload.Parts, etag)
+	upload.Offset = upload.Offset - upload.TailSize + int64(len(data))
+	upload.TailSize = 0
+
+	return service.uploads.UpdateUpload(*upload)
+}
+
+// finishUpload checks a complete upload and moves it into the bucket of its owner.
+// A file breaking the upload rules ends the upload; after any other failure an
+// empty chunk at the final offset tries again. On success upload carries the
+// stored content type.
+func (service *UploadService) finishUpload(ctx context.Context, upload *models.Upload) error {
+
+	// Whatever happens to the client, the staged file must end up accepted or kept for a retry.
+	ctx = context.WithoutCancel(ctx)
+
+	err := service.staging.AssembleUpload(ctx, upload.Id, upload.MultipartId, upload.Parts)
+	if err != nil {
+		return err
+	}
+
+	roles, err := service.repo.GetUserRolesByLogin(upload.Login)
+	if err != nil {
+		return err
+	}
+
+	contentType, size, err := service.acceptStaged(ctx, upload.ProfileId, roles, upload.Id, upload.Login, upload.FileName,
+		upload.ContentType, upload.Length)
+	if err != nil {
+		if uploadRejected(err) {
+			service.terminate(ctx, *upload)
+		}
+		return err
+	}
+	upload.ContentType = contentType
+
+	service.audit.RecordAction(ctx, models.AuditFileUploaded, upload.Login, map[string]string{
+		"file": upload.FileName, "size": strconv.FormatInt(size, 10), "content_type": contentType, "via": "tus",
+	})
+
+	return service.uploads.DeleteUpload(upload.Id)
+}
+
+// acceptStaged checks the staged object key against the upload rules of a user with
+// roles and the storage quota of profileId, and moves it to fileName in the bucket
+// of login. SVG images are stored sanitized. It returns the content type and size
+// of the stored file. The staged object is left in place when it is refused.
+func (service *UploadService) acceptStaged(ctx context.Context, profileId string, roles []string, key, login, fileName, declaredType string,
+	maxSize int64) (string, int64, error) {
+
+	body, info, err := service.staging.OpenStaged(ctx, key)
+	if err != nil {
+		return "", 0, customError.UnexistingFileError
+	}
+	defer body.Close()
+
+	if info.Size > maxSize {
+		return "", 0, customError.FileTooLargeError
+	}
+
+	bucketName := fmt.Sprintf("%s-%s", strings.ToLower(login), profileId)
+
+	_, err = service.fileStorage.GetFile(ctx, bucketName, fileName)
+	if err == nil {
+		return "", 0, customError.ExistingFileError
+	}
+
+	contentType, err := service.fileTypes.Check(roles, fileName, declaredType, asReaderAt(body), info.Size)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var sanitized []byte
+	size := info.Size
+	if contentType == "image/svg+xml" {
+		_, err = body.Seek(0, io.SeekStart)
+		if err != nil {
+			return "", 0, err
+		}
+		sanitized, err = sanitizeSVGFile(body, info.Size)
+		if err != nil {
+			return "", 0, err
+		}
+		size = int64(len(sanitized))
+	}
+
+	err = service.quotas.Reserve(profileId, roles, size)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if sanitized != nil {
+		err = service.fileStorage.UploadFile(ctx, bucketName, fileName, bytes.NewReader(sanitized), size, contentType)
+		if err == nil {
+			service.staging.RemoveStaged(ctx, key)
+		}
+	} else {
+		err = service.staging.MoveStaged(ctx, key, bucketName, fileName, contentType)
+	}
+	if err != nil {
+		service.quotas.Release(profileId, size)
+		return "", 0, err
+	}
+
+	return contentType, size, nil
+}
+
+// uploadRejected tells whether err means a staged file breaks the upload rules,
+// so trying again cannot help.
+func uploadRejected(err error) bool {
+	return errors.Is(err, customError.TypeNotAllowed) || errors.Is(err, customError.FileTooLargeError) ||
+		errors.Is(err, customError.QuotaExceededError) || errors.Is(err, customError.ExistingFileError)
+}
+
+// TerminateUpload cancels an upload and frees everything staged for it.
+func (service *UploadService) TerminateUpload(ctx context.Context, id string) error {
+
+	if !service.lock(id) {
+		return customError.UploadBusyError
+	}
+	defer service.unlock(id)
+
+	upload, err := service.uploads.GetUpload(id)
+	if err != nil {
+		return customError.UnexistingUploadError
+	}
+
+	return service.terminate(ctx, upload)
+}
+
+func (service *UploadService) terminate(ctx context.Context, upload models.Upload) error {
+
+	err := service.staging.AbortUpload(ctx, upload.Id, upload.MultipartId)
+	if err != nil {
+		return err
+	}
+
+	return service.uploads.DeleteUpload(upload.Id)
+}
+
+// PruneUploads terminates uploads that were not continued before they expired
+// and drops presigned uploads that can no longer be confirmed.
+func (service *UploadService) PruneUploads(ctx context.Context) error {
+
+	err := service.prunePresignedUploads(ctx)
+	if err != nil {
+		return err
+	}
+
+	uploads, err := service.uploads.GetExpiredUploads(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, upload := range uploads {
+		if !service.lock(upload.Id) {
+			continue
+		}
+		err = service.terminate(ctx, upload)
+		service.unlock(upload.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(uploads) > 0 {
+		log.Printf("Pruned %d expired uploads", len(uploads))
+	}
+
+	return nil
+}
+
+func (service *UploadService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := service.PruneUploads(ctx)
+		if err != nil {
+			log.Printf("Pruning expired uploads failed: %v", err)
+		}
+	}
+}
+
+// lock claims an upload for one request at a time.
+func (service *UploadService) lock(id string) bool {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if service.active[id] {
+		return false
+	}
+	service.active[id] = true
+
+	return true
+}
+
+func (service *UploadService) unlock(id string) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	delete(service.active, id)
+}
@@ -0,0 +1,342 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+const testPNG = "\x89PNG\r\n\x1A\n0123456789"
+
+var errStorage = errors.New("storage unavailable")
+
+// fakeStaging stages parts, tails and objects in memory. failPart makes staging
+// that part number fail and moveErr makes moving staged objects fail.
+type fakeStaging struct {
+	UploadStaging
+	parts    map[int][]byte
+	tail     []byte
+	staged   map[string][]byte
+	stored   map[string][]byte
+	removed  []string
+	aborted  bool
+	failPart int
+	moveErr  error
+}
+
+func newFakeStaging() *fakeStaging {
+	return &fakeStaging{parts: make(map[int][]byte), staged: make(map[string][]byte), stored: make(map[string][]byte)}
+}
+
+func (staging *fakeStaging) PutUploadPart(_ context.Context, _, _ string, partNumber int, data io.Reader, _ int64) (string, error) {
+	if partNumber == staging.failPart {
+		return "", errStorage
+	}
+	part, _ := io.ReadAll(data)
+	staging.parts[partNumber] = part
+
+	return "part-" + strconv.Itoa(partNumber), nil
+}
+
+func (staging *fakeStaging) PutUploadTail(_ context.Context, _ string, data io.Reader, _ int64) error {
+	staging.tail, _ = io.ReadAll(data)
+	return nil
+}
+
+func (staging *fakeStaging) OpenUploadTail(context.Context, string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(staging.tail)), nil
+}
+
+func (staging *fakeStaging) AssembleUpload(_ context.Context, id, _ string, parts []string) error {
+	var assembled []byte
+	for _, etag := range parts {
+		partNumber, _ := strconv.Atoi(strings.TrimPrefix(etag, "part-"))
+		assembled = append(assembled, staging.parts[partNumber]...)
+	}
+	staging.staged[id] = assembled
+
+	return nil
+}
+
+func (staging *fakeStaging) AbortUpload(context.Context, string, string) error {
+	staging.aborted = true
+	return nil
+}
+
+func (staging *fakeStaging) OpenStaged(_ context.Context, key string) (io.ReadSeekCloser, minio.ObjectInfo, error) {
+	data, ok := staging.staged[key]
+	if !ok {
+		return nil, minio.ObjectInfo{}, errStorage
+	}
+
+	return nopSeekCloser{bytes.NewReader(data)}, minio.ObjectInfo{Key: key, Size: int64(len(data))}, nil
+}
+
+func (staging *fakeStaging) MoveStaged(_ context.Context, key, bucketName, fileName, _ string) error {
+	if staging.moveErr != nil {
+		return staging.moveErr
+	}
+	staging.stored[bucketName+"/"+fileName] = staging.staged[key]
+	delete(staging.staged, key)
+
+	return nil
+}
+
+func (staging *fakeStaging) RemoveStaged(_ context.Context, key string) {
+	staging.removed = append(staging.removed, key)
+	delete(staging.staged, key)
+}
+
+// fakeUploads keeps resumable uploads in memory.
+type fakeUploads struct {
+	UploadsRepository
+	uploads map[string]models.Upload
+}
+
+func (repo fakeUploads) GetUpload(id string) (models.Upload, error) {
+	upload, ok := repo.uploads[id]
+	if !ok {
+		return models.Upload{}, errors.New("no such upload")
+	}
+	return upload, nil
+}
+
+func (repo fakeUploads) UpdateUpload(upload models.Upload) error {
+	upload.Parts = append([]string(nil), upload.Parts...)
+	repo.uploads[upload.Id] = upload
+	return nil
+}
+
+func (repo fakeUploads) DeleteUpload(id string) error {
+	delete(repo.uploads, id)
+	return nil
+}
+
+// fakeObjects tells which files exist in a bucket.
+type fakeObjects struct {
+	FileStorage
+	existing map[string]bool
+}
+
+func (storage fakeObjects) GetFile(_ context.Context, bucketName, fileName string) (minio.ObjectInfo, error) {
+	if !storage.existing[bucketName+"/"+fileName] {
+		return minio.ObjectInfo{}, errors.New("no such file")
+	}
+	return minio.ObjectInfo{Key: fileName}, nil
+}
+
+// newTestUploadService stages parts of partSize bytes for alice, a User who may
+// upload PNG images without a quota.
+func newTestUploadService(partSize int64) (*UploadService, *fakeStaging, fakeUploads, *fakeQuotas, *[]models.AuditEvent) {
+	staging := newFakeStaging()
+	uploads := fakeUploads{uploads: make(map[string]models.Upload)}
+	quotas := newFakeQuotas()
+	audit, events := newTestAuditLog()
+
+	service := &UploadService{
+		repo: fakeUsers{
+			users: map[string]models.User{"alice": {Id: "1", Login: "alice"}},
+			roles: map[string][]string{"alice": {"User"}},
+		},
+		fileStorage: fakeObjects{existing: map[string]bool{}},
+		staging:     staging,
+		uploads:     uploads,
+		fileTypes:   &FileTypePolicy{allowed: map[string][]string{"User": {"image/png"}}},
+		quotas:      &StorageQuotas{repo: quotas, limits: map[string]quotaLimit{}},
+		audit:       audit,
+		maxSize:     1 << 20,
+		expiry:      time.Hour,
+		partSize:    partSize,
+		active:      make(map[string]bool),
+	}
+
+	return service, staging, uploads, quotas, events
+}
+
+func startTestUpload(uploads fakeUploads, length int64) {
+	uploads.uploads["u1"] = models.Upload{
+		Id: "u1", ProfileId: "1", Login: "alice", FileName: "a.png", ContentType: "image/png",
+		MultipartId: "mp", Length: length, ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+// failingReader returns its data and then err instead of io.EOF.
+type failingReader struct {
+	data io.Reader
+	err  error
+}
+
+func (reader failingReader) Read(p []byte) (int, error) {
+	n, err := reader.data.Read(p)
+	if errors.Is(err, io.EOF) {
+		return n, reader.err
+	}
+	return n, err
+}
+
+func TestAppendUpload(t *testing.T) {
+	const partSize = 5
+
+	tests := []struct {
+		name   string
+		chunks []int
+	}{
+		{"one chunk", []int{18}},
+		{"chunks of a part", []int{5, 5, 5, 3}},
+		{"chunks smaller than a part", []int{3, 3, 3, 3, 3, 3}},
+		{"chunks larger than a part", []int{7, 7, 4}},
+		{"single bytes", []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+		{"empty chunk", []int{4, 0, 14}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, staging, uploads, quotas, events := newTestUploadService(partSize)
+			startTestUpload(uploads, int64(len(testPNG)))
+
+			offset := int64(0)
+			for i, size := range test.chunks {
+				upload, err := service.AppendUpload(context.Background(), "u1", offset, strings.NewReader(testPNG[offset:offset+int64(size)]))
+				if err != nil {
+					t.Fatalf("chunk %d: AppendUpload() error = %v", i, err)
+				}
+				offset += int64(size)
+				if upload.Offset != offset {
+					t.Fatalf("chunk %d: offset = %d, want %d", i, upload.Offset, offset)
+				}
+				if offset < int64(len(testPNG)) && upload.TailSize != upload.Offset-int64(len(upload.Parts))*partSize {
+					t.Errorf("chunk %d: tail = %d with %d parts at offset %d", i, upload.TailSize, len(upload.Parts), upload.Offset)
+				}
+			}
+
+			if got := string(staging.stored["alice-1/a.png"]); got != testPNG {
+				t.Errorf("stored file = %q, want %q", got, testPNG)
+			}
+			for number, part := range staging.parts {
+				if number < len(staging.parts) && len(part) != partSize {
+					t.Errorf("part %d has %d bytes, want %d", number, len(part), partSize)
+				}
+			}
+			if _, ok := uploads.uploads["u1"]; ok {
+				t.Error("upload is still recorded after it completed")
+			}
+			if usage := quotas.usage["1"]; usage.UsedBytes != int64(len(testPNG)) || usage.UsedObjects != 1 {
+				t.Errorf("usage = %+v, want the file counted", usage)
+			}
+			if len(*events) != 1 || (*events)[0].Action != models.AuditFileUploaded || (*events)[0].Details["via"] != "tus" {
+				t.Errorf("audit events = %+v, want one tus upload", *events)
+			}
+		})
+	}
+}
+
+func TestAppendUploadFailures(t *testing.T) {
+	const partSize = 5
+	length := int64(len(testPNG))
+
+	t.Run("wrong offset", func(t *testing.T) {
+		service, _, uploads, _, _ := newTestUploadService(partSize)
+		startTestUpload(uploads, length)
+
+		_, err := service.AppendUpload(context.Background(), "u1", 3, strings.NewReader(testPNG[3:]))
+		if !errors.Is(err, customError.UploadOffsetError) {
+			t.Errorf("AppendUpload() error = %v, want %v", err, customError.UploadOffsetError)
+		}
+	})
+
+	t.Run("body fails midway", func(t *testing.T) {
+		service, staging, uploads, _, _ := newTestUploadService(partSize)
+		startTestUpload(uploads, length)
+		lost := errors.New("connection reset")
+
+		upload, err := service.AppendUpload(context.Background(), "u1", 0, failingReader{strings.NewReader(testPNG[:7]), lost})
+		if !errors.Is(err, lost) {
+			t.Fatalf("AppendUpload() error = %v, want %v", err, lost)
+		}
+		if upload.Offset != 7 || upload.TailSize != 2 || uploads.uploads["u1"].Offset != 7 {
+			t.Fatalf("upload = %+v, want what arrived kept", upload)
+		}
+
+		_, err = service.AppendUpload(context.Background(), "u1", 7, strings.NewReader(testPNG[7:]))
+		if err != nil {
+			t.Fatalf("resumed AppendUpload() error = %v", err)
+		}
+		if got := string(staging.stored["alice-1/a.png"]); got != testPNG {
+			t.Errorf("stored file = %q, want %q", got, testPNG)
+		}
+	})
+
+	t.Run("part fails to stage", func(t *testing.T) {
+		service, staging, uploads, _, _ := newTestUploadService(partSize)
+		startTestUpload(uploads, length)
+		staging.failPart = 2
+
+		_, err := service.AppendUpload(context.Background(), "u1", 0, strings.NewReader(testPNG))
+		if !errors.Is(err, errStorage) {
+			t.Fatalf("AppendUpload() error = %v, want %v", err, errStorage)
+		}
+		if saved := uploads.uploads["u1"]; saved.Offset != partSize || len(saved.Parts) != 1 || saved.TailSize != 0 {
+			t.Fatalf("saved upload = %+v, want the first part kept", saved)
+		}
+
+		staging.failPart = 0
+		_, err = service.AppendUpload(context.Background(), "u1", partSize, strings.NewReader(testPNG[partSize:]))
+		if err != nil {
+			t.Fatalf("resumed AppendUpload() error = %v", err)
+		}
+		if got := string(staging.stored["alice-1/a.png"]); got != testPNG {
+			t.Errorf("stored file = %q, want %q", got, testPNG)
+		}
+	})
+
+	t.Run("rejected content ends the upload", func(t *testing.T) {
+		service, staging, uploads, quotas, _ := newTestUploadService(partSize)
+		startTestUpload(uploads, 10)
+
+		_, err := service.AppendUpload(context.Background(), "u1", 0, strings.NewReader("not an image"))
+		if !errors.Is(err, customError.TypeNotAllowed) {
+			t.Fatalf("AppendUpload() error = %v, want %v", err, customError.TypeNotAllowed)
+		}
+		if _, ok := uploads.uploads["u1"]; ok || !staging.aborted {
+			t.Error("rejected upload was kept")
+		}
+		if usage := quotas.usage["1"]; usage != (models.StorageQuota{}) {
+			t.Errorf("usage = %+v, want nothing claimed", usage)
+		}
+	})
+
+	t.Run("failed move is retried", func(t *testing.T) {
+		service, staging, uploads, quotas, _ := newTestUploadService(partSize)
+		startTestUpload(uploads, length)
+		staging.moveErr = errStorage
+
+		_, err := service.AppendUpload(context.Background(), "u1", 0, strings.NewReader(testPNG))
+		if !errors.Is(err, errStorage) {
+			t.Fatalf("AppendUpload() error = %v, want %v", err, errStorage)
+		}
+		if saved, ok := uploads.uploads["u1"]; !ok || saved.Offset != length {
+			t.Fatalf("saved upload = %+v, want it kept complete for a retry", saved)
+		}
+		if usage := quotas.usage["1"]; usage != (models.StorageQuota{}) {
+			t.Errorf("usage = %+v, want the claim released", usage)
+		}
+
+		staging.moveErr = nil
+		_, err = service.AppendUpload(context.Background(), "u1", length, strings.NewReader(""))
+		if err != nil {
+			t.Fatalf("retried AppendUpload() error = %v", err)
+		}
+		if got := string(staging.stored["alice-1/a.png"]); got != testPNG {
+			t.Errorf("stored file = %q, want %q", got, testPNG)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	defaultUsersPageSize = 50
+	maxUsersPageSize     = 500
+)
+
+// usersCursor is the position of the last user of a page, opaque to clients.
+type usersCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	Id    string `json:"i"`
+}
+
+func (service *UserService) ListUsers(params models.ListUsersDTO) ([]models.User, string, int, error) {
+
+	query := models.UsersQuery{
+		LoginPrefix: params.LoginPrefix,
+		Status:      strings.ToLower(params.Status),
+		CreatedFrom: params.CreatedFrom,
+		CreatedTo:   params.CreatedTo,
+		Sort:        strings.ToLower(params.Sort),
+		Desc:        strings.EqualFold(params.Order, "desc"),
+		Limit:       params.Limit,
+	}
+
+	if params.Role != "" {
+		query.Role = strings.Title(strings.ToLower(params.Role))
+	}
+
+	if query.Sort == "" {
+		query.Sort = "login"
+	}
+
+	switch query.Status {
+	case "", models.StatusActive, models.StatusDisabled, models.StatusLocked:
+	default:
+		return nil, "", 0, customError.InvalidStatusError
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = defaultUsersPageSize
+	}
+	if query.Limit > maxUsersPageSize {
+		query.Limit = maxUsersPageSize
+	}
+
+	if params.Cursor != "" {
+		cursor, err := decodeUsersCursor(params.Cursor)
+		if err != nil || cursor.Sort != query.Sort || cursor.Desc != query.Desc {
+			return nil, "", 0, customError.InvalidCursorError
+		}
+		query.AfterValue, query.AfterId = cursor.Value, cursor.Id
+	}
+
+	// One extra row tells whether there is a next page without another query.
+	query.Limit++
+	users, total, err := service.repo.ListUsers(query)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	query.Limit--
+
+	if len(users) <= query.Limit {
+		return users, "", total, nil
+	}
+
+	users = users[:query.Limit]
+	last := users[len(users)-1]
+
+	cursor := usersCursor{Sort: query.Sort, Desc: query.Desc, Id: last.Id}
+	switch query.Sort {
+	case "login":
+		cursor.Value = last.Login
+	case "status":
+		cursor.Value = last.Status
+	case "created_at":
+		cursor.Value = last.CreatedAt.Format(time.RFC3339Nano)
+	}
+
+	return users, encodeUsersCursor(cursor), total, nil
+}
+
+func encodeUsersCursor(cursor usersCursor) string {
+	data, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeUsersCursor(value string) (usersCursor, error) {
+	var cursor usersCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+
+	err = json.Unmarshal(data, &cursor)
+
+	return cursor, err
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/internal/core/domain/responses"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ListUsers  	 godoc
+// @Summary 	 List users
+// @Tags 		 Admin
+// @Produce      json
+// @Param		 cursor			query	string	false	"Cursor returned with the previous page"
+// @Param		 limit			query	int		false	"Page size, 50 by default and 500 at most"
+// @Param		 login_prefix	query	string	false	"Beginning of a login"
+// @Param		 role			query	string	false	"Role held directly or through a group"
+// @Param		 status			query	string	false	"Account status"	Enums(active, disabled, locked)
+// @Param		 created_from	query	string	false	"Registered at or after, RFC 3339"
+// @Param		 created_to		query	string	false	"Registered before, RFC 3339"
+// @Param		 sort			query	string	false	"Sort column"	Enums(login, created_at, status)
+// @Param		 order			query	string	false	"Sort order"	Enums(asc, desc)
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.ListUsersSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /admin/users [get]
+func (handler *UserHandler) ListUsers(c *gin.Context) {
+
+	var queryData models.ListUsersDTO
+	err := c.ShouldBindQuery(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	users, nextCursor, total, err := handler.service.ListUsers(queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	list := make([]responses.UserSummary, 0, len(users))
+	for _, user := range users {
+		list = append(list, responses.UserSummary{
+			Id:        user.Id,
+			Login:     user.Login,
+			Roles:     user.Roles,
+			Status:    user.Status,
+			CreatedAt: user.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, responses.ListUsersSuccess{Users: list, NextCursor: nextCursor, Total: total})
+}
+
+// SetAccountStatus godoc
+// @Summary 	 Change account status
+// @Description  Disabled and locked accounts can neither log in nor use issued tokens. Their data is kept, so setting the status back to active restores access.
+// @Tags 		 Admin
+// @Accept       json
+// @Produce      json
+// @Param		 login			path	string		true	"Login of an account"
+// @Param		 SetAccountStatusDTO	body	models.SetAccountStatusDTO		true	"New status (active, disabled or locked) and its reason"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"Account status was successfully changed"			string
+// @Failure 	 400 		{object}		responses.Error
+// @Router /admin/users/{login}/status [put]
+func (handler *UserHandler) SetAccountStatus(c *gin.Context) {
+
+	var queryData models.SetAccountStatusDTO
+	err := c.ShouldBindJSON(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.service.SetAccountStatus(c.Request.Context(), c.Param("login"), queryData.Status, queryData.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "Account status was successfully changed")
+}
+
+// ListPendingDeletions godoc
+// @Summary 	 List unregistered accounts waiting to be purged
+// @Tags 		 Admin
+// @Produce      json
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.ListPendingDeletionsSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /admin/deletions [get]
+func (handler *UserHandler) ListPendingDeletions(c *gin.Context) {
+
+	err := handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	users, err := handler.service.ListPendingDeletions()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	list := make([]responses.PendingDeletion, 0, len(users))
+	for _, user := range users {
+		list = append(list, responses.PendingDeletion{
+			Id:        user.Id,
+			Login:     user.Login,
+			DeletedAt: user.DeletedAt,
+			PurgeAt:   handler.service.PurgeTime(user.DeletedAt),
+		})
+	}
+
+	c.JSON(http.StatusOK, responses.ListPendingDeletionsSuccess{Users: list})
+}
+
+// RestoreUser   godoc
+// @Summary 	 Restore unregistered account
+// @Tags 		 Admin
+// @Produce      json
+// @Param		 login			path	string		true	"Login of an account"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"Account was successfully restored"			string
+// @Failure 	 400 		{object}		responses.Error
+// @Router /admin/deletions/{login}/restore [post]
+func (handler *UserHandler) RestoreUser(c *gin.Context) {
+
+	err := handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.service.RestoreUser(c.Request.Context(), c.Param("login"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "Account was successfully restored")
+}
+
+// ClearLockout  godoc
+// @Summary 	 Clear failed login lockout
+// @Tags 		 Admin
+// @Accept       json
+// @Produce      json
+// @Param		 ClearLockoutDTO	body	models.ClearLockoutDTO		true	"Login and/or IP to unblock"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"Lockout was successfully cleared"			string
+// @Failure 	 400 		{object}		responses.Error
+// @Router /admin/lockouts [delete]
+func (handler *UserHandler) ClearLockout(c *gin.Context) {
+
+	var queryData models.ClearLockoutDTO
+	err := c.ShouldBindJSON(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.service.ClearLockout(c.Request.Context(), queryData.Login, queryData.IP)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "Lockout was successfully cleared")
+}
+
+// ListAuditEvents godoc
+// @Summary 	 Query audit log
+// @Description  Events are returned newest first. Pass next_before of a page as before to get the next one.
+// @Tags 		 Admin
+// @Produce      json
+// @Param		 before			query	int		false	"Only events with a lower id"
+// @Param		 limit			query	int		false	"Page size, 100 by default and 1000 at most"
+// @Param		 actor			query	string	false	"Login that performed the action"
+// @Param		 target			query	string	false	"Login the action was performed on"
+// @Param		 action			query	string	false	"Action, e.g. user.login_failed"
+// @Param		 ip				query	string	false	"Client IP"
+// @Param		 request_id		query	string	false	"Request id"
+// @Param		 from			query	string	false	"At or after, RFC 3339"
+// @Param		 to				query	string	false	"Before, RFC 3339"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.AuditLogSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /admin/audit [get]
+func (handler *UserHandler) ListAuditEvents(c *gin.Context) {
+
+	var queryData models.AuditLogDTO
+	err := c.ShouldBindQuery(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	events, nextBefore, err := handler.audit.Query(queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.AuditLogSuccess{Events: events, NextBefore: nextBefore})
+}
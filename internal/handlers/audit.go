@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"regexp"
+)
+
+const (
+	requestIdHeader = "X-Request-ID"
+	requestIdKey    = "requestId"
+	claimsKey       = "claims"
+)
+
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type AuditLog interface {
+	Query(params models.AuditLogDTO) ([]models.AuditEvent, int64, error)
+}
+
+// RequestId tags every request with an id, taken from the X-Request-ID header when
+// the client or a proxy sent a sane one, and echoes it in the response. The id,
+// client IP and user agent go into the request context for the audit log.
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIdHeader)
+		if !requestIdPattern.MatchString(id) {
+			random := make([]byte, 16)
+			rand.Read(random)
+			id = hex.EncodeToString(random)
+		}
+
+		c.Set(requestIdKey, id)
+		c.Header(requestIdHeader, id)
+
+		request := models.AuditRequest{IP: c.ClientIP(), UserAgent: c.Request.UserAgent(), RequestId: id}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), models.AuditRequestKey{}, request))
+
+		c.Next()
+	}
+}
+
+// auditActor makes the owner of the verified access token the actor of the audit
+// events of the request.
+func auditActor(c *gin.Context, login string) {
+	request, _ := c.Request.Context().Value(models.AuditRequestKey{}).(models.AuditRequest)
+	request.Actor = login
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), models.AuditRequestKey{}, request))
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"os"
+	"slices"
+	"time"
+)
+
+type AccountChecker interface {
+	CheckAccountStatus(login string) error
+	CheckSession(id string) error
+}
+
+// Authenticator checks access tokens of incoming requests. Besides the token itself
+// it makes sure the account the token was issued to is still active and the session
+// of the token was not revoked.
+type Authenticator struct {
+	accounts AccountChecker
+}
+
+func NewAuthenticator(accounts AccountChecker) *Authenticator {
+	return &Authenticator{accounts: accounts}
+}
+
+// VerifyToken allows the request if the token belongs to login or to an Admin.
+func (auth *Authenticator) VerifyToken(c *gin.Context, login string) error {
+	claims, err := auth.parseToken(c)
+	if err != nil {
+		return err
+	}
+
+	if claims.Login != login && !slices.Contains(claims.Roles, "Admin") {
+		return customError.NoPermission
+	}
+
+	return nil
+}
+
+// VerifyOwner resolves whose data a request is about: login when given, which the
+// token must belong to or be an Admin's, otherwise the owner of the token.
+func (auth *Authenticator) VerifyOwner(c *gin.Context, login string) (string, error) {
+	claims, err := auth.parseToken(c)
+	if err != nil {
+		return "", err
+	}
+
+	if login == "" {
+		return claims.Login, nil
+	}
+
+	if claims.Login != login && !slices.Contains(claims.Roles, "Admin") {
+		return "", customError.NoPermission
+	}
+
+	return login, nil
+}
+
+// Requester returns the login of the token owner, for requests the service
+// authorizes because access depends on what was shared with them.
+func (auth *Authenticator) Requester(c *gin.Context) (string, error) {
+	claims, err := auth.parseToken(c)
+	if err != nil {
+		return "", err
+	}
+
+	return claims.Login, nil
+}
+
+// VerifyAdmin allows the request only if the token belongs to an Admin.
+func (auth *Authenticator) VerifyAdmin(c *gin.Context) error {
+	claims, err := auth.parseToken(c)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(claims.Roles, "Admin") {
+		return customError.NoPermission
+	}
+
+	return nil
+}
+
+func (auth *Authenticator) parseToken(c *gin.Context) (models.TokenClaims, error) {
+	access_token := c.Request.Header.Get("Authorization")
+	if access_token == "" {
+		return models.TokenClaims{}, customError.TokenNotProvidedError
+	}
+
+	claims, err := parseClaims(access_token)
+	if err != nil {
+		return models.TokenClaims{}, err
+	}
+
+	expired := claims.VerifyExpiresAt(time.Now().Unix(), true)
+	if !expired {
+		return models.TokenClaims{}, customError.ExpiredTokenError
+	}
+
+	err = auth.accounts.CheckAccountStatus(claims.Login)
+	if err != nil {
+		return models.TokenClaims{}, err
+	}
+
+	err = auth.accounts.CheckSession(claims.Id)
+	if err != nil {
+		return models.TokenClaims{}, err
+	}
+
+	c.Set(claimsKey, claims)
+	auditActor(c, claims.Login)
+
+	return claims, nil
+}
+
+// parseClaims checks the signature of an access token and returns its claims.
+func parseClaims(access_token string) (models.TokenClaims, error) {
+	JWT_SECRET_KEY, _ := os.LookupEnv("JWT_SECRET_KEY")
+	claims := models.TokenClaims{}
+
+	_, err := jwt.ParseWithClaims(access_token, &claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(JWT_SECRET_KEY), nil
+	})
+	if err != nil {
+		return models.TokenClaims{}, customError.InvalidTokenError
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/internal/core/domain/responses"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"os"
+)
+
+type ExportService interface {
+	StartExport(login string) (string, error)
+	GetExport(id string) (models.ExportJob, error)
+	OpenExport(id string) (*os.File, error)
+}
+
+type ExportHandler struct {
+	service ExportService
+	auth    *Authenticator
+}
+
+func NewExportHandler(service ExportService, auth *Authenticator) *ExportHandler {
+	return &ExportHandler{service: service, auth: auth}
+}
+
+// StartExport   godoc
+// @Summary 	 Request export of personal data
+// @Description  Starts building a ZIP archive with the profile, role history, login sessions, audit events and all files of a user. Poll the returned job for its status.
+// @Tags 		 Export
+// @Accept       json
+// @Produce      json
+// @Param		 ExportDataDTO	body	models.ExportDataDTO		true	"Login of a user whose data to export"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 202 		{object}		responses.ExportStartedSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/export [post]
+func (handler *ExportHandler) StartExport(c *gin.Context) {
+
+	var queryData models.ExportDataDTO
+	err := c.ShouldBind(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	id, err := handler.service.StartExport(queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, responses.ExportStartedSuccess{JobId: id})
+}
+
+// GetExport     godoc
+// @Summary 	 Get status of personal data export
+// @Tags 		 Export
+// @Produce      json
+// @Param		 id				path	string		true	"Export job id"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.ExportStatusSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/export/{id} [get]
+func (handler *ExportHandler) GetExport(c *gin.Context) {
+
+	job, err := handler.service.GetExport(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, job.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	status := responses.ExportStatusSuccess{
+		JobId:     job.Id,
+		Status:    job.Status,
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt,
+	}
+	if !job.FinishedAt.IsZero() {
+		status.FinishedAt = &job.FinishedAt
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// DownloadExport godoc
+// @Summary 	 Download personal data export
+// @Tags 		 Export
+// @Produce      application/zip
+// @Param		 id				path	string		true	"Export job id"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{file}		file
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/export/{id}/download [get]
+func (handler *ExportHandler) DownloadExport(c *gin.Context) {
+
+	job, err := handler.service.GetExport(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, job.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	file, err := handler.service.OpenExport(job.Id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+
+	extraHeaders := map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s-export.zip"`, job.Login),
+	}
+
+	c.DataFromReader(http.StatusOK, stat.Size(), "application/zip", file, extraHeaders)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"auth/pkg/customError"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// GetFile       godoc
+// @Summary 	 Download file
+// @Description  Streams the file body. Slashes in the name are part of it. Supports single and multiple
+// @Description  byte ranges, If-Range to resume only an unchanged file, and If-None-Match or If-Modified-Since
+// @Description  to revalidate a cached copy. Other users' files can be read when they shared them, or a folder
+// @Description  holding them, with the caller.
+// @Tags 		 File
+// @Produce      octet-stream
+// @Param		 name			path	string		true	"File name"
+// @Param		 login			query	string		false	"Login of the owner, the token owner by default"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Param		 Range				header	string		false	"Byte ranges, e.g. bytes=0-1023,4096-"
+// @Param		 If-Range			header	string		false	"ETag or Last-Modified the ranges apply to"
+// @Param		 If-None-Match		header	string		false	"ETags of cached copies"
+// @Param		 If-Modified-Since	header	string		false	"Last-Modified of a cached copy"
+// @Success 	 200 		{file}		file
+// @Success 	 206 		{file}		file	"The requested range, or multipart/byteranges for several"
+// @Success 	 304 		{string}	string	"Cached copy is still current"
+// @Header 		 200,206 	{string}		ETag				"Entity tag of the object"
+// @Header 		 200,206 	{string}		Last-Modified		"Modification time of the object"
+// @Header 		 200,206 	{string}		Accept-Ranges		"bytes"
+// @Header 		 200 		{string}		Content-Disposition	"attachment with the file name"
+// @Header 		 206 		{string}		Content-Range		"Range sent for a single range"
+// @Failure 	 400 		{object}		responses.Error
+// @Failure 	 404 		{object}		responses.Error
+// @Failure 	 416 		{string}	string	"No requested range overlaps the file"
+// @Router /files/{name} [get]
+func (handler *UserHandler) GetFile(c *gin.Context) {
+
+	requester, err := handler.auth.Requester(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	login := c.Query("login")
+	if login == "" {
+		login = requester
+	}
+
+	fileName := strings.TrimPrefix(c.Param("name"), "/")
+
+	body, info, err := handler.service.DownloadFile(c.Request.Context(), requester, login, fileName)
+	if errors.Is(err, customError.NoPermission) {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	serveFile(c, fileName, body, info)
+}
+
+// serveFile streams an opened file as an attachment.
+func serveFile(c *gin.Context, fileName string, body io.ReadSeeker, info minio.ObjectInfo) {
+	attachmentHeaders(c, fileName, info)
+	c.Header("ETag", fmt.Sprintf(`"%s"`, info.ETag))
+
+	// ServeContent answers conditional and range requests against the ETag and
+	// modification time, seeking the body to the start of every range it sends.
+	http.ServeContent(c.Writer, c.Request, "", info.LastModified, body)
+}
+
+// serveWholeFile streams the whole of an opened file as an attachment, ignoring
+// Range and conditional headers, for responses that must each send all of it.
+func serveWholeFile(c *gin.Context, fileName string, body io.Reader, info minio.ObjectInfo) {
+	attachmentHeaders(c, fileName, info)
+	c.Header("Accept-Ranges", "none")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Length", strconv.FormatInt(info.Size, 10))
+	c.Status(http.StatusOK)
+
+	io.Copy(c.Writer, body)
+}
+
+func attachmentHeaders(c *gin.Context, fileName string, info minio.ObjectInfo) {
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(fileName)}))
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/internal/core/domain/responses"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// CreateFolder  godoc
+// @Summary 	 Create an empty folder
+// @Description  Folders are the parts of file names up to a "/". A created folder is listed while it holds no files.
+// @Tags 		 File
+// @Accept       json
+// @Produce      json
+// @Param		 FolderDTO		body	models.FolderDTO		true	"Login of an owner and name of the folder"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"Folder was successfully created" string
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/createFolder [post]
+func (handler *UserHandler) CreateFolder(c *gin.Context) {
+
+	var queryData models.FolderDTO
+	err := c.ShouldBindJSON(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	_, err = handler.service.CreateFolder(c.Request.Context(), queryData.Login, queryData.Folder)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "Folder was successfully created")
+}
+
+// DeleteFolder  godoc
+// @Summary 	 Delete a folder with everything in it
+// @Tags 		 File
+// @Accept       json
+// @Produce      json
+// @Param		 FolderDTO		body	models.FolderDTO		true	"Login of an owner and name of the folder"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"Folder was successfully deleted" string
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/deleteFolder [delete]
+func (handler *UserHandler) DeleteFolder(c *gin.Context) {
+
+	var queryData models.FolderDTO
+	err := c.ShouldBindJSON(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	_, err = handler.service.DeleteFolder(c.Request.Context(), queryData.Login, queryData.Folder)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "Folder was successfully deleted")
+}
+
+// MoveFile  	 godoc
+// @Summary 	 Rename or move a file or folder
+// @Description  A source ending with "/" is a folder, moved with everything in it. A destination ending with "/" is the
+// @Description  folder a file is moved into. The extension of a file must stay one of its type. conflict is fail,
+// @Description  overwrite or auto-rename, which appends a number to a taken name; fail is the default.
+// @Tags 		 File
+// @Accept       json
+// @Produce      json
+// @Param		 TransferFileDTO	body	models.TransferFileDTO		true	"Login of an owner, source and destination"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.TransferFileSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/moveFile [put]
+func (handler *UserHandler) MoveFile(c *gin.Context) {
+	handler.transferFile(c, true)
+}
+
+// CopyFile  	 godoc
+// @Summary 	 Copy a file or folder
+// @Description  Copies within storage, without downloading. A source ending with "/" is a folder, copied with everything
+// @Description  in it. A destination ending with "/" is the folder a file is copied into. The copy counts against the
+// @Description  storage quota. conflict is fail, overwrite or auto-rename, which appends a number to a taken name.
+// @Tags 		 File
+// @Accept       json
+// @Produce      json
+// @Param		 TransferFileDTO	body	models.TransferFileDTO		true	"Login of an owner, source and destination"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.TransferFileSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Failure 	 413 		{object}		responses.Error
+// @Router /user/copyFile [post]
+func (handler *UserHandler) CopyFile(c *gin.Context) {
+	handler.transferFile(c, false)
+}
+
+func (handler *UserHandler) transferFile(c *gin.Context, move bool) {
+
+	var queryData models.TransferFileDTO
+	err := c.ShouldBindJSON(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	transfer := handler.service.CopyFile
+	if move {
+		transfer = handler.service.MoveFile
+	}
+
+	name, err := transfer(c.Request.Context(), queryData.Login, queryData.Source, queryData.Destination, queryData.Conflict)
+	if err != nil {
+		c.JSON(storeErrorStatus(err), gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.TransferFileSuccess{Name: name})
+}
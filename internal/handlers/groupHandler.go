@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type GroupService interface {
+	CreateGroup(ctx context.Context, name string) error
+	RenameGroup(ctx context.Context, name, newName string) error
+	DeleteGroup(ctx context.Context, name string) error
+	AddMembers(ctx context.Context, name, logins string) (map[string]string, error)
+	RemoveMembers(ctx context.Context, name, logins string) (map[string]string, error)
+	AddRoles(ctx context.Context, name, roles string) (map[string]string, error)
+	RemoveRoles(ctx context.Context, name, roles string) (map[string]string, error)
+}
+
+type GroupHandler struct {
+	service GroupService
+	auth    *Authenticator
+}
+
+func NewGroupHandler(service GroupService, auth *Authenticator) *GroupHandler {
+	return &GroupHandler{service: service, auth: auth}
+}
+
+// CreateGroup   godoc
+// @Summary 	 Create new group
+// @Tags 		 Group
+// @Accept       json
+// @Produce      json
+// @Param		 CreateGroupDTO	body	models.CreateGroupDTO		true	"Name of new group"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"Group was successfully created"			string
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/createGroup [post]
+func (handler *GroupHandler) CreateGroup(c *gin.Context) {
+
+	var queryData models.CreateGroupDTO
+	err := c.ShouldBind(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.service.CreateGroup(c.Request.Context(), queryData.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "Group was successfully created")
+}
+
+// RenameGroup   godoc
+// @Summary 	 Rename group
+// @Tags 		 Group
+// @Accept       json
+// @Produce      json
+// @Param		 RenameGroupDTO	body	models.RenameGroupDTO		true	"Current and new name of a group"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"Group was successfully renamed"			string
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/renameGroup [put]
+func (handler *GroupHandler) RenameGroup(c *gin.Context) {
+
+	var queryData models.RenameGroupDTO
+	err := c.ShouldBind(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.service.RenameGroup(c.Request.Context(), queryData.Name, queryData.NewName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "Group was successfully renamed")
+}
+
+// DeleteGroup   godoc
+// @Summary 	 Delete group
+// @Tags 		 Group
+// @Accept       json
+// @Produce      json
+// @Param		 DeleteGroupDTO	body	models.DeleteGroupDTO		true	"Name of a group to delete"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"Group was successfully deleted"			string
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/deleteGroup [delete]
+func (handler *GroupHandler) DeleteGroup(c *gin.Context) {
+
+	var queryData models.DeleteGroupDTO
+	err := c.ShouldBind(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.service.DeleteGroup(c.Request.Context(), queryData.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "Group was successfully deleted")
+}
+
+// AddGroupMembers  godoc
+// @Summary 	 Add users to group
+// @Tags 		 Group
+// @Accept       json
+// @Produce      json
+// @Param		 GroupMembersDTO	body	models.GroupMembersDTO		true	"Name of a group and space separated logins to add"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.GroupStatusSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Failure 	 400 		{object}		responses.GroupStatusError
+// @Router /user/addGroupMembers [put]
+func (handler *GroupHandler) AddMembers(c *gin.Context) {
+
+	var queryData models.GroupMembersDTO
+	err := c.ShouldBind(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	status, err := handler.service.AddMembers(c.Request.Context(), queryData.Name, queryData.Logins)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error(), "Status": status})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"Group": queryData.Name, "Status": status})
+}
+
+// RemoveGroupMembers  godoc
+// @Summary 	 Remove users from group
+// @Tags 		 Group
+// @Accept       json
+// @Produce      json
+// @Param		 GroupMembersDTO	body	models.GroupMembersDTO		true	"Name of a group and space separated logins to remove"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.GroupStatusSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Failure 	 400 		{object}		responses.GroupStatusError
+// @Router /user/removeGroupMembers [put]
+func (handler *GroupHandler) RemoveMembers(c *gin.Context) {
+
+	var queryData models.GroupMembersDTO
+	err := c.ShouldBind(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	status, err := handler.service.RemoveMembers(c.Request.Context(), queryData.Name, queryData.Logins)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error(), "Status": status})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"Group": queryData.Name, "Status": status})
+}
+
+// AddGroupRoles godoc
+// @Summary 	 Add roles to group
+// @Tags 		 Group
+// @Accept       json
+// @Produce      json
+// @Param		 GroupRolesDTO	body	models.GroupRolesDTO		true	"Name of a group and roles to add"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.GroupStatusSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Failure 	 400 		{object}		responses.GroupStatusError
+// @Router /user/addGroupRoles [put]
+func (handler *GroupHandler) AddRoles(c *gin.Context) {
+
+	var queryData models.GroupRolesDTO
+	err := c.ShouldBind(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	status, err := handler.service.AddRoles(c.Request.Context(), queryData.Name, queryData.Roles)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error(), "Status": status})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"Group": queryData.Name, "Status": status})
+}
+
+// RemoveGroupRoles godoc
+// @Summary 	 Remove roles from group
+// @Tags 		 Group
+// @Accept       json
+// @Produce      json
+// @Param		 GroupRolesDTO	body	models.GroupRolesDTO		true	"Name of a group and roles to remove"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.GroupStatusSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Failure 	 400 		{object}		responses.GroupStatusError
+// @Router /user/removeGroupRoles [put]
+func (handler *GroupHandler) RemoveRoles(c *gin.Context) {
+
+	var queryData models.GroupRolesDTO
+	err := c.ShouldBind(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	status, err := handler.service.RemoveRoles(c.Request.Context(), queryData.Name, queryData.Roles)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error(), "Status": status})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"Group": queryData.Name, "Status": status})
+}
@@ -2,103 +2,67 @@ package handlers
 
 import (
 	"auth/internal/core/domain/models"
+	"auth/internal/core/domain/responses"
 	"auth/pkg/customError"
 	"context"
+	"errors"
 	"fmt"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
 	"io"
+	"math"
 	"net/http"
-	"os"
+	"strconv"
 	"time"
 )
 
 const maxUploadSize = 5 << 20
 
-var fileTypes = map[string]interface{}{
-	"image/jpeg": nil,
-	"image/png":  nil,
-}
-
 type Service interface {
-	RegisterUser(login, pass string) error
-	LoginUser(login, pass string) (string, error)
-	UnregisterUser(login string) error
-	AddRoles(login, newRoles string) (map[string]string, error)
+	RegisterUser(ctx context.Context, login, pass string) error
+	LoginUser(ctx context.Context, login, pass, ip, userAgent string) (string, error)
+	UnregisterUser(ctx context.Context, login string) error
+	AddRoles(ctx context.Context, login, newRoles string) (map[string]string, error)
 	GetUserData(login string) (models.User, error)
 	CreateBucket(ctx context.Context, login string) error
 	RemoveBucket(ctx context.Context, login string) error
-	UploadFile(ctx context.Context, login, name string, file io.Reader, size int64, contentType string) error
-	DownloadFile(ctx context.Context, login, fileName, path string) error
-	DeleteFile(ctx context.Context, login, fileName string) error
-	GetFileList(ctx context.Context, login string) ([]string, error)
+	UploadFile(ctx context.Context, login, name string, file io.ReaderAt, size int64, contentType string) (string, error)
+	DownloadFile(ctx context.Context, requester, login, fileName string) (io.ReadSeekCloser, minio.ObjectInfo, error)
+	DeleteFile(ctx context.Context, requester, login, fileName string) error
+	GetFileList(ctx context.Context, requester string, params models.GetFileListDTO) ([]models.FileInfo, string, error)
+	ListUsers(params models.ListUsersDTO) ([]models.User, string, int, error)
+	SetAccountStatus(ctx context.Context, login, status, reason string) error
+	ListPendingDeletions() ([]models.User, error)
+	PurgeTime(deletedAt time.Time) time.Time
+	RestoreUser(ctx context.Context, login string) error
+	ClearLockout(ctx context.Context, login, ip string) error
+	ListSessions(login string) ([]models.Session, error)
+	RevokeSession(ctx context.Context, login, id string) error
+	PresignDownload(ctx context.Context, login, fileName string, expires time.Duration) (string, time.Time, error)
+	CreateFolder(ctx context.Context, login, folder string) (string, error)
+	DeleteFolder(ctx context.Context, login, folder string) (int, error)
+	MoveFile(ctx context.Context, login, source, destination, conflict string) (string, error)
+	CopyFile(ctx context.Context, login, source, destination, conflict string) (string, error)
+	GetQuota(login string) (models.StorageQuota, error)
+	SetQuotaOverride(ctx context.Context, login string, override models.QuotaOverride) error
+	ShareFile(ctx context.Context, login, path, user, group, permission string) (models.Share, error)
+	ListShares(login string) ([]models.Share, error)
+	ListSharedWith(login string) ([]models.Share, error)
+	RevokeShare(ctx context.Context, requester, id string) (models.Share, error)
+	CreateShareLink(ctx context.Context, login, fileName, password string, expires time.Duration, maxDownloads int64) (models.ShareLink, string, error)
+	ListShareLinks(login string) ([]models.ShareLink, error)
+	RevokeShareLink(ctx context.Context, requester, id string) (models.ShareLink, error)
+	OpenShareLink(ctx context.Context, token, password string) (io.ReadSeekCloser, minio.ObjectInfo, error)
 }
 
 type UserHandler struct {
 	service Service
+	auth    *Authenticator
+	audit   AuditLog
 }
 
-func NewUserHandler(service Service) *UserHandler {
-	return &UserHandler{service: service}
-}
-
-func VerifyToken(c *gin.Context, login string) error {
-	access_token := c.Request.Header.Get("Authorization")
-	if access_token == "" {
-		return customError.TokenNotProvidedError
-	}
-
-	JWT_SECRET_KEY, _ := os.LookupEnv("JWT_SECRET_KEY")
-	claims := models.TokenClaims{}
-
-	_, err := jwt.ParseWithClaims(access_token, &claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(JWT_SECRET_KEY), nil
-	})
-
-	if err != nil {
-		return customError.InvalidTokenError
-	}
-
-	fmt.Println(claims.Roles)
-
-	if claims.Login != login && claims.Roles[0] != "Admin" {
-		return customError.NoPermission
-	}
-
-	expired := claims.VerifyExpiresAt(time.Now().Unix(), true)
-	if !expired {
-		return customError.ExpiredTokenError
-	}
-
-	return nil
-}
-
-func VerifyAdmin(c *gin.Context) error {
-	access_token := c.Request.Header.Get("Authorization")
-	if access_token == "" {
-		return customError.TokenNotProvidedError
-	}
-
-	JWT_SECRET_KEY, _ := os.LookupEnv("JWT_SECRET_KEY")
-	claims := models.TokenClaims{}
-
-	_, err := jwt.ParseWithClaims(access_token, &claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(JWT_SECRET_KEY), nil
-	})
-	if err != nil {
-		return customError.InvalidTokenError
-	}
-
-	if claims.Roles[0] != "Admin" {
-		return customError.NoPermission
-	}
-
-	expired := claims.VerifyExpiresAt(time.Now().Unix(), true)
-	if !expired {
-		return customError.ExpiredTokenError
-	}
-
-	return nil
+func NewUserHandler(service Service, auth *Authenticator, audit AuditLog) *UserHandler {
+	return &UserHandler{service: service, auth: auth, audit: audit}
 }
 
 // Register	     godoc
@@ -109,7 +73,8 @@ func VerifyAdmin(c *gin.Context) error {
 // @Param		 RegisterDTO	body	models.RegisterDTO		true	"Data of new account"
 // @Success 	 200 		"New profile was successfully registered"			string
 // @Failure 	 400 		{object}		responses.Error
-// @Router /register [post]
+// @Failure 	 400 		{object}		responses.PasswordPolicyError
+// @Router /user/register [post]
 func (handler *UserHandler) Register(c *gin.Context) {
 	var queryData models.RegisterDTO
 	err := c.ShouldBindJSON(&queryData)
@@ -118,13 +83,18 @@ func (handler *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	err = handler.service.RegisterUser(queryData.Login, queryData.Password)
+	err = handler.service.RegisterUser(c.Request.Context(), queryData.Login, queryData.Password)
+	var weakPassword *customError.WeakPassword
+	if errors.As(err, &weakPassword) {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error(), "Failures": weakPassword.Failures})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
 	}
 
-	_, err = handler.service.AddRoles(queryData.Login, "User")
+	_, err = handler.service.AddRoles(c.Request.Context(), queryData.Login, "User")
 
 	err = handler.service.CreateBucket(c, queryData.Login)
 	if err != nil {
@@ -144,7 +114,11 @@ func (handler *UserHandler) Register(c *gin.Context) {
 // @Param		 LoginDTO	body	models.LoginDTO		true	"Account data"
 // @Success 	 200 		{object}		responses.LoginSuccess
 // @Failure 	 400 		{object}		responses.Error
-// @Router /login [post]
+// @Failure 	 401 		{object}		responses.Error		"Login requires additional verification"
+// @Failure 	 403 		{object}		responses.Error		"Login was blocked as suspicious"
+// @Failure 	 429 		{object}		responses.Error
+// @Header 		 429 		{integer}		Retry-After		"Seconds until the next attempt is allowed"
+// @Router /user/login [post]
 func (handler *UserHandler) Login(c *gin.Context) {
 	var queryData models.LoginDTO
 	err := c.ShouldBindJSON(&queryData)
@@ -153,7 +127,21 @@ func (handler *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := handler.service.LoginUser(queryData.Login, queryData.Password)
+	token, err := handler.service.LoginUser(c.Request.Context(), queryData.Login, queryData.Password, c.ClientIP(), c.Request.UserAgent())
+	var attemptsErr *customError.TooManyAttempts
+	if errors.As(err, &attemptsErr) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(attemptsErr.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"Error": err.Error()})
+		return
+	}
+	if errors.Is(err, customError.StepUpRequiredError) {
+		c.JSON(http.StatusUnauthorized, gin.H{"Error": err.Error()})
+		return
+	}
+	if errors.Is(err, customError.LoginBlockedError) {
+		c.JSON(http.StatusForbidden, gin.H{"Error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
@@ -165,6 +153,7 @@ func (handler *UserHandler) Login(c *gin.Context) {
 
 // Unregister  godoc
 // @Summary 	 Unregister user
+// @Description  The account is hidden at once and purged with all its files after the deletion grace period. Until then an Admin can restore it.
 // @Tags 		 User
 // @Accept       json
 // @Produce      json
@@ -172,7 +161,7 @@ func (handler *UserHandler) Login(c *gin.Context) {
 // @Param		 Authorization	header	string		true	"Access token"
 // @Success 	 200 		"Profile was successfully unregistered"			string
 // @Failure 	 400 		{object}		responses.Error
-// @Router /unregister [delete]
+// @Router /user/unregister [delete]
 func (handler *UserHandler) Unregister(c *gin.Context) {
 
 	var queryData models.UnregisterDTO
@@ -182,19 +171,13 @@ func (handler *UserHandler) Unregister(c *gin.Context) {
 		return
 	}
 
-	err = VerifyToken(c, queryData.Login)
+	err = handler.auth.VerifyToken(c, queryData.Login)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
 	}
 
-	err = handler.service.RemoveBucket(c.Request.Context(), queryData.Login)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
-		return
-	}
-
-	err = handler.service.UnregisterUser(queryData.Login)
+	err = handler.service.UnregisterUser(c.Request.Context(), queryData.Login)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
@@ -214,7 +197,7 @@ func (handler *UserHandler) Unregister(c *gin.Context) {
 // @Success 	 200 		{object}		responses.AddRolesSuccess
 // @Failure 	 400 		{object}		responses.Error
 // @Failure 	 400 		{object}		responses.AddRolesError
-// @Router /addRoles [put]
+// @Router /user/addRoles [put]
 func (handler *UserHandler) AddRoles(c *gin.Context) {
 
 	var queryData models.AddRolesDTO
@@ -224,13 +207,13 @@ func (handler *UserHandler) AddRoles(c *gin.Context) {
 		return
 	}
 
-	err = VerifyAdmin(c)
+	err = handler.auth.VerifyAdmin(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
 	}
 
-	newRolesStatus, err := handler.service.AddRoles(queryData.Login, queryData.Roles)
+	newRolesStatus, err := handler.service.AddRoles(c.Request.Context(), queryData.Login, queryData.Roles)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error(), "Roles status": newRolesStatus})
 		return
@@ -248,7 +231,7 @@ func (handler *UserHandler) AddRoles(c *gin.Context) {
 // @Param		 Authorization	header	string		true	"Access token"
 // @Success 	 200 		{object}		responses.GetUserSuccess
 // @Failure 	 400 		{object}		responses.Error
-// @Router /getUserData [post]
+// @Router /user/getUserData [post]
 func (handler *UserHandler) GetUserData(c *gin.Context) {
 
 	var queryData models.GetUserDataDTO
@@ -258,7 +241,7 @@ func (handler *UserHandler) GetUserData(c *gin.Context) {
 		return
 	}
 
-	err = VerifyToken(c, queryData.Login)
+	err = handler.auth.VerifyToken(c, queryData.Login)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
@@ -275,6 +258,8 @@ func (handler *UserHandler) GetUserData(c *gin.Context) {
 
 // UploadFile  	 godoc
 // @Summary 	 UploadFile user
+// @Description  The extension, the declared content type and the content must agree on a type the roles of the user
+// @Description  may upload, see UPLOAD_ALLOWED_TYPES. SVG images are stored without scripts and external references.
 // @Tags 		 File
 // @Accept       mpfd
 // @Produce      json
@@ -283,7 +268,8 @@ func (handler *UserHandler) GetUserData(c *gin.Context) {
 // @Param		 Authorization	header	string		true	"Access token"
 // @Success 	 200 		"File was successfully uploaded" string
 // @Failure 	 400 		{object}		responses.Error
-// @Router /uploadFile [post]
+// @Failure 	 413 		{object}		responses.Error
+// @Router /user/uploadFile [post]
 func (handler *UserHandler) UploadFile(c *gin.Context) {
 	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize)
 
@@ -303,35 +289,24 @@ func (handler *UserHandler) UploadFile(c *gin.Context) {
 	login := form.Value["login"][0]
 
 	file, err := fileHeader.Open()
-
-	fmt.Println(file)
-	fmt.Println(login)
-
-	err = VerifyToken(c, login)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
 	}
+	defer file.Close()
 
-	buffer := make([]byte, fileHeader.Size)
-	_, err = file.Read(buffer)
+	fmt.Println(file)
+	fmt.Println(login)
 
+	err = handler.auth.VerifyToken(c, login)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
 	}
-	fileType := http.DetectContentType(buffer)
-
-	if _, value := fileTypes[fileType]; !value {
-		c.JSON(http.StatusBadRequest, gin.H{"Error": customError.TypeNotAllowed.Error()})
-		return
-	}
-	file.Seek(0, io.SeekStart)
-
-	err = handler.service.UploadFile(c.Request.Context(), login, fileHeader.Filename, file, fileHeader.Size, fileType)
 
+	_, err = handler.service.UploadFile(c.Request.Context(), login, fileHeader.Filename, file, fileHeader.Size, fileHeader.Header.Get("Content-Type"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		c.JSON(storeErrorStatus(err), gin.H{"Error": err.Error()})
 		return
 	}
 
@@ -339,43 +314,9 @@ func (handler *UserHandler) UploadFile(c *gin.Context) {
 	return
 }
 
-// DownloadFile  	 godoc
-// @Summary 	 DownloadFile user
-// @Tags 		 File
-// @Accept       json
-// @Produce      json
-// @Param		 DownloadFileDTO	body	models.DownloadFileDTO		true	"Login of an owner, name of file and path of downloading"
-// @Param		 Authorization	header	string		true	"Access token"
-// @Success 	 200 		"File was successfully downloaded" string
-// @Failure 	 400 		{object}		responses.Error
-// @Router /downloadFile [post]
-func (handler *UserHandler) DownloadFile(c *gin.Context) {
-
-	var queryData models.DownloadFileDTO
-	err := c.ShouldBind(&queryData)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
-		return
-	}
-
-	err = VerifyToken(c, queryData.Login)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
-		return
-	}
-
-	err = handler.service.DownloadFile(c.Request.Context(), queryData.Login, queryData.FileName, queryData.Path)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, "File was successfully downloaded")
-	return
-}
-
 // DeleteFile  	 godoc
 // @Summary 	 DeleteFile user
+// @Description  Deletes a file of login, which may be another user's when they shared it read-write with the caller.
 // @Tags 		 File
 // @Accept       json
 // @Produce      json
@@ -383,7 +324,7 @@ func (handler *UserHandler) DownloadFile(c *gin.Context) {
 // @Param		 Authorization	header	string		true	"Access token"
 // @Success 	 200 		"File was successfully deleted" string
 // @Failure 	 400 		{object}		responses.Error
-// @Router /deleteFile [delete]
+// @Router /user/deleteFile [delete]
 func (handler *UserHandler) DeleteFile(c *gin.Context) {
 
 	var queryData models.DeleteFileDTO
@@ -393,13 +334,13 @@ func (handler *UserHandler) DeleteFile(c *gin.Context) {
 		return
 	}
 
-	err = VerifyToken(c, queryData.Login)
+	requester, err := handler.auth.Requester(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
 	}
 
-	err = handler.service.DeleteFile(c.Request.Context(), queryData.Login, queryData.FileName)
+	err = handler.service.DeleteFile(c.Request.Context(), requester, queryData.Login, queryData.FileName)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
@@ -411,14 +352,19 @@ func (handler *UserHandler) DeleteFile(c *gin.Context) {
 
 // GetFileList   godoc
 // @Summary 	 GetFileList user
+// @Description  Lists files under prefix with their size, content type, modification time and ETag. Files below the next
+// @Description  delimiter ("/" by default) are listed as folders unless recursive is set. filter keeps names containing it.
+// @Description  sort is name, size or last_modified and order asc or desc; limit is 100 by default and 1000 at most.
+// @Description  Pass next_cursor as cursor with the same prefix, delimiter, recursive, filter, sort and order to get the next page.
+// @Description  Other users' files can be listed with a prefix ending in "/" that is a folder they shared with the caller or lies below one.
 // @Tags 		 File
 // @Accept       json
 // @Produce      json
-// @Param		 GetFileListDTO	body	models.GetFileListDTO		true	"Login of an owner of files"
+// @Param		 GetFileListDTO	body	models.GetFileListDTO		true	"Login of an owner of files and listing options"
 // @Param		 Authorization	header	string		true	"Access token"
 // @Success 	 200 		{object}		responses.GetFileListSuccess
 // @Failure 	 400 		{object}		responses.Error
-// @Router /getFileList [post]
+// @Router /user/getFileList [post]
 func (handler *UserHandler) GetFileList(c *gin.Context) {
 
 	var queryData models.GetFileListDTO
@@ -428,18 +374,33 @@ func (handler *UserHandler) GetFileList(c *gin.Context) {
 		return
 	}
 
-	err = VerifyToken(c, queryData.Login)
+	requester, err := handler.auth.Requester(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
 	}
 
-	list, err := handler.service.GetFileList(c.Request.Context(), queryData.Login)
+	files, nextCursor, err := handler.service.GetFileList(c.Request.Context(), requester, queryData)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"Files list": list})
+	list := make([]responses.FileSummary, 0, len(files))
+	for _, file := range files {
+		summary := responses.FileSummary{
+			Name:        file.Name,
+			Folder:      file.Folder,
+			Size:        file.Size,
+			ContentType: file.ContentType,
+			ETag:        file.ETag,
+		}
+		if !file.Folder {
+			summary.LastModified = &file.LastModified
+		}
+		list = append(list, summary)
+	}
+
+	c.JSON(http.StatusOK, responses.GetFileListSuccess{Files: list, NextCursor: nextCursor})
 	return
 }
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/internal/core/domain/responses"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// PresignUpload godoc
+// @Summary 	 Get URLs to upload a file directly to storage
+// @Description  Returns a URL for a PUT that must send put_headers, and a URL with form fields for a multipart POST
+// @Description  whose policy caps the size. Files may be up to PRESIGN_MAX_SIZE bytes; the data never passes through
+// @Description  this service. Both URLs expire after expires seconds, bounded by PRESIGN_MAX_EXPIRY.
+// @Description  The file is sent to staging and only reaches the bucket once the returned id is confirmed,
+// @Description  at most an hour after the URLs expire.
+// @Tags 		 File
+// @Accept       json
+// @Produce      json
+// @Param		 PresignUploadDTO	body	models.PresignUploadDTO		true	"Owner, name, content type and size of the new file"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.PresignUploadSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Failure 	 409 		{object}		responses.Error
+// @Failure 	 413 		{object}		responses.Error
+// @Router /user/presignUpload [post]
+func (handler *UploadHandler) PresignUpload(c *gin.Context) {
+
+	var queryData models.PresignUploadDTO
+	err := c.ShouldBindJSON(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	upload, err := handler.uploads.PresignUpload(c.Request.Context(), queryData.Login, queryData.FileName,
+		queryData.ContentType, queryData.Size, time.Duration(queryData.Expires)*time.Second)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.PresignUploadSuccess{
+		Id:         upload.Id,
+		PutURL:     upload.PutURL,
+		PutHeaders: upload.PutHeaders,
+		PostURL:    upload.PostURL,
+		FormData:   upload.FormData,
+		ExpiresAt:  upload.ExpiresAt,
+	})
+}
+
+// ConfirmUpload godoc
+// @Summary 	 Accept a file uploaded with a presigned URL
+// @Description  Checks the size and content of the staged file against the upload rules and the storage quota, and moves
+// @Description  it into the bucket. Each upload is accepted once; a file that breaks the rules is dropped.
+// @Tags 		 File
+// @Accept       json
+// @Produce      json
+// @Param		 ConfirmUploadDTO	body	models.ConfirmUploadDTO		true	"Login of an owner and id of the presigned upload"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"File was successfully uploaded" string
+// @Failure 	 400 		{object}		responses.Error
+// @Failure 	 404 		{object}		responses.Error
+// @Failure 	 409 		{object}		responses.Error
+// @Failure 	 413 		{object}		responses.Error
+// @Router /user/confirmUpload [post]
+func (handler *UploadHandler) ConfirmUpload(c *gin.Context) {
+
+	var queryData models.ConfirmUploadDTO
+	err := c.ShouldBindJSON(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	_, err = handler.uploads.ConfirmUpload(c.Request.Context(), queryData.Login, queryData.Id)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "File was successfully uploaded")
+}
+
+// PresignDownload godoc
+// @Summary 	 Get a URL to download a file directly from storage
+// @Description  The URL expires after expires seconds, bounded by PRESIGN_MAX_EXPIRY.
+// @Tags 		 File
+// @Accept       json
+// @Produce      json
+// @Param		 PresignDownloadDTO	body	models.PresignDownloadDTO		true	"Login of an owner and name of file to download"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.PresignDownloadSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/presignDownload [post]
+func (handler *UserHandler) PresignDownload(c *gin.Context) {
+
+	var queryData models.PresignDownloadDTO
+	err := c.ShouldBindJSON(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	url, expiresAt, err := handler.service.PresignDownload(c.Request.Context(), queryData.Login, queryData.FileName,
+		time.Duration(queryData.Expires)*time.Second)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.PresignDownloadSuccess{URL: url, ExpiresAt: expiresAt})
+}
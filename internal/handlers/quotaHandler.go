@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/internal/core/domain/responses"
+	"auth/pkg/customError"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GetQuota  	 godoc
+// @Summary 	 Get storage usage and limits
+// @Description  A limit of 0 means unlimited. Limits come from STORAGE_QUOTAS for the roles of the user unless an Admin set them.
+// @Tags 		 File
+// @Produce      json
+// @Param		 login			query	string		false	"Login of a user, the token owner by default"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.QuotaSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/quota [get]
+func (handler *UserHandler) GetQuota(c *gin.Context) {
+
+	var queryData models.QuotaDTO
+	err := c.ShouldBindQuery(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	login, err := handler.auth.VerifyOwner(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	quota, err := handler.service.GetQuota(login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	response := responses.QuotaSuccess{
+		UsedBytes:   quota.UsedBytes,
+		UsedObjects: quota.UsedObjects,
+		MaxBytes:    quota.MaxBytes,
+		MaxObjects:  quota.MaxObjects,
+		Overridden:  quota.Overridden,
+	}
+	if !quota.ReconciledAt.IsZero() {
+		response.ReconciledAt = &quota.ReconciledAt
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetQuota  	 godoc
+// @Summary 	 Set storage limits of a user
+// @Description  Overrides the limits the roles of the user give, 0 meaning unlimited. A null limit is taken from the roles again.
+// @Tags 		 Admin
+// @Accept       json
+// @Produce      json
+// @Param		 login			path	string		true	"Login of an account"
+// @Param		 QuotaOverride	body	models.QuotaOverride		true	"Largest number of bytes and of files"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"Storage quota was successfully changed"			string
+// @Failure 	 400 		{object}		responses.Error
+// @Router /admin/users/{login}/quota [put]
+func (handler *UserHandler) SetQuota(c *gin.Context) {
+
+	var queryData models.QuotaOverride
+	err := c.ShouldBindJSON(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.service.SetQuotaOverride(c.Request.Context(), c.Param("login"), queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "Storage quota was successfully changed")
+}
+
+// storeErrorStatus answers a failed upload with 413 when the storage quota is full.
+func storeErrorStatus(err error) int {
+	if errors.Is(err, customError.QuotaExceededError) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLimitKeyBody is how much of a request body is read to find the login of a "login" keyed policy.
+const maxLimitKeyBody = 64 << 10
+
+type RateLimitStore interface {
+	Take(key string, capacity int, period time.Duration) (models.RateLimitState, error)
+}
+
+type RateLimiter struct {
+	store RateLimitStore
+}
+
+func NewRateLimiter(store RateLimitStore) *RateLimiter {
+	return &RateLimiter{store: store}
+}
+
+// LoadRateLimitPolicy returns def overridden by RATE_LIMIT_<NAME>, written as
+// "<capacity>/<period>", for example "5/1m".
+func LoadRateLimitPolicy(def models.RateLimitPolicy) models.RateLimitPolicy {
+	name := "RATE_LIMIT_" + strings.ToUpper(def.Name)
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return def
+	}
+
+	capacity, period, found := strings.Cut(value, "/")
+	if !found {
+		log.Printf("Invalid %s %q, using %d/%s", name, value, def.Capacity, def.Period)
+		return def
+	}
+
+	policy := def
+	var err error
+
+	policy.Capacity, err = strconv.Atoi(capacity)
+	if err != nil || policy.Capacity <= 0 {
+		log.Printf("Invalid %s %q, using %d/%s", name, value, def.Capacity, def.Period)
+		return def
+	}
+
+	policy.Period, err = time.ParseDuration(period)
+	if err != nil || policy.Period <= 0 {
+		log.Printf("Invalid %s %q, using %d/%s", name, value, def.Capacity, def.Period)
+		return def
+	}
+
+	return policy
+}
+
+// Limit returns a middleware applying policy. Every response carries the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, requests over
+// the limit are rejected with 429 and Retry-After. If the store fails requests are let through.
+func (limiter *RateLimiter) Limit(policy models.RateLimitPolicy) gin.HandlerFunc {
+	rate := float64(policy.Capacity) / policy.Period.Seconds()
+
+	return func(c *gin.Context) {
+		key := fmt.Sprintf("%s:%s:%s", policy.Name, policy.Key, limitKey(c, policy.Key))
+
+		state, err := limiter.store.Take(key, policy.Capacity, policy.Period)
+		if err != nil {
+			log.Printf("Rate limit %s failed: %v", policy.Name, err)
+			c.Next()
+			return
+		}
+
+		reset := math.Ceil((float64(policy.Capacity) - state.Tokens) / rate)
+
+		c.Header("RateLimit-Limit", strconv.Itoa(policy.Capacity))
+		c.Header("RateLimit-Remaining", strconv.Itoa(int(math.Max(0, math.Floor(state.Tokens)))))
+		c.Header("RateLimit-Reset", strconv.Itoa(int(reset)))
+
+		if !state.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil((1-state.Tokens)/rate))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"Error": customError.RateLimitedError.Error()})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// limitKey finds what the request is limited by. Requests without a login or a
+// valid token are limited by IP, which forwarding headers only set when they come
+// from one of TRUSTED_PROXIES.
+func limitKey(c *gin.Context, key string) string {
+	switch key {
+	case "login":
+		if login := bodyLogin(c); login != "" {
+			return login
+		}
+	case "subject":
+		if login := tokenSubject(c); login != "" {
+			return login
+		}
+	}
+
+	return c.ClientIP()
+}
+
+// bodyLogin reads the login field of a JSON body and puts the body back for the handler.
+func bodyLogin(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxLimitKeyBody))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if err != nil {
+		return ""
+	}
+
+	var data struct {
+		Login string `json:"login"`
+	}
+	if json.Unmarshal(body, &data) != nil {
+		return ""
+	}
+
+	return data.Login
+}
+
+// tokenSubject returns the login of a correctly signed access token. The account
+// itself is not checked here, handlers still verify the token.
+func tokenSubject(c *gin.Context) string {
+	access_token := c.Request.Header.Get("Authorization")
+	if access_token == "" {
+		return ""
+	}
+
+	claims, err := parseClaims(access_token)
+	if err != nil {
+		return ""
+	}
+
+	return claims.Login
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeLimitStore allows capacity requests per key and never refills. It records
+// the keys it was asked for.
+type fakeLimitStore struct {
+	taken map[string]int
+	keys  []string
+	err   error
+}
+
+func (store *fakeLimitStore) Take(key string, capacity int, _ time.Duration) (models.RateLimitState, error) {
+	store.keys = append(store.keys, key)
+	if store.err != nil {
+		return models.RateLimitState{}, store.err
+	}
+
+	if store.taken[key] >= capacity {
+		return models.RateLimitState{Allowed: false, Tokens: 0}, nil
+	}
+	store.taken[key]++
+
+	return models.RateLimitState{Allowed: true, Tokens: float64(capacity - store.taken[key])}, nil
+}
+
+func newLimitedRouter(t *testing.T, store RateLimitStore, policy models.RateLimitPolicy, trustedProxies []string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		t.Fatalf("SetTrustedProxies() error = %v", err)
+	}
+	r.POST("/", NewRateLimiter(store).Limit(policy), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	return r
+}
+
+func TestLimit(t *testing.T) {
+	store := &fakeLimitStore{taken: make(map[string]int)}
+	policy := models.RateLimitPolicy{Name: "test", Capacity: 2, Period: time.Minute, Key: "ip"}
+	r := newLimitedRouter(t, store, policy, nil)
+
+	tests := []struct {
+		status     int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{http.StatusOK, "1", "30", ""},
+		{http.StatusOK, "0", "60", ""},
+		{http.StatusTooManyRequests, "0", "60", "30"},
+	}
+
+	for i, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/", nil)
+		request.RemoteAddr = "192.0.2.1:1234"
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, request)
+
+		if response.Code != test.status {
+			t.Errorf("request %d: status = %d, want %d", i, response.Code, test.status)
+		}
+		headers := map[string]string{
+			"RateLimit-Limit":     "2",
+			"RateLimit-Remaining": test.remaining,
+			"RateLimit-Reset":     test.reset,
+			"Retry-After":         test.retryAfter,
+		}
+		for name, want := range headers {
+			if got := response.Header().Get(name); got != want {
+				t.Errorf("request %d: %s = %q, want %q", i, name, got, want)
+			}
+		}
+	}
+
+	// Another client has its own bucket.
+	request := httptest.NewRequest(http.MethodPost, "/", nil)
+	request.RemoteAddr = "192.0.2.2:1234"
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	if response.Code != http.StatusOK {
+		t.Errorf("other client: status = %d, want %d", response.Code, http.StatusOK)
+	}
+}
+
+func TestLimitStoreFailure(t *testing.T) {
+	store := &fakeLimitStore{err: errors.New("database unavailable")}
+	policy := models.RateLimitPolicy{Name: "test", Capacity: 1, Period: time.Minute, Key: "ip"}
+	r := newLimitedRouter(t, store, policy, nil)
+
+	for i := 0; i < 3; i++ {
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/", nil))
+		if response.Code != http.StatusOK {
+			t.Errorf("request %d: status = %d, want requests let through", i, response.Code)
+		}
+	}
+}
+
+func testToken(t *testing.T, login, secret string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"login": login, "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return token
+}
+
+func TestLimitKey(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "secret")
+
+	tests := []struct {
+		name           string
+		key            string
+		trustedProxies []string
+		forwardedFor   string
+		token          string
+		body           string
+		want           string
+	}{
+		{name: "ip", key: "ip", want: "test:ip:192.0.2.1"},
+		{name: "forwarded for without trusted proxies", key: "ip", forwardedFor: "203.0.113.9", want: "test:ip:192.0.2.1"},
+		{name: "forwarded for by an untrusted proxy", key: "ip", trustedProxies: []string{"198.51.100.0/24"},
+			forwardedFor: "203.0.113.9", want: "test:ip:192.0.2.1"},
+		{name: "forwarded for by a trusted proxy", key: "ip", trustedProxies: []string{"192.0.2.0/24"},
+			forwardedFor: "203.0.113.9", want: "test:ip:203.0.113.9"},
+		{name: "login", key: "login", body: `{"login":"alice","password":"x"}`, want: "test:login:alice"},
+		{name: "no login", key: "login", body: `{"password":"x"}`, want: "test:login:192.0.2.1"},
+		{name: "body not JSON", key: "login", body: "login=alice", want: "test:login:192.0.2.1"},
+		{name: "subject", key: "subject", token: testToken(t, "bob", "secret"), want: "test:subject:bob"},
+		{name: "forged subject", key: "subject", token: testToken(t, "bob", "other"), want: "test:subject:192.0.2.1"},
+		{name: "no token", key: "subject", want: "test:subject:192.0.2.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &fakeLimitStore{taken: make(map[string]int)}
+			policy := models.RateLimitPolicy{Name: "test", Capacity: 5, Period: time.Minute, Key: test.key}
+			r := newLimitedRouter(t, store, policy, test.trustedProxies)
+
+			request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+			request.RemoteAddr = "192.0.2.1:1234"
+			if test.forwardedFor != "" {
+				request.Header.Set("X-Forwarded-For", test.forwardedFor)
+			}
+			if test.token != "" {
+				request.Header.Set("Authorization", test.token)
+			}
+			response := httptest.NewRecorder()
+			r.ServeHTTP(response, request)
+
+			if len(store.keys) != 1 || store.keys[0] != test.want {
+				t.Errorf("keys = %q, want %q", store.keys, test.want)
+			}
+			// The handler still reads the whole body.
+			if got := response.Body.String(); got != test.body {
+				t.Errorf("handler read body %q, want %q", got, test.body)
+			}
+		})
+	}
+}
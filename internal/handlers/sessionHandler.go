@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/internal/core/domain/responses"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// ListSessions  godoc
+// @Summary 	 List login history and active sessions
+// @Description  Every successful login starts a session that lasts as long as its access token. Sessions are listed newest first.
+// @Tags 		 User
+// @Produce      json
+// @Param		 login			query	string		true	"Login of an account"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.ListSessionsSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/sessions [get]
+func (handler *UserHandler) ListSessions(c *gin.Context) {
+
+	var queryData models.SessionsDTO
+	err := c.ShouldBindQuery(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	sessions, err := handler.service.ListSessions(queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	currentId := ""
+	if claims, ok := c.Get(claimsKey); ok {
+		currentId = claims.(models.TokenClaims).Id
+	}
+
+	list := make([]responses.SessionSummary, 0, len(sessions))
+	for _, session := range sessions {
+		summary := responses.SessionSummary{
+			Id:        session.Id,
+			IP:        session.IP,
+			UserAgent: session.UserAgent,
+			Device:    session.Device,
+			Country:   session.Location.Country,
+			City:      session.Location.City,
+			RiskScore: session.RiskScore,
+			Flagged:   session.RiskAction == models.RiskFlag,
+			CreatedAt: session.CreatedAt,
+			ExpiresAt: session.ExpiresAt,
+			Active:    session.RevokedAt.IsZero() && session.ExpiresAt.After(time.Now()),
+			Current:   session.Id == currentId,
+		}
+		if !session.RevokedAt.IsZero() {
+			summary.RevokedAt = &session.RevokedAt
+		}
+		list = append(list, summary)
+	}
+
+	c.JSON(http.StatusOK, responses.ListSessionsSuccess{Sessions: list})
+}
+
+// RevokeSession godoc
+// @Summary 	 Revoke session
+// @Description  The access token of the session stops working at once.
+// @Tags 		 User
+// @Produce      json
+// @Param		 id				path	string		true	"Session id"
+// @Param		 login			query	string		true	"Login of an account"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"Session was successfully revoked"			string
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/sessions/{id} [delete]
+func (handler *UserHandler) RevokeSession(c *gin.Context) {
+
+	var queryData models.SessionsDTO
+	err := c.ShouldBindQuery(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.service.RevokeSession(c.Request.Context(), queryData.Login, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "Session was successfully revoked")
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/internal/core/domain/responses"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ShareFile     godoc
+// @Summary 	 Share a file or folder with a user or group
+// @Description  Gives user or the members of group, exactly one of them, access to path, or to everything in it when it ends
+// @Description  with "/". read allows downloading and listing, read-write also deleting. Sharing a path with the same user
+// @Description  or group again changes the permission. Shares end when the path is deleted or moved.
+// @Tags 		 File
+// @Accept       json
+// @Produce      json
+// @Param		 ShareFileDTO	body	models.ShareFileDTO		true	"Login of an owner, the path, the grantee and read or read-write"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.ShareSummary
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/shares [post]
+func (handler *UserHandler) ShareFile(c *gin.Context) {
+
+	var queryData models.ShareFileDTO
+	err := c.ShouldBindJSON(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	share, err := handler.service.ShareFile(c.Request.Context(), queryData.Login, queryData.Path, queryData.User, queryData.Group,
+		queryData.Permission)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, shareSummary(share))
+}
+
+// ListShares    godoc
+// @Summary 	 List the shares a user gave
+// @Tags 		 File
+// @Produce      json
+// @Param		 login			query	string		false	"Login of an owner, the token owner by default"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.ListSharesSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/shares [get]
+func (handler *UserHandler) ListShares(c *gin.Context) {
+
+	var queryData models.SharesDTO
+	err := c.ShouldBindQuery(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	login, err := handler.auth.VerifyOwner(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	shares, err := handler.service.ListShares(login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, shareList(shares))
+}
+
+// ListSharedWith godoc
+// @Summary 	 List what others shared with the caller
+// @Description  Includes what was shared with groups the caller is a member of.
+// @Tags 		 File
+// @Produce      json
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.ListSharesSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/shares/received [get]
+func (handler *UserHandler) ListSharedWith(c *gin.Context) {
+
+	requester, err := handler.auth.Requester(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	shares, err := handler.service.ListSharedWith(requester)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, shareList(shares))
+}
+
+// RevokeShare   godoc
+// @Summary 	 Revoke a share
+// @Description  Only the owner of the shared path and Admins may revoke a share.
+// @Tags 		 File
+// @Produce      json
+// @Param		 id				path	string		true	"Id of the share"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"Share was successfully revoked" string
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/shares/{id} [delete]
+func (handler *UserHandler) RevokeShare(c *gin.Context) {
+
+	requester, err := handler.auth.Requester(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	_, err = handler.service.RevokeShare(c.Request.Context(), requester, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "Share was successfully revoked")
+}
+
+func shareSummary(share models.Share) responses.ShareSummary {
+	return responses.ShareSummary{
+		Id:         share.Id,
+		Owner:      share.OwnerLogin,
+		Path:       share.Path,
+		User:       share.GranteeLogin,
+		Group:      share.GranteeGroup,
+		Permission: share.Permission,
+		CreatedAt:  share.CreatedAt,
+	}
+}
+
+func shareList(shares []models.Share) responses.ListSharesSuccess {
+	list := make([]responses.ShareSummary, 0, len(shares))
+	for _, share := range shares {
+		list = append(list, shareSummary(share))
+	}
+
+	return responses.ListSharesSuccess{Shares: list}
+}
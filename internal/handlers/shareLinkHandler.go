@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/internal/core/domain/responses"
+	"auth/pkg/customError"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// CreateShareLink godoc
+// @Summary 	 Create a public link to a file
+// @Description  Anyone with the link can download the file without an account until it expires after expires seconds,
+// @Description  reaches max-downloads or is revoked; 0 means no limit. A password is asked for with HTTP Basic
+// @Description  authentication, any user name. The token is only returned here. The link ends when the file is deleted or moved.
+// @Tags 		 File
+// @Accept       json
+// @Produce      json
+// @Param		 CreateShareLinkDTO	body	models.CreateShareLinkDTO		true	"Owner, file name and limits of the link"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.CreateShareLinkSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/links [post]
+func (handler *UserHandler) CreateShareLink(c *gin.Context) {
+
+	var queryData models.CreateShareLinkDTO
+	err := c.ShouldBindJSON(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	err = handler.auth.VerifyToken(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	link, token, err := handler.service.CreateShareLink(c.Request.Context(), queryData.Login, queryData.FileName, queryData.Password,
+		time.Duration(queryData.Expires)*time.Second, queryData.MaxDownloads)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	response := responses.CreateShareLinkSuccess{Id: link.Id, Token: token, Link: "/s/" + token}
+	if !link.ExpiresAt.IsZero() {
+		response.ExpiresAt = &link.ExpiresAt
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListShareLinks godoc
+// @Summary 	 List the public links of a user
+// @Description  Lists the links that still serve downloads, newest first, with how often they were used.
+// @Tags 		 File
+// @Produce      json
+// @Param		 login			query	string		false	"Login of an owner, the token owner by default"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		{object}		responses.ListShareLinksSuccess
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/links [get]
+func (handler *UserHandler) ListShareLinks(c *gin.Context) {
+
+	var queryData models.ShareLinksDTO
+	err := c.ShouldBindQuery(&queryData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	login, err := handler.auth.VerifyOwner(c, queryData.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	links, err := handler.service.ListShareLinks(login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	list := make([]responses.ShareLinkSummary, 0, len(links))
+	for _, link := range links {
+		summary := responses.ShareLinkSummary{
+			Id:           link.Id,
+			FileName:     link.Path,
+			Protected:    link.PasswordHash != "",
+			MaxDownloads: link.MaxDownloads,
+			Downloads:    link.Downloads,
+			CreatedAt:    link.CreatedAt,
+		}
+		if !link.ExpiresAt.IsZero() {
+			summary.ExpiresAt = &link.ExpiresAt
+		}
+		if !link.LastAccessedAt.IsZero() {
+			summary.LastAccessedAt = &link.LastAccessedAt
+		}
+		list = append(list, summary)
+	}
+
+	c.JSON(http.StatusOK, responses.ListShareLinksSuccess{Links: list})
+}
+
+// RevokeShareLink godoc
+// @Summary 	 Revoke a public link
+// @Description  Only the owner of the file and Admins may revoke a link.
+// @Tags 		 File
+// @Produce      json
+// @Param		 id				path	string		true	"Id of the link"
+// @Param		 Authorization	header	string		true	"Access token"
+// @Success 	 200 		"Link was successfully revoked" string
+// @Failure 	 400 		{object}		responses.Error
+// @Router /user/links/{id} [delete]
+func (handler *UserHandler) RevokeShareLink(c *gin.Context) {
+
+	requester, err := handler.auth.Requester(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	_, err = handler.service.RevokeShareLink(c.Request.Context(), requester, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, "Link was successfully revoked")
+}
+
+// DownloadShareLink godoc
+// @Summary 	 Download a file through a public link
+// @Description  Needs no account. Links with a password ask for it with HTTP Basic authentication, any user name.
+// @Description  Every request sends the whole file and counts as a download; Range and conditional headers are ignored.
+// @Tags 		 File
+// @Produce      octet-stream
+// @Param		 token			path	string		true	"Token of the link"
+// @Success 	 200 		{file}		file
+// @Failure 	 401 		{object}		responses.Error
+// @Header 		 401 		{string}		WWW-Authenticate	"Basic realm of the link password"
+// @Failure 	 404 		{object}		responses.Error
+// @Failure 	 410 		{object}		responses.Error		"Link was revoked, has expired or reached its download limit"
+// @Failure 	 429 		{object}		responses.Error
+// @Router /s/{token} [get]
+func (handler *UserHandler) DownloadShareLink(c *gin.Context) {
+
+	_, password, _ := c.Request.BasicAuth()
+
+	body, info, err := handler.service.OpenShareLink(c.Request.Context(), c.Param("token"), password)
+	switch {
+	case errors.Is(err, customError.LinkPasswordError):
+		c.Header("WWW-Authenticate", `Basic realm="share link", charset="UTF-8"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"Error": err.Error()})
+		return
+	case errors.Is(err, customError.LinkUnavailableError):
+		c.JSON(http.StatusGone, gin.H{"Error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusNotFound, gin.H{"Error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	serveWholeFile(c, info.Key, body, info)
+}
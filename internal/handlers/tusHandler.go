@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,expiration,termination"
+	tusContentType = "application/offset+octet-stream"
+)
+
+type UploadService interface {
+	MaxSize() int64
+	CreateUpload(ctx context.Context, login, fileName, contentType string, length int64, metadata string) (models.Upload, error)
+	GetUpload(id string) (models.Upload, error)
+	AppendUpload(ctx context.Context, id string, offset int64, body io.Reader) (models.Upload, error)
+	TerminateUpload(ctx context.Context, id string) error
+	PresignUpload(ctx context.Context, login, fileName, declaredType string, size int64, expires time.Duration) (models.PresignedUpload, error)
+	ConfirmUpload(ctx context.Context, login, id string) (models.PresignedUpload, error)
+}
+
+// UploadHandler serves resumable uploads following the tus 1.0 protocol with the
+// creation, expiration and termination extensions, and the presigned uploads that
+// are sent straight to storage. Both pass the checks of UploadService before a
+// file is stored.
+type UploadHandler struct {
+	uploads UploadService
+	auth    *Authenticator
+}
+
+func NewUploadHandler(uploads UploadService, auth *Authenticator) *UploadHandler {
+	return &UploadHandler{uploads: uploads, auth: auth}
+}
+
+// TusResumable answers requests of clients speaking another tus version with 412
+// and marks every response with the version spoken here.
+func TusResumable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Tus-Resumable", tusVersion)
+
+		if c.Request.Method != http.MethodOptions && c.GetHeader("Tus-Resumable") != tusVersion {
+			c.Header("Tus-Version", tusVersion)
+			c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"Error": "unsupported tus version"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Options       godoc
+// @Summary 	 Describe the upload server
+// @Tags 		 Upload
+// @Success 	 204
+// @Header 		 204 		{string}		Tus-Version		"1.0.0"
+// @Header 		 204 		{string}		Tus-Extension	"creation,expiration,termination"
+// @Header 		 204 		{integer}		Tus-Max-Size	"Largest upload in bytes"
+// @Router /uploads [options]
+func (handler *UploadHandler) Options(c *gin.Context) {
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Max-Size", strconv.FormatInt(handler.uploads.MaxSize(), 10))
+	c.Status(http.StatusNoContent)
+}
+
+// CreateUpload  godoc
+// @Summary 	 Start a resumable upload
+// @Description  Upload-Metadata must carry filename and may carry filetype, the declared content type, and login
+// @Description  to upload for another user as an Admin. The name and type are checked against the file type policy.
+// @Description  Deferred lengths are not supported.
+// @Tags 		 Upload
+// @Param		 Tus-Resumable		header	string		true	"1.0.0"
+// @Param		 Upload-Length		header	integer		true	"Size of the file in bytes"
+// @Param		 Upload-Metadata	header	string		true	"Comma separated keys with base64 values"
+// @Param		 Authorization		header	string		true	"Access token"
+// @Success 	 201
+// @Header 		 201 		{string}		Location		"URL of the upload"
+// @Header 		 201 		{string}		Upload-Expires	"When the upload is dropped unless continued"
+// @Failure 	 400 		{object}		responses.Error
+// @Failure 	 413 		{object}		responses.Error
+// @Router /uploads [post]
+func (handler *UploadHandler) CreateUpload(c *gin.Context) {
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Upload-Length must be a positive number of bytes"})
+		return
+	}
+
+	rawMetadata := c.GetHeader("Upload-Metadata")
+	metadata, err := parseUploadMetadata(rawMetadata)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if metadata["filename"] == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Upload-Metadata must contain filename"})
+		return
+	}
+
+	login, err := handler.auth.VerifyOwner(c, metadata["login"])
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	upload, err := handler.uploads.CreateUpload(c.Request.Context(), login, metadata["filename"], metadata["filetype"], length, rawMetadata)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.Header("Location", "/uploads/"+upload.Id)
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// GetUpload     godoc
+// @Summary 	 Get the offset of a resumable upload
+// @Tags 		 Upload
+// @Param		 id					path	string		true	"Upload id"
+// @Param		 Tus-Resumable		header	string		true	"1.0.0"
+// @Param		 Authorization		header	string		true	"Access token"
+// @Success 	 200
+// @Header 		 200 		{integer}		Upload-Offset	"Bytes received so far"
+// @Header 		 200 		{integer}		Upload-Length	"Size of the file in bytes"
+// @Header 		 200 		{string}		Upload-Expires	"When the upload is dropped unless continued"
+// @Failure 	 404 		{object}		responses.Error
+// @Failure 	 410 		{object}		responses.Error
+// @Router /uploads/{id} [head]
+func (handler *UploadHandler) GetUpload(c *gin.Context) {
+
+	upload, ok := handler.ownUpload(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	if upload.Metadata != "" {
+		c.Header("Upload-Metadata", upload.Metadata)
+	}
+	c.Status(http.StatusOK)
+}
+
+// PatchUpload   godoc
+// @Summary 	 Send a chunk of a resumable upload
+// @Description  The chunk is written at Upload-Offset, which must equal the offset of the upload. With the last chunk
+// @Description  the file is checked before it is stored; a file breaking the upload rules ends the upload and 400 is
+// @Description  returned, or 409 when the name is taken and 413 when it does not fit in the storage quota. After
+// @Description  any other failure an empty chunk at the final offset tries again.
+// @Tags 		 Upload
+// @Accept       application/offset+octet-stream
+// @Param		 id					path	string		true	"Upload id"
+// @Param		 Tus-Resumable		header	string		true	"1.0.0"
+// @Param		 Upload-Offset		header	integer		true	"Offset the chunk starts at"
+// @Param		 Authorization		header	string		true	"Access token"
+// @Success 	 204
+// @Header 		 204 		{integer}		Upload-Offset	"Bytes received so far"
+// @Header 		 204 		{string}		Upload-Expires	"When the upload is dropped unless continued"
+// @Failure 	 400 		{object}		responses.Error
+// @Failure 	 404 		{object}		responses.Error
+// @Failure 	 409 		{object}		responses.Error
+// @Failure 	 410 		{object}		responses.Error
+// @Failure 	 413 		{object}		responses.Error
+// @Failure 	 415 		{object}		responses.Error
+// @Router /uploads/{id} [patch]
+func (handler *UploadHandler) PatchUpload(c *gin.Context) {
+
+	if c.ContentType() != tusContentType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"Error": "Content-Type must be " + tusContentType})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Upload-Offset must be a number of bytes"})
+		return
+	}
+
+	upload, ok := handler.ownUpload(c)
+	if !ok {
+		return
+	}
+
+	if c.Request.ContentLength > upload.Length-offset {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"Error": "chunk goes past Upload-Length"})
+		return
+	}
+
+	upload, err = handler.uploads.AppendUpload(c.Request.Context(), upload.Id, offset, c.Request.Body)
+	if err != nil {
+		if upload.Id != "" {
+			c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		}
+		c.JSON(uploadErrorStatus(err), gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+
+	if upload.Offset < upload.Length {
+		c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteUpload  godoc
+// @Summary 	 Cancel a resumable upload
+// @Tags 		 Upload
+// @Param		 id					path	string		true	"Upload id"
+// @Param		 Tus-Resumable		header	string		true	"1.0.0"
+// @Param		 Authorization		header	string		true	"Access token"
+// @Success 	 204
+// @Failure 	 404 		{object}		responses.Error
+// @Failure 	 410 		{object}		responses.Error
+// @Router /uploads/{id} [delete]
+func (handler *UploadHandler) DeleteUpload(c *gin.Context) {
+
+	upload, ok := handler.ownUpload(c)
+	if !ok {
+		return
+	}
+
+	err := handler.uploads.TerminateUpload(c.Request.Context(), upload.Id)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"Error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ownUpload looks up the upload of the request, which only its owner and Admins
+// may touch, and answers the request itself when it cannot continue.
+func (handler *UploadHandler) ownUpload(c *gin.Context) (models.Upload, bool) {
+
+	upload, err := handler.uploads.GetUpload(c.Param("id"))
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"Error": err.Error()})
+		return models.Upload{}, false
+	}
+
+	_, err = handler.auth.VerifyOwner(c, upload.Login)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return models.Upload{}, false
+	}
+
+	return upload, true
+}
+
+// parseUploadMetadata decodes an Upload-Metadata header: comma separated pairs of
+// a key and a base64 value, which may be left out.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if header == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("Upload-Metadata has an empty key")
+		}
+
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.New("Upload-Metadata value of " + key + " is not base64")
+		}
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
+
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, customError.UnexistingUploadError):
+		return http.StatusNotFound
+	case errors.Is(err, customError.UploadExpiredError):
+		return http.StatusGone
+	case errors.Is(err, customError.UploadOffsetError), errors.Is(err, customError.ExistingFileError):
+		return http.StatusConflict
+	case errors.Is(err, customError.UploadBusyError):
+		return http.StatusLocked
+	case errors.Is(err, customError.FileTooLargeError), errors.Is(err, customError.QuotaExceededError):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, customError.TooManyUploadsError):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// JSONLinesSink appends audit events, one JSON object per line, to the file in
+// AUDIT_FILE_PATH. Once the file would grow past AUDIT_FILE_MAX_SIZE bytes it is
+// renamed to path.1, older backups shift up and only AUDIT_FILE_MAX_BACKUPS are kept.
+type JSONLinesSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func NewJSONLinesSink() (*JSONLinesSink, error) {
+	sink := &JSONLinesSink{
+		path:       "audit.jsonl",
+		maxSize:    100 << 20,
+		maxBackups: 5,
+	}
+
+	if AUDIT_FILE_PATH, ok := os.LookupEnv("AUDIT_FILE_PATH"); ok {
+		sink.path = AUDIT_FILE_PATH
+	}
+
+	if AUDIT_FILE_MAX_SIZE, ok := os.LookupEnv("AUDIT_FILE_MAX_SIZE"); ok {
+		maxSize, err := strconv.ParseInt(AUDIT_FILE_MAX_SIZE, 10, 64)
+		if err != nil || maxSize <= 0 {
+			return nil, fmt.Errorf("AUDIT_FILE_MAX_SIZE %q must be a positive number of bytes", AUDIT_FILE_MAX_SIZE)
+		}
+		sink.maxSize = maxSize
+	}
+
+	if AUDIT_FILE_MAX_BACKUPS, ok := os.LookupEnv("AUDIT_FILE_MAX_BACKUPS"); ok {
+		maxBackups, err := strconv.Atoi(AUDIT_FILE_MAX_BACKUPS)
+		if err != nil || maxBackups < 0 {
+			return nil, fmt.Errorf("AUDIT_FILE_MAX_BACKUPS %q must be a non-negative number", AUDIT_FILE_MAX_BACKUPS)
+		}
+		sink.maxBackups = maxBackups
+	}
+
+	return sink, sink.open()
+}
+
+func (sink *JSONLinesSink) WriteAudit(events []models.AuditEvent) error {
+	if sink.file == nil {
+		err := sink.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+
+		if sink.size > 0 && sink.size+int64(len(line)) > sink.maxSize {
+			err = sink.rotate()
+			if err != nil {
+				return err
+			}
+		}
+
+		n, err := sink.file.Write(line)
+		sink.size += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sink *JSONLinesSink) open() error {
+	file, err := os.OpenFile(sink.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	sink.file = file
+	sink.size = stat.Size()
+
+	return nil
+}
+
+func (sink *JSONLinesSink) rotate() error {
+	sink.file.Close()
+	sink.file = nil
+
+	if sink.maxBackups == 0 {
+		err := os.Remove(sink.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return sink.open()
+	}
+
+	for i := sink.maxBackups - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", sink.path, i), fmt.Sprintf("%s.%d", sink.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	err := os.Rename(sink.path, sink.path+".1")
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return sink.open()
+}
+
+// StdoutSink writes audit events as JSON lines to standard output, for log
+// collectors that read container output.
+type StdoutSink struct {
+	out io.Writer
+}
+
+func NewStdoutSink() *StdoutSink {
+	return &StdoutSink{out: os.Stdout}
+}
+
+func (sink *StdoutSink) WriteAudit(events []models.AuditEvent) error {
+	writer := bufio.NewWriter(sink.out)
+	encoder := json.NewEncoder(writer)
+
+	for _, event := range events {
+		err := encoder.Encode(event)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const auditColumns = "audit_id, audit_at, audit_actor, audit_target, audit_action, audit_ip, audit_user_agent, " +
+	"audit_request_id, audit_details, audit_prev_hash, audit_hash"
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (repository *AuditRepository) AppendAudit(event models.AuditEvent, seal func(event models.AuditEvent) string) (models.AuditEvent, error) {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return models.AuditEvent{}, err
+	}
+	defer tx.Rollback()
+
+	// Appends are serialized, so every entry chains to the one committed right before it.
+	// Readers are not blocked by this lock mode.
+	_, err = tx.Exec("LOCK TABLE audit_log IN SHARE ROW EXCLUSIVE MODE")
+	if err != nil {
+		return models.AuditEvent{}, err
+	}
+
+	err = tx.QueryRow("SELECT audit_hash FROM audit_log ORDER BY audit_id DESC LIMIT 1").Scan(&event.PrevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return models.AuditEvent{}, err
+	}
+
+	// Postgres keeps microseconds, the hash must cover the stored value.
+	event.At = time.Now().UTC().Truncate(time.Microsecond)
+	event.Hash = seal(event)
+
+	var details []byte
+	if len(event.Details) > 0 {
+		details, err = json.Marshal(event.Details)
+		if err != nil {
+			return models.AuditEvent{}, err
+		}
+	}
+
+	query := "INSERT INTO audit_log (audit_at, audit_actor, audit_target, audit_action, audit_ip, audit_user_agent, " +
+		"audit_request_id, audit_details, audit_prev_hash, audit_hash) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING audit_id"
+
+	err = tx.QueryRow(query, event.At, event.Actor, event.Target, event.Action, event.IP, event.UserAgent,
+		event.RequestId, details, event.PrevHash, event.Hash).Scan(&event.Id)
+	if err != nil {
+		return models.AuditEvent{}, err
+	}
+
+	return event, tx.Commit()
+}
+
+func (repository *AuditRepository) QueryAudit(query models.AuditQuery) ([]models.AuditEvent, error) {
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.Actor != "" {
+		addCondition("audit_actor = $%d", query.Actor)
+	}
+	if query.Target != "" {
+		addCondition("audit_target = $%d", query.Target)
+	}
+	if query.Login != "" {
+		addCondition("(audit_actor = $%[1]d OR audit_target = $%[1]d)", query.Login)
+	}
+	if query.Action != "" {
+		addCondition("audit_action = $%d", query.Action)
+	}
+	if query.IP != "" {
+		addCondition("audit_ip = $%d", query.IP)
+	}
+	if query.RequestId != "" {
+		addCondition("audit_request_id = $%d", query.RequestId)
+	}
+	if !query.From.IsZero() {
+		addCondition("audit_at >= $%d", query.From)
+	}
+	if !query.To.IsZero() {
+		addCondition("audit_at < $%d", query.To)
+	}
+	if query.Before > 0 {
+		addCondition("audit_id < $%d", query.Before)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, query.Limit)
+	rows, err := repository.db.Query(fmt.Sprintf("SELECT %s FROM audit_log%s ORDER BY audit_id DESC LIMIT $%d", auditColumns, where, len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAuditEvents(rows)
+}
+
+func (repository *AuditRepository) ScanAudit(afterId int64, limit int) ([]models.AuditEvent, error) {
+	rows, err := repository.db.Query("SELECT "+auditColumns+" FROM audit_log WHERE audit_id > $1 ORDER BY audit_id LIMIT $2", afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAuditEvents(rows)
+}
+
+func scanAuditEvents(rows *sql.Rows) ([]models.AuditEvent, error) {
+	defer rows.Close()
+
+	events := make([]models.AuditEvent, 0)
+	for rows.Next() {
+		var event models.AuditEvent
+		var details []byte
+
+		err := rows.Scan(&event.Id, &event.At, &event.Actor, &event.Target, &event.Action, &event.IP, &event.UserAgent,
+			&event.RequestId, &details, &event.PrevHash, &event.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		if details != nil {
+			err = json.Unmarshal(details, &event.Details)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		event.At = event.At.UTC()
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
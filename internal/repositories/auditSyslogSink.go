@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	syslogFacilityAuthpriv = 10
+	syslogSeverityWarning  = 4
+	syslogSeverityNotice   = 5
+
+	// syslogStructuredDataId uses the enterprise number reserved for documentation,
+	// as the service has none of its own.
+	syslogStructuredDataId = "audit@32473"
+
+	syslogTimeout = 5 * time.Second
+)
+
+var syslogParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// SyslogSink sends audit events as RFC 5424 messages to the address in
+// AUDIT_SYSLOG_ADDRESS, udp://host:port or tcp://host:port. Over TCP messages are
+// framed by octet counting (RFC 6587). The message body is the event as JSON, the
+// fields SIEMs filter on are repeated as structured data.
+type SyslogSink struct {
+	network  string
+	address  string
+	appName  string
+	hostname string
+	conn     net.Conn
+}
+
+func NewSyslogSink() (*SyslogSink, error) {
+	AUDIT_SYSLOG_ADDRESS, _ := os.LookupEnv("AUDIT_SYSLOG_ADDRESS")
+	AUDIT_SYSLOG_APP_NAME, ok := os.LookupEnv("AUDIT_SYSLOG_APP_NAME")
+	if !ok {
+		AUDIT_SYSLOG_APP_NAME = "auth"
+	}
+
+	address, err := url.Parse(AUDIT_SYSLOG_ADDRESS)
+	if err != nil || (address.Scheme != "udp" && address.Scheme != "tcp") || address.Host == "" {
+		return nil, fmt.Errorf("AUDIT_SYSLOG_ADDRESS %q must be udp://host:port or tcp://host:port", AUDIT_SYSLOG_ADDRESS)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	return &SyslogSink{
+		network:  address.Scheme,
+		address:  address.Host,
+		appName:  AUDIT_SYSLOG_APP_NAME,
+		hostname: hostname,
+	}, nil
+}
+
+func (sink *SyslogSink) WriteAudit(events []models.AuditEvent) error {
+	if sink.conn == nil {
+		conn, err := net.DialTimeout(sink.network, sink.address, syslogTimeout)
+		if err != nil {
+			return err
+		}
+		sink.conn = conn
+	}
+
+	sink.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+
+	for _, event := range events {
+		message, err := sink.format(event)
+		if err != nil {
+			return err
+		}
+
+		if sink.network == "tcp" {
+			message = append([]byte(fmt.Sprintf("%d ", len(message))), message...)
+		}
+
+		_, err = sink.conn.Write(message)
+		if err != nil {
+			// The connection is redialed on the next write, the whole batch is sent again.
+			sink.conn.Close()
+			sink.conn = nil
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sink *SyslogSink) format(event models.AuditEvent) ([]byte, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	severity := syslogSeverityNotice
+	if event.Action == models.AuditLoginFailed || event.Details["risk_action"] == models.RiskFlag {
+		severity = syslogSeverityWarning
+	}
+
+	var structuredData strings.Builder
+	structuredData.WriteString("[" + syslogStructuredDataId)
+	for _, param := range [][2]string{
+		{"id", fmt.Sprint(event.Id)},
+		{"actor", event.Actor},
+		{"target", event.Target},
+		{"ip", event.IP},
+		{"requestId", event.RequestId},
+	} {
+		fmt.Fprintf(&structuredData, ` %s="%s"`, param[0], syslogParamEscaper.Replace(param[1]))
+	}
+	structuredData.WriteString("]")
+
+	header := fmt.Sprintf("<%d>1 %s %s %s %d %s %s \ufeff",
+		syslogFacilityAuthpriv*8+severity,
+		event.At.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderField(sink.hostname, 255),
+		syslogHeaderField(sink.appName, 48),
+		os.Getpid(),
+		syslogHeaderField(event.Action, 32),
+		structuredData.String(),
+	)
+
+	return append([]byte(header), body...), nil
+}
+
+// syslogHeaderField keeps a header field within its length and printable ASCII
+// without spaces, as RFC 5424 requires. Empty fields become the nil value "-".
+func syslogHeaderField(value string, maxLength int) string {
+	field := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+
+	if len(field) > maxLength {
+		field = field[:maxLength]
+	}
+	if field == "" {
+		return "-"
+	}
+
+	return field
+}
@@ -1,18 +1,32 @@
 package repositories
 
 import (
+	"auth/internal/core/domain/models"
+	"bytes"
 	"context"
-	"fmt"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	"io"
 	"log"
+	"mime"
+	"net/url"
 	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type FileStorage struct {
 	client   *minio.Client
 	endpoint string
+	// presigner signs URLs for the host clients reach MinIO at, which differs from
+	// ENDPOINT when the service talks to MinIO over an internal network.
+	presigner *minio.Client
+	// staging holds resumable uploads until they are complete.
+	staging      string
+	stagingReady atomic.Bool
 }
 
 func NewFileStorage() *FileStorage {
@@ -30,9 +44,40 @@ func NewFileStorage() *FileStorage {
 		log.Fatal(err)
 	}
 
+	presigner := client
+	if PUBLIC_ENDPOINT, ok := os.LookupEnv("MINIO_PUBLIC_ENDPOINT"); ok && PUBLIC_ENDPOINT != "" {
+		publicURL, err := url.Parse(PUBLIC_ENDPOINT)
+		if err != nil || publicURL.Host == "" {
+			log.Fatalf("MINIO_PUBLIC_ENDPOINT must be a URL such as https://files.example.com, got %q", PUBLIC_ENDPOINT)
+		}
+
+		// Signing needs the bucket region; setting it avoids asking a host the
+		// service may not be able to reach.
+		REGION, ok := os.LookupEnv("MINIO_REGION")
+		if !ok {
+			REGION = "us-east-1"
+		}
+
+		presigner, err = minio.New(publicURL.Host, &minio.Options{
+			Creds:  opts.Creds,
+			Secure: publicURL.Scheme == "https",
+			Region: REGION,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	STAGING_BUCKET, ok := os.LookupEnv("TUS_STAGING_BUCKET")
+	if !ok {
+		STAGING_BUCKET = "tus-staging"
+	}
+
 	return &FileStorage{
-		client:   client,
-		endpoint: ENDPOINT,
+		client:    client,
+		endpoint:  ENDPOINT,
+		presigner: presigner,
+		staging:   STAGING_BUCKET,
 	}
 }
 
@@ -60,7 +105,7 @@ func (storage *FileStorage) RemoveBucket(ctx context.Context, bucketName string)
 }
 
 func (storage *FileStorage) RemoveObjects(ctx context.Context, bucketName string) error {
-	listObjectOpts := minio.ListObjectsOptions{}
+	listObjectOpts := minio.ListObjectsOptions{Recursive: true}
 	removeObjectsOpts := minio.RemoveObjectsOptions{}
 
 	objects := storage.client.ListObjects(ctx, bucketName, listObjectOpts)
@@ -90,20 +135,25 @@ func (storage *FileStorage) UploadFile(ctx context.Context, bucketName, fileName
 	return nil
 }
 
-func (storage *FileStorage) DownloadFile(ctx context.Context, bucketName, fileName, path string) error {
+// OpenFile returns the body of an object, to be closed by the caller, and its metadata.
+// Only the metadata is fetched up front. Reads issue a ranged GetObject from the
+// current offset, pinned to the ETag seen here, so seeking serves byte ranges
+// and fails rather than mixing two versions of the object.
+func (storage *FileStorage) OpenFile(ctx context.Context, bucketName, fileName string) (io.ReadSeekCloser, minio.ObjectInfo, error) {
 	opts := minio.GetObjectOptions{}
 
-	_, err := storage.client.GetObject(ctx, bucketName, fileName, opts)
+	object, err := storage.client.GetObject(ctx, bucketName, fileName, opts)
 	if err != nil {
-		return err
+		return nil, minio.ObjectInfo{}, err
 	}
 
-	err = storage.client.FGetObject(ctx, bucketName, fileName, fmt.Sprintf("%s/%s", path, fileName), opts)
+	objectStat, err := object.Stat()
 	if err != nil {
-		return err
+		object.Close()
+		return nil, minio.ObjectInfo{}, err
 	}
 
-	return nil
+	return object, objectStat, nil
 }
 
 func (storage *FileStorage) DeleteFile(ctx context.Context, bucketName, fileName string) error {
@@ -133,14 +183,149 @@ func (storage *FileStorage) GetFile(ctx context.Context, bucketName, fileName st
 	return objectStat, nil
 }
 
-func (storage *FileStorage) GetFileList(ctx context.Context, bucketName string) []string {
-	opts := minio.ListObjectsOptions{}
+// GetFileList returns the names of all files of a bucket, in folders too, without
+// folder markers; a missing bucket holds none. It fails when listing fails, so
+// that no caller takes part of a bucket for all of it.
+func (storage *FileStorage) GetFileList(ctx context.Context, bucketName string) ([]string, error) {
+	opts := minio.ListObjectsOptions{Recursive: true}
 
 	list := make([]string, 0)
 
 	for object := range storage.client.ListObjects(ctx, bucketName, opts) {
+		if object.Err != nil {
+			if minio.ToErrorResponse(object.Err).Code == "NoSuchBucket" {
+				return make([]string, 0), nil
+			}
+			return nil, object.Err
+		}
+		if strings.HasSuffix(object.Key, "/") {
+			continue
+		}
 		list = append(list, object.Key)
 	}
 
-	return list
+	return list, nil
+}
+
+// ListFiles returns one page of up to 1000 entries of a bucket in key order, and
+// the token of the next page or "" after the last. With a delimiter, keys sharing
+// the part up to it after prefix are grouped into one folder entry. Folder markers
+// are listed as folders, including the one named prefix itself. Listings carry
+// no metadata, so content types are derived from the extension, which stored
+// files are checked to match.
+func (storage *FileStorage) ListFiles(ctx context.Context, bucketName, prefix, delimiter, startAfter, continuationToken string) ([]models.FileInfo, string, error) {
+	core := minio.Core{Client: storage.client}
+
+	result, err := core.ListObjectsV2(bucketName, prefix, startAfter, continuationToken, delimiter, 1000)
+	if minio.ToErrorResponse(err).Code == "NoSuchBucket" {
+		return make([]models.FileInfo, 0), "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	files := make([]models.FileInfo, 0, len(result.Contents)+len(result.CommonPrefixes))
+	for _, object := range result.Contents {
+		if strings.HasSuffix(object.Key, "/") {
+			files = append(files, models.FileInfo{Name: object.Key, Folder: true, LastModified: object.LastModified})
+			continue
+		}
+		contentType, _, _ := strings.Cut(mime.TypeByExtension(path.Ext(object.Key)), ";")
+		files = append(files, models.FileInfo{
+			Name:         object.Key,
+			Size:         object.Size,
+			ContentType:  contentType,
+			LastModified: object.LastModified,
+			ETag:         strings.Trim(object.ETag, `"`),
+		})
+	}
+	for _, folder := range result.CommonPrefixes {
+		files = append(files, models.FileInfo{Name: folder.Prefix, Folder: true})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	if !result.IsTruncated {
+		return files, "", nil
+	}
+
+	return files, result.NextContinuationToken, nil
+}
+
+// BucketUsage counts the bytes and files in a bucket, not counting folder markers;
+// a missing bucket holds none.
+func (storage *FileStorage) BucketUsage(ctx context.Context, bucketName string) (int64, int64, error) {
+	opts := minio.ListObjectsOptions{Recursive: true}
+
+	var bytes, objects int64
+	for object := range storage.client.ListObjects(ctx, bucketName, opts) {
+		if object.Err != nil {
+			if minio.ToErrorResponse(object.Err).Code == "NoSuchBucket" {
+				return 0, 0, nil
+			}
+			return 0, 0, object.Err
+		}
+		if strings.HasSuffix(object.Key, "/") {
+			continue
+		}
+		bytes += object.Size
+		objects++
+	}
+
+	return bytes, objects, nil
+}
+
+// CreateFolder stores the empty marker object that keeps a folder, whose name ends
+// with "/", listed while it holds no files.
+func (storage *FileStorage) CreateFolder(ctx context.Context, bucketName, folder string) error {
+	opts := minio.PutObjectOptions{ContentType: "application/x-directory"}
+
+	_, err := storage.client.PutObject(ctx, bucketName, folder, bytes.NewReader(nil), 0, opts)
+
+	return err
+}
+
+// CopyFile copies an object to another name in the same bucket without the data
+// leaving MinIO, keeping its content type and metadata. Objects of up to 5 GiB can
+// be copied this way.
+func (storage *FileStorage) CopyFile(ctx context.Context, bucketName, source, destination string) error {
+	src := minio.CopySrcOptions{Bucket: bucketName, Object: source}
+	dst := minio.CopyDestOptions{Bucket: bucketName, Object: destination}
+
+	_, err := storage.client.CopyObject(ctx, dst, src)
+
+	return err
+}
+
+// RemoveFiles deletes objects of a bucket with batched requests.
+func (storage *FileStorage) RemoveFiles(ctx context.Context, bucketName string, fileNames []string) error {
+	objects := make(chan minio.ObjectInfo)
+
+	go func() {
+		defer close(objects)
+		for _, name := range fileNames {
+			select {
+			case objects <- minio.ObjectInfo{Key: name}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var err error
+	for removeErr := range storage.client.RemoveObjects(ctx, bucketName, objects, minio.RemoveObjectsOptions{}) {
+		if removeErr.Err != nil && err == nil {
+			err = removeErr.Err
+		}
+	}
+
+	return err
+}
+
+// PresignGet returns a URL that downloads one object as an attachment until expiry.
+func (storage *FileStorage) PresignGet(ctx context.Context, bucketName, fileName, disposition string, expiry time.Duration) (*url.URL, error) {
+	params := url.Values{}
+	params.Set("response-content-disposition", disposition)
+
+	return storage.presigner.PresignedGetObject(ctx, bucketName, fileName, expiry, params)
 }
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"fmt"
+	"github.com/oschwald/maxminddb-golang"
+	"net"
+)
+
+// geoRecord is the part of a MaxMind GeoIP2/GeoLite2 City or Country record the
+// risk engine uses. Country databases have no city and location.
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+// GeoIPDatabase locates IPs with an offline MaxMind MMDB file.
+type GeoIPDatabase struct {
+	reader *maxminddb.Reader
+}
+
+func OpenGeoIPDatabase(path string) (*GeoIPDatabase, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GeoIPDatabase{reader: reader}, nil
+}
+
+func (database *GeoIPDatabase) Locate(ip string) (models.GeoLocation, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return models.GeoLocation{}, fmt.Errorf("%q is not an IP address", ip)
+	}
+
+	var record geoRecord
+	err := database.reader.Lookup(parsed, &record)
+	if err != nil {
+		return models.GeoLocation{}, err
+	}
+
+	location := models.GeoLocation{
+		Country: record.Country.ISOCode,
+		City:    record.City.Names["en"],
+	}
+
+	if record.Location.Latitude != nil && record.Location.Longitude != nil {
+		location.Latitude, location.Longitude = *record.Location.Latitude, *record.Location.Longitude
+		location.HasCoordinates = true
+	}
+
+	return location, nil
+}
+
+func (database *GeoIPDatabase) Close() error {
+	return database.reader.Close()
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"database/sql"
+	"sort"
+)
+
+type GroupsRepository struct {
+	db *sql.DB
+}
+
+func NewGroupsRepository(db *sql.DB) *GroupsRepository {
+	return &GroupsRepository{db: db}
+}
+
+func (repository *GroupsRepository) GetGroupByName(name string) (models.Group, error) {
+	var dbData models.Group
+
+	err := repository.db.QueryRow("SELECT group_id, group_name FROM user_group WHERE group_name = $1", name).Scan(&dbData.Id, &dbData.Name)
+
+	return dbData, err
+}
+
+func (repository *GroupsRepository) GetGroupMembers(groupId string) ([]string, error) {
+	var members []string
+
+	query := "SELECT profile_login FROM group_profile INNER JOIN profile ON group_profile.profile_id = profile.profile_id WHERE group_id = $1"
+
+	rows, err := repository.db.Query(query, groupId)
+	if err != nil {
+		return members, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var login string
+		err = rows.Scan(&login)
+		if err != nil {
+			return members, err
+		}
+		members = append(members, login)
+	}
+
+	sort.Strings(members)
+
+	return members, nil
+}
+
+func (repository *GroupsRepository) GetGroupRoles(groupId string) ([]string, error) {
+	var roles []string
+
+	query := "SELECT role_name FROM group_role INNER JOIN role ON group_role.role_id = role.role_id WHERE group_id = $1"
+
+	rows, err := repository.db.Query(query, groupId)
+	if err != nil {
+		return roles, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role string
+		err = rows.Scan(&role)
+		if err != nil {
+			return roles, err
+		}
+		roles = append(roles, role)
+	}
+
+	sort.Strings(roles)
+
+	return roles, nil
+}
+
+func (repository *GroupsRepository) CreateGroup(name string) error {
+	_, err := repository.db.Exec("INSERT INTO user_group (group_name) VALUES ($1)", name)
+
+	return err
+}
+
+func (repository *GroupsRepository) RenameGroup(groupId, newName string) error {
+	_, err := repository.db.Exec("UPDATE user_group SET group_name = $1 WHERE group_id = $2", newName, groupId)
+
+	return err
+}
+
+func (repository *GroupsRepository) DeleteGroup(groupId string) error {
+	_, err := repository.db.Exec("DELETE FROM user_group WHERE group_id = $1", groupId)
+
+	return err
+}
+
+func (repository *GroupsRepository) AddMember(groupId, profileId string) error {
+	_, err := repository.db.Exec("INSERT INTO group_profile (group_id, profile_id) VALUES ($1, $2)", groupId, profileId)
+
+	return err
+}
+
+func (repository *GroupsRepository) RemoveMember(groupId, profileId string) error {
+	_, err := repository.db.Exec("DELETE FROM group_profile WHERE group_id = $1 AND profile_id = $2", groupId, profileId)
+
+	return err
+}
+
+func (repository *GroupsRepository) AddRole(groupId, roleId string) error {
+	_, err := repository.db.Exec("INSERT INTO group_role (group_id, role_id) VALUES ($1, $2)", groupId, roleId)
+
+	return err
+}
+
+func (repository *GroupsRepository) RemoveRole(groupId, roleId string) error {
+	_, err := repository.db.Exec("DELETE FROM group_role WHERE group_id = $1 AND role_id = $2", groupId, roleId)
+
+	return err
+}
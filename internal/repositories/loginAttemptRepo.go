@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// LoginAttemptsRepository keeps failed login counters in Postgres, so they are
+// shared by every instance of the service.
+type LoginAttemptsRepository struct {
+	db *sql.DB
+}
+
+func NewLoginAttemptsRepository(db *sql.DB) *LoginAttemptsRepository {
+	return &LoginAttemptsRepository{db: db}
+}
+
+func (repository *LoginAttemptsRepository) GetAttempts(key string) (models.LoginAttempts, error) {
+	var attempts models.LoginAttempts
+	var blockedUntil sql.NullTime
+
+	query := "SELECT attempt_failures, attempt_last_failure, attempt_blocked_until FROM login_attempt WHERE attempt_key = $1"
+
+	err := repository.db.QueryRow(query, key).Scan(&attempts.Failures, &attempts.LastFailure, &blockedUntil)
+	if err == sql.ErrNoRows {
+		return models.LoginAttempts{}, nil
+	}
+	if err != nil {
+		return models.LoginAttempts{}, err
+	}
+
+	attempts.BlockedUntil = blockedUntil.Time
+
+	return attempts, nil
+}
+
+func (repository *LoginAttemptsRepository) RecordFailure(key string, at time.Time, window time.Duration) (models.LoginAttempts, error) {
+	var attempts models.LoginAttempts
+	var blockedUntil sql.NullTime
+
+	query := "INSERT INTO login_attempt (attempt_key, attempt_failures, attempt_last_failure) VALUES ($1, 1, $2) " +
+		"ON CONFLICT (attempt_key) DO UPDATE SET " +
+		"attempt_failures = CASE WHEN login_attempt.attempt_last_failure < $3 THEN 1 ELSE login_attempt.attempt_failures + 1 END, " +
+		"attempt_last_failure = $2 " +
+		"RETURNING attempt_failures, attempt_last_failure, attempt_blocked_until"
+
+	err := repository.db.QueryRow(query, key, at, at.Add(-window)).Scan(&attempts.Failures, &attempts.LastFailure, &blockedUntil)
+	if err != nil {
+		return models.LoginAttempts{}, err
+	}
+
+	attempts.BlockedUntil = blockedUntil.Time
+
+	return attempts, nil
+}
+
+func (repository *LoginAttemptsRepository) Block(key string, until time.Time) error {
+	_, err := repository.db.Exec("UPDATE login_attempt SET attempt_blocked_until = $1 WHERE attempt_key = $2", until, key)
+
+	return err
+}
+
+func (repository *LoginAttemptsRepository) ResetAttempts(key string) error {
+	_, err := repository.db.Exec("DELETE FROM login_attempt WHERE attempt_key = $1", key)
+
+	return err
+}
+
+// RemoveStale deletes counters that are not blocked and whose last failure is
+// older than window. They would start over on the next failure anyway, so
+// forgetting them changes nothing.
+func (repository *LoginAttemptsRepository) RemoveStale(window time.Duration) error {
+	now := time.Now()
+
+	query := "DELETE FROM login_attempt WHERE attempt_last_failure < $1 " +
+		"AND (attempt_blocked_until IS NULL OR attempt_blocked_until < $2)"
+
+	_, err := repository.db.Exec(query, now.Add(-window), now)
+
+	return err
+}
+
+// RunCleanup calls RemoveStale every hour until ctx is done.
+func (repository *LoginAttemptsRepository) RunCleanup(ctx context.Context, window time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := repository.RemoveStale(window)
+		if err != nil {
+			log.Printf("Removing stale login attempts failed: %v", err)
+		}
+	}
+}
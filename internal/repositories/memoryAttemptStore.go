@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"sync"
+	"time"
+)
+
+// memoryAttemptSweepSize is the number of tracked keys after which stale ones are dropped.
+const memoryAttemptSweepSize = 10000
+
+// MemoryAttemptStore keeps failed login counters in process memory. It suits
+// single node deployments, counters are lost on restart.
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempts
+	window   time.Duration
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{attempts: make(map[string]models.LoginAttempts)}
+}
+
+func (store *MemoryAttemptStore) GetAttempts(key string) (models.LoginAttempts, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.attempts[key], nil
+}
+
+func (store *MemoryAttemptStore) RecordFailure(key string, at time.Time, window time.Duration) (models.LoginAttempts, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.window = window
+	if len(store.attempts) >= memoryAttemptSweepSize {
+		store.sweep(at)
+	}
+
+	attempts := store.attempts[key]
+	if attempts.LastFailure.Before(at.Add(-window)) {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailure = at
+	store.attempts[key] = attempts
+
+	return attempts, nil
+}
+
+func (store *MemoryAttemptStore) Block(key string, until time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	attempts, ok := store.attempts[key]
+	if ok {
+		attempts.BlockedUntil = until
+		store.attempts[key] = attempts
+	}
+
+	return nil
+}
+
+func (store *MemoryAttemptStore) ResetAttempts(key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.attempts, key)
+
+	return nil
+}
+
+// sweep drops keys that are neither blocked nor have failures inside the window.
+// It must be called with mu held.
+func (store *MemoryAttemptStore) sweep(now time.Time) {
+	for key, attempts := range store.attempts {
+		if attempts.BlockedUntil.Before(now) && attempts.LastFailure.Before(now.Add(-store.window)) {
+			delete(store.attempts, key)
+		}
+	}
+}
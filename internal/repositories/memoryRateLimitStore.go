@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"sync"
+	"time"
+)
+
+// memoryRateLimitSweepSize is the number of tracked buckets after which full ones are dropped.
+const memoryRateLimitSweepSize = 100000
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+// MemoryRateLimitStore keeps token buckets in process memory, so every instance
+// of the service limits requests on its own.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]tokenBucket
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]tokenBucket)}
+}
+
+func (store *MemoryRateLimitStore) Take(key string, capacity int, period time.Duration) (models.RateLimitState, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	rate := float64(capacity) / period.Seconds()
+
+	if len(store.buckets) >= memoryRateLimitSweepSize {
+		for bucketKey, bucket := range store.buckets {
+			if now.After(bucket.fullAt) {
+				delete(store.buckets, bucketKey)
+			}
+		}
+	}
+
+	bucket, ok := store.buckets[key]
+	if !ok {
+		bucket = tokenBucket{tokens: float64(capacity), updatedAt: now}
+	}
+
+	bucket.tokens = min(float64(capacity), bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*rate)
+	bucket.updatedAt = now
+
+	state := models.RateLimitState{Allowed: bucket.tokens >= 1}
+	if state.Allowed {
+		bucket.tokens--
+	}
+	state.Tokens = bucket.tokens
+
+	bucket.fullAt = now.Add(time.Duration((float64(capacity) - bucket.tokens) / rate * float64(time.Second)))
+	store.buckets[key] = bucket
+
+	return state, nil
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+
+	take := func(key string, want bool) {
+		t.Helper()
+		state, err := store.Take(key, 2, time.Minute)
+		if err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+		if state.Allowed != want {
+			t.Fatalf("Take(%q) allowed = %v, want %v", key, state.Allowed, want)
+		}
+	}
+	rewind := func(key string, by time.Duration) {
+		bucket := store.buckets[key]
+		bucket.updatedAt = bucket.updatedAt.Add(-by)
+		store.buckets[key] = bucket
+	}
+
+	// A new bucket starts full.
+	take("a", true)
+	take("a", true)
+	take("a", false)
+	take("b", true)
+
+	// Two tokens a minute come back one every 30 seconds.
+	rewind("a", 29*time.Second)
+	take("a", false)
+	rewind("a", 2*time.Second)
+	take("a", true)
+	take("a", false)
+
+	// A bucket never holds more than its capacity.
+	rewind("a", time.Hour)
+	take("a", true)
+	take("a", true)
+	take("a", false)
+}
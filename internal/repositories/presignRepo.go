@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"database/sql"
+	"time"
+)
+
+const presignColumns = "presign_id, profile_id, presign_file_name, presign_content_type, presign_size, presign_created_at, presign_expires_at"
+
+// PresignedUploadsRepository keeps presigned uploads until their URLs can no longer
+// be used, confirmed or not.
+type PresignedUploadsRepository struct {
+	db *sql.DB
+}
+
+func NewPresignedUploadsRepository(db *sql.DB) *PresignedUploadsRepository {
+	return &PresignedUploadsRepository{db: db}
+}
+
+func (repository *PresignedUploadsRepository) CreatePresignedUpload(upload models.PresignedUpload) error {
+	query := "INSERT INTO presigned_upload (" + presignColumns + ") VALUES ($1, $2, $3, $4, $5, $6, $7)"
+
+	_, err := repository.db.Exec(query, upload.Id, upload.ProfileId, upload.FileName, upload.ContentType, upload.Size,
+		upload.CreatedAt, upload.ExpiresAt)
+
+	return err
+}
+
+// ClaimPresignedUpload marks a presigned upload of a profile that expired after
+// after as confirmed and returns it. Only one confirmation can claim an upload.
+func (repository *PresignedUploadsRepository) ClaimPresignedUpload(id, profileId string, after time.Time) (models.PresignedUpload, error) {
+	query := `UPDATE presigned_upload SET presign_confirmed_at = now()
+		WHERE presign_id = $1 AND profile_id = $2 AND presign_confirmed_at IS NULL AND presign_expires_at > $3
+		RETURNING ` + presignColumns
+
+	rows, err := repository.db.Query(query, id, profileId, after)
+	if err != nil {
+		return models.PresignedUpload{}, err
+	}
+
+	uploads, err := scanPresignedUploads(rows)
+	if err != nil {
+		return models.PresignedUpload{}, err
+	}
+	if len(uploads) == 0 {
+		return models.PresignedUpload{}, sql.ErrNoRows
+	}
+
+	return uploads[0], nil
+}
+
+// ReleasePresignedUpload lets an upload whose confirmation failed be confirmed again.
+func (repository *PresignedUploadsRepository) ReleasePresignedUpload(id string) error {
+	_, err := repository.db.Exec("UPDATE presigned_upload SET presign_confirmed_at = NULL WHERE presign_id = $1", id)
+
+	return err
+}
+
+func (repository *PresignedUploadsRepository) DeletePresignedUpload(id string) error {
+	_, err := repository.db.Exec("DELETE FROM presigned_upload WHERE presign_id = $1", id)
+
+	return err
+}
+
+func (repository *PresignedUploadsRepository) GetExpiredPresignedUploads(before time.Time) ([]models.PresignedUpload, error) {
+	rows, err := repository.db.Query("SELECT "+presignColumns+" FROM presigned_upload WHERE presign_expires_at < $1", before)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanPresignedUploads(rows)
+}
+
+func scanPresignedUploads(rows *sql.Rows) ([]models.PresignedUpload, error) {
+	defer rows.Close()
+
+	uploads := make([]models.PresignedUpload, 0)
+	for rows.Next() {
+		var upload models.PresignedUpload
+
+		err := rows.Scan(&upload.Id, &upload.ProfileId, &upload.FileName, &upload.ContentType, &upload.Size,
+			&upload.CreatedAt, &upload.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, rows.Err()
+}
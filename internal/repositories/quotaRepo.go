@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"database/sql"
+)
+
+type QuotaRepository struct {
+	db *sql.DB
+}
+
+func NewQuotaRepository(db *sql.DB) *QuotaRepository {
+	return &QuotaRepository{db: db}
+}
+
+// GetQuota returns the usage and overrides of a profile, nothing for one that never stored a file.
+func (repository *QuotaRepository) GetQuota(profileId string) (models.StorageQuota, models.QuotaOverride, error) {
+	var quota models.StorageQuota
+	var override models.QuotaOverride
+	var maxBytes, maxObjects sql.NullInt64
+	var reconciledAt sql.NullTime
+
+	query := "SELECT quota_used_bytes, quota_used_objects, quota_max_bytes, quota_max_objects, quota_reconciled_at " +
+		"FROM storage_quota WHERE profile_id = $1"
+
+	err := repository.db.QueryRow(query, profileId).Scan(&quota.UsedBytes, &quota.UsedObjects, &maxBytes, &maxObjects, &reconciledAt)
+	if err == sql.ErrNoRows {
+		return quota, override, nil
+	}
+	if err != nil {
+		return quota, override, err
+	}
+
+	if maxBytes.Valid {
+		override.MaxBytes = &maxBytes.Int64
+	}
+	if maxObjects.Valid {
+		override.MaxObjects = &maxObjects.Int64
+	}
+	quota.ReconciledAt = reconciledAt.Time
+
+	return quota, override, nil
+}
+
+// ReserveQuota adds to the usage of a profile unless that breaks its limits: the
+// overrides of the profile or else maxBytes and maxObjects, where 0 is unlimited.
+// It reports whether the usage was added.
+func (repository *QuotaRepository) ReserveQuota(profileId string, bytes, objects, maxBytes, maxObjects int64) (bool, error) {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO storage_quota (profile_id) VALUES ($1) ON CONFLICT DO NOTHING", profileId)
+	if err != nil {
+		return false, err
+	}
+
+	query := "UPDATE storage_quota SET quota_used_bytes = quota_used_bytes + $2, quota_used_objects = quota_used_objects + $3 " +
+		"WHERE profile_id = $1 " +
+		"AND (COALESCE(quota_max_bytes, $4) = 0 OR quota_used_bytes + $2 <= COALESCE(quota_max_bytes, $4)) " +
+		"AND (COALESCE(quota_max_objects, $5) = 0 OR quota_used_objects + $3 <= COALESCE(quota_max_objects, $5))"
+
+	result, err := tx.Exec(query, profileId, bytes, objects, maxBytes, maxObjects)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, tx.Commit()
+}
+
+func (repository *QuotaRepository) ReleaseQuota(profileId string, bytes, objects int64) error {
+	query := "UPDATE storage_quota SET quota_used_bytes = GREATEST(quota_used_bytes - $2, 0), " +
+		"quota_used_objects = GREATEST(quota_used_objects - $3, 0) WHERE profile_id = $1"
+
+	_, err := repository.db.Exec(query, profileId, bytes, objects)
+
+	return err
+}
+
+func (repository *QuotaRepository) SetQuotaOverride(profileId string, override models.QuotaOverride) error {
+	query := "INSERT INTO storage_quota (profile_id, quota_max_bytes, quota_max_objects) VALUES ($1, $2, $3) " +
+		"ON CONFLICT (profile_id) DO UPDATE SET quota_max_bytes = $2, quota_max_objects = $3"
+
+	_, err := repository.db.Exec(query, profileId, override.MaxBytes, override.MaxObjects)
+
+	return err
+}
+
+// SetQuotaUsage replaces the tracked usage of a profile with a counted one.
+func (repository *QuotaRepository) SetQuotaUsage(profileId string, bytes, objects int64) error {
+	query := "INSERT INTO storage_quota (profile_id, quota_used_bytes, quota_used_objects, quota_reconciled_at) VALUES ($1, $2, $3, now()) " +
+		"ON CONFLICT (profile_id) DO UPDATE SET quota_used_bytes = $2, quota_used_objects = $3, quota_reconciled_at = now()"
+
+	_, err := repository.db.Exec(query, profileId, bytes, objects)
+
+	return err
+}
+
+// ListAccounts returns the id and login of every account that was not unregistered.
+func (repository *QuotaRepository) ListAccounts() ([]models.User, error) {
+	rows, err := repository.db.Query("SELECT profile_id, profile_login FROM profile WHERE profile_deleted_at IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var user models.User
+		err = rows.Scan(&user.Id, &user.Login)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
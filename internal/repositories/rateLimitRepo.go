@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// RateLimitRepository keeps token buckets in Postgres, so limits are shared by
+// every instance of the service.
+type RateLimitRepository struct {
+	db *sql.DB
+}
+
+func NewRateLimitRepository(db *sql.DB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+// Take refills the bucket of key and takes a token from it in one statement, so
+// concurrent requests from different instances can not spend the same token.
+func (repository *RateLimitRepository) Take(key string, capacity int, period time.Duration) (models.RateLimitState, error) {
+	var state models.RateLimitState
+
+	refilled := "LEAST($2, rate_limit.limit_tokens + EXTRACT(EPOCH FROM (now() - rate_limit.limit_updated_at)) * $3)"
+
+	query := "INSERT INTO rate_limit (limit_key, limit_tokens, limit_allowed, limit_updated_at) VALUES ($1, $2 - 1, true, now()) " +
+		"ON CONFLICT (limit_key) DO UPDATE SET " +
+		"limit_tokens = CASE WHEN " + refilled + " >= 1 THEN " + refilled + " - 1 ELSE " + refilled + " END, " +
+		"limit_allowed = " + refilled + " >= 1, " +
+		"limit_updated_at = now() " +
+		"RETURNING limit_allowed, limit_tokens"
+
+	rate := float64(capacity) / period.Seconds()
+
+	err := repository.db.QueryRow(query, key, float64(capacity), rate).Scan(&state.Allowed, &state.Tokens)
+
+	return state, err
+}
+
+// RemoveIdle deletes buckets untouched for longer than idle. A bucket refills
+// completely within its period, so forgetting it afterwards changes nothing.
+func (repository *RateLimitRepository) RemoveIdle(idle time.Duration) error {
+	_, err := repository.db.Exec("DELETE FROM rate_limit WHERE limit_updated_at < $1", time.Now().Add(-idle))
+
+	return err
+}
+
+// RunCleanup calls RemoveIdle every hour until ctx is done.
+func (repository *RateLimitRepository) RunCleanup(ctx context.Context, idle time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := repository.RemoveIdle(idle)
+		if err != nil {
+			log.Printf("Removing idle rate limit buckets failed: %v", err)
+		}
+	}
+}
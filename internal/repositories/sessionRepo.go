@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"database/sql"
+	"time"
+)
+
+const sessionColumns = "session_id, profile_id, session_ip, session_user_agent, session_device, " +
+	"session_country, session_city, session_latitude, session_longitude, session_risk_score, session_risk_action, " +
+	"session_created_at, session_expires_at, session_revoked_at"
+
+type SessionsRepository struct {
+	db *sql.DB
+}
+
+func NewSessionsRepository(db *sql.DB) *SessionsRepository {
+	return &SessionsRepository{db: db}
+}
+
+func (repository *SessionsRepository) CreateSession(session models.Session) (string, error) {
+	var id string
+
+	var latitude, longitude sql.NullFloat64
+	if session.Location.HasCoordinates {
+		latitude = sql.NullFloat64{Float64: session.Location.Latitude, Valid: true}
+		longitude = sql.NullFloat64{Float64: session.Location.Longitude, Valid: true}
+	}
+
+	query := "INSERT INTO session (profile_id, session_ip, session_user_agent, session_device, " +
+		"session_country, session_city, session_latitude, session_longitude, session_risk_score, session_risk_action, " +
+		"session_created_at, session_expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING session_id"
+
+	err := repository.db.QueryRow(query, session.ProfileId, session.IP, session.UserAgent, session.Device,
+		session.Location.Country, session.Location.City, latitude, longitude, session.RiskScore, session.RiskAction,
+		session.CreatedAt, session.ExpiresAt).Scan(&id)
+
+	return id, err
+}
+
+func (repository *SessionsRepository) GetSession(id string) (models.Session, error) {
+	rows, err := repository.db.Query("SELECT "+sessionColumns+" FROM session WHERE session_id = $1", id)
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	sessions, err := scanSessions(rows)
+	if err != nil {
+		return models.Session{}, err
+	}
+	if len(sessions) == 0 {
+		return models.Session{}, sql.ErrNoRows
+	}
+
+	return sessions[0], nil
+}
+
+func (repository *SessionsRepository) GetSessions(profileId string) ([]models.Session, error) {
+	rows, err := repository.db.Query("SELECT "+sessionColumns+" FROM session WHERE profile_id = $1 ORDER BY session_created_at DESC", profileId)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSessions(rows)
+}
+
+func (repository *SessionsRepository) RevokeSession(id string) error {
+	_, err := repository.db.Exec("UPDATE session SET session_revoked_at = now() WHERE session_id = $1 AND session_revoked_at IS NULL", id)
+
+	return err
+}
+
+func (repository *SessionsRepository) DeleteSessionsBefore(before time.Time) (int64, error) {
+	result, err := repository.db.Exec("DELETE FROM session WHERE session_expires_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func scanSessions(rows *sql.Rows) ([]models.Session, error) {
+	defer rows.Close()
+
+	sessions := make([]models.Session, 0)
+	for rows.Next() {
+		var session models.Session
+		var latitude, longitude sql.NullFloat64
+		var revokedAt sql.NullTime
+
+		err := rows.Scan(&session.Id, &session.ProfileId, &session.IP, &session.UserAgent, &session.Device,
+			&session.Location.Country, &session.Location.City, &latitude, &longitude, &session.RiskScore, &session.RiskAction,
+			&session.CreatedAt, &session.ExpiresAt, &revokedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		session.Location.Latitude, session.Location.Longitude = latitude.Float64, longitude.Float64
+		session.Location.HasCoordinates = latitude.Valid && longitude.Valid
+		session.RevokedAt = revokedAt.Time
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
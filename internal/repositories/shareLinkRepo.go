@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"database/sql"
+)
+
+const shareLinkColumns = "link_id, share_link.owner_id, profile_login, link_path, link_token_hash, COALESCE(link_password_hash, ''), " +
+	"link_expires_at, COALESCE(link_max_downloads, 0), link_downloads, link_last_accessed_at, link_created_at, link_revoked_at " +
+	"FROM share_link INNER JOIN profile ON share_link.owner_id = profile.profile_id "
+
+// usableLink matches links that are not revoked, expired or used up.
+const usableLink = "link_revoked_at IS NULL AND (link_expires_at IS NULL OR link_expires_at > now()) " +
+	"AND (link_max_downloads IS NULL OR link_downloads < link_max_downloads)"
+
+type ShareLinksRepository struct {
+	db *sql.DB
+}
+
+func NewShareLinksRepository(db *sql.DB) *ShareLinksRepository {
+	return &ShareLinksRepository{db: db}
+}
+
+func (repository *ShareLinksRepository) CreateLink(link models.ShareLink) (models.ShareLink, error) {
+	query := "INSERT INTO share_link (owner_id, link_path, link_token_hash, link_password_hash, link_expires_at, link_max_downloads) " +
+		"VALUES ($1, $2, $3, $4, $5, $6) RETURNING link_id, link_created_at"
+
+	passwordHash := sql.NullString{String: link.PasswordHash, Valid: link.PasswordHash != ""}
+	expiresAt := sql.NullTime{Time: link.ExpiresAt, Valid: !link.ExpiresAt.IsZero()}
+	maxDownloads := sql.NullInt64{Int64: link.MaxDownloads, Valid: link.MaxDownloads > 0}
+
+	err := repository.db.QueryRow(query, link.OwnerId, link.Path, link.TokenHash, passwordHash, expiresAt, maxDownloads).
+		Scan(&link.Id, &link.CreatedAt)
+
+	return link, err
+}
+
+func (repository *ShareLinksRepository) GetLink(id string) (models.ShareLink, error) {
+	return repository.getLink("SELECT "+shareLinkColumns+"WHERE link_id = $1", id)
+}
+
+// GetLinkByToken finds a link by the hash of its token. Links of unregistered
+// accounts are not found.
+func (repository *ShareLinksRepository) GetLinkByToken(tokenHash string) (models.ShareLink, error) {
+	return repository.getLink("SELECT "+shareLinkColumns+"WHERE link_token_hash = $1 AND profile_deleted_at IS NULL", tokenHash)
+}
+
+func (repository *ShareLinksRepository) getLink(query, parameter string) (models.ShareLink, error) {
+	rows, err := repository.db.Query(query, parameter)
+	if err != nil {
+		return models.ShareLink{}, err
+	}
+
+	links, err := scanShareLinks(rows)
+	if err != nil {
+		return models.ShareLink{}, err
+	}
+	if len(links) == 0 {
+		return models.ShareLink{}, sql.ErrNoRows
+	}
+
+	return links[0], nil
+}
+
+// GetActiveLinks returns the links of an owner that still serve downloads, newest first.
+func (repository *ShareLinksRepository) GetActiveLinks(ownerId string) ([]models.ShareLink, error) {
+	query := "SELECT " + shareLinkColumns + "WHERE share_link.owner_id = $1 AND " + usableLink + " ORDER BY link_created_at DESC"
+
+	rows, err := repository.db.Query(query, ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanShareLinks(rows)
+}
+
+// ClaimDownload counts a download of a link and tells whether the link allowed
+// it. The check and the count are one statement, so concurrent downloads cannot
+// together pass the limit.
+func (repository *ShareLinksRepository) ClaimDownload(id string) (bool, error) {
+	query := "UPDATE share_link SET link_downloads = link_downloads + 1, link_last_accessed_at = now() " +
+		"WHERE link_id = $1 AND " + usableLink
+
+	result, err := repository.db.Exec(query, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+
+	return affected == 1, err
+}
+
+func (repository *ShareLinksRepository) RevokeLink(id string) error {
+	_, err := repository.db.Exec("UPDATE share_link SET link_revoked_at = now() WHERE link_id = $1 AND link_revoked_at IS NULL", id)
+
+	return err
+}
+
+// DeleteLinks removes the links of an owner to path and, for a folder, to
+// everything in it.
+func (repository *ShareLinksRepository) DeleteLinks(ownerId, path string) error {
+	query := "DELETE FROM share_link WHERE owner_id = $1 AND (link_path = $2 OR " +
+		"(right($2, 1) = '/' AND left(link_path, length($2)) = $2))"
+
+	_, err := repository.db.Exec(query, ownerId, path)
+
+	return err
+}
+
+func scanShareLinks(rows *sql.Rows) ([]models.ShareLink, error) {
+	defer rows.Close()
+
+	links := make([]models.ShareLink, 0)
+	for rows.Next() {
+		var link models.ShareLink
+		var expiresAt, lastAccessedAt, revokedAt sql.NullTime
+
+		err := rows.Scan(&link.Id, &link.OwnerId, &link.OwnerLogin, &link.Path, &link.TokenHash, &link.PasswordHash,
+			&expiresAt, &link.MaxDownloads, &link.Downloads, &lastAccessedAt, &link.CreatedAt, &revokedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		link.ExpiresAt, link.LastAccessedAt, link.RevokedAt = expiresAt.Time, lastAccessedAt.Time, revokedAt.Time
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"auth/pkg/customError"
+	"database/sql"
+	"github.com/lib/pq"
+)
+
+const shareColumns = "share_id, share.owner_id, owner.profile_login, share_path, COALESCE(grantee.profile_id::text, ''), " +
+	"COALESCE(grantee.profile_login, ''), COALESCE(group_name, ''), share_permission, share_created_at " +
+	"FROM share INNER JOIN profile owner ON share.owner_id = owner.profile_id " +
+	"LEFT JOIN profile grantee ON share.grantee_profile_id = grantee.profile_id " +
+	"LEFT JOIN user_group ON share.grantee_group_id = user_group.group_id "
+
+// granteeCondition matches shares given to the profile in parameter directly or through one of its groups.
+func granteeCondition(parameter string) string {
+	return "(share.grantee_profile_id = " + parameter + " OR share.grantee_group_id IN " +
+		"(SELECT group_id FROM group_profile WHERE profile_id = " + parameter + "))"
+}
+
+type SharesRepository struct {
+	db *sql.DB
+}
+
+func NewSharesRepository(db *sql.DB) *SharesRepository {
+	return &SharesRepository{db: db}
+}
+
+// CreateShare stores a share with either GranteeId or GranteeGroup, a group name,
+// set. Sharing a path with the same grantee again changes the permission.
+func (repository *SharesRepository) CreateShare(share models.Share) (models.Share, error) {
+	var query string
+	var grantee string
+
+	if share.GranteeGroup != "" {
+		query = "INSERT INTO share (owner_id, share_path, share_permission, grantee_group_id) " +
+			"SELECT $1, $2, $3, group_id FROM user_group WHERE group_name = $4 " +
+			"ON CONFLICT (owner_id, share_path, grantee_group_id) WHERE grantee_group_id IS NOT NULL " +
+			"DO UPDATE SET share_permission = EXCLUDED.share_permission RETURNING share_id, share_created_at"
+		grantee = share.GranteeGroup
+	} else {
+		query = "INSERT INTO share (owner_id, share_path, share_permission, grantee_profile_id) VALUES ($1, $2, $3, $4) " +
+			"ON CONFLICT (owner_id, share_path, grantee_profile_id) WHERE grantee_profile_id IS NOT NULL " +
+			"DO UPDATE SET share_permission = EXCLUDED.share_permission RETURNING share_id, share_created_at"
+		grantee = share.GranteeId
+	}
+
+	err := repository.db.QueryRow(query, share.OwnerId, share.Path, share.Permission, grantee).Scan(&share.Id, &share.CreatedAt)
+	if err == sql.ErrNoRows {
+		return models.Share{}, customError.UnexistingGroupError
+	}
+
+	return share, err
+}
+
+func (repository *SharesRepository) GetShare(id string) (models.Share, error) {
+	rows, err := repository.db.Query("SELECT "+shareColumns+"WHERE share_id = $1", id)
+	if err != nil {
+		return models.Share{}, err
+	}
+
+	shares, err := scanShares(rows)
+	if err != nil {
+		return models.Share{}, err
+	}
+	if len(shares) == 0 {
+		return models.Share{}, sql.ErrNoRows
+	}
+
+	return shares[0], nil
+}
+
+// GetShares returns the shares an owner gave, ordered by path.
+func (repository *SharesRepository) GetShares(ownerId string) ([]models.Share, error) {
+	rows, err := repository.db.Query("SELECT "+shareColumns+"WHERE share.owner_id = $1 ORDER BY share_path, share_created_at", ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanShares(rows)
+}
+
+// GetSharedWith returns the shares a profile got directly or through its groups
+// from accounts that were not unregistered.
+func (repository *SharesRepository) GetSharedWith(profileId string) ([]models.Share, error) {
+	query := "SELECT " + shareColumns + "WHERE owner.profile_deleted_at IS NULL AND " + granteeCondition("$1") +
+		" ORDER BY owner.profile_login, share_path"
+
+	rows, err := repository.db.Query(query, profileId)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanShares(rows)
+}
+
+// FindShares returns the shares of an owner on any of paths that reach a profile.
+func (repository *SharesRepository) FindShares(ownerId, profileId string, paths []string) ([]models.Share, error) {
+	query := "SELECT " + shareColumns + "WHERE share.owner_id = $1 AND share_path = ANY($3) AND " + granteeCondition("$2")
+
+	rows, err := repository.db.Query(query, ownerId, profileId, pq.Array(paths))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanShares(rows)
+}
+
+func (repository *SharesRepository) DeleteShare(id string) error {
+	_, err := repository.db.Exec("DELETE FROM share WHERE share_id = $1", id)
+
+	return err
+}
+
+// DeleteShares removes the shares of an owner on path and, for a folder, on
+// everything in it.
+func (repository *SharesRepository) DeleteShares(ownerId, path string) error {
+	query := "DELETE FROM share WHERE owner_id = $1 AND (share_path = $2 OR " +
+		"(right($2, 1) = '/' AND left(share_path, length($2)) = $2))"
+
+	_, err := repository.db.Exec(query, ownerId, path)
+
+	return err
+}
+
+func scanShares(rows *sql.Rows) ([]models.Share, error) {
+	defer rows.Close()
+
+	shares := make([]models.Share, 0)
+	for rows.Next() {
+		var share models.Share
+		err := rows.Scan(&share.Id, &share.OwnerId, &share.OwnerLogin, &share.Path, &share.GranteeId,
+			&share.GranteeLogin, &share.GranteeGroup, &share.Permission, &share.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	return shares, rows.Err()
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"database/sql"
+	"github.com/lib/pq"
+	"time"
+)
+
+const uploadColumns = "upload_id, upload.profile_id, profile_login, upload_file_name, upload_content_type, upload_metadata, " +
+	"upload_multipart_id, upload_length, upload_offset, upload_tail_size, upload_parts, upload_created_at, upload_expires_at"
+
+type UploadsRepository struct {
+	db *sql.DB
+}
+
+func NewUploadsRepository(db *sql.DB) *UploadsRepository {
+	return &UploadsRepository{db: db}
+}
+
+// CreateUpload saves a new upload unless its profile already has limit uploads
+// that have not expired. It reports whether the upload was saved.
+func (repository *UploadsRepository) CreateUpload(upload models.Upload, limit int) (bool, error) {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Locking the profile makes concurrent uploads of the same user count one after another.
+	_, err = tx.Exec("SELECT 1 FROM profile WHERE profile_id = $1 FOR UPDATE", upload.ProfileId)
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	err = tx.QueryRow("SELECT count(*) FROM upload WHERE profile_id = $1 AND upload_expires_at > now()", upload.ProfileId).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	if count >= limit {
+		return false, nil
+	}
+
+	query := "INSERT INTO upload (upload_id, profile_id, upload_file_name, upload_content_type, upload_metadata, " +
+		"upload_multipart_id, upload_length, upload_created_at, upload_expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+
+	_, err = tx.Exec(query, upload.Id, upload.ProfileId, upload.FileName, upload.ContentType, upload.Metadata,
+		upload.MultipartId, upload.Length, upload.CreatedAt, upload.ExpiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (repository *UploadsRepository) GetUpload(id string) (models.Upload, error) {
+	rows, err := repository.db.Query("SELECT "+uploadColumns+" FROM upload INNER JOIN profile ON upload.profile_id = profile.profile_id "+
+		"WHERE upload_id = $1", id)
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	uploads, err := scanUploads(rows)
+	if err != nil {
+		return models.Upload{}, err
+	}
+	if len(uploads) == 0 {
+		return models.Upload{}, sql.ErrNoRows
+	}
+
+	return uploads[0], nil
+}
+
+// UpdateUpload saves the progress of an upload.
+func (repository *UploadsRepository) UpdateUpload(upload models.Upload) error {
+	query := "UPDATE upload SET upload_offset = $2, upload_tail_size = $3, upload_parts = $4, upload_expires_at = $5 WHERE upload_id = $1"
+
+	_, err := repository.db.Exec(query, upload.Id, upload.Offset, upload.TailSize, pq.Array(upload.Parts), upload.ExpiresAt)
+
+	return err
+}
+
+func (repository *UploadsRepository) DeleteUpload(id string) error {
+	_, err := repository.db.Exec("DELETE FROM upload WHERE upload_id = $1", id)
+
+	return err
+}
+
+func (repository *UploadsRepository) GetExpiredUploads(before time.Time) ([]models.Upload, error) {
+	rows, err := repository.db.Query("SELECT "+uploadColumns+" FROM upload INNER JOIN profile ON upload.profile_id = profile.profile_id "+
+		"WHERE upload_expires_at < $1", before)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanUploads(rows)
+}
+
+func scanUploads(rows *sql.Rows) ([]models.Upload, error) {
+	defer rows.Close()
+
+	uploads := make([]models.Upload, 0)
+	for rows.Next() {
+		var upload models.Upload
+
+		err := rows.Scan(&upload.Id, &upload.ProfileId, &upload.Login, &upload.FileName, &upload.ContentType, &upload.Metadata,
+			&upload.MultipartId, &upload.Length, &upload.Offset, &upload.TailSize, pq.Array(&upload.Parts),
+			&upload.CreatedAt, &upload.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, rows.Err()
+}
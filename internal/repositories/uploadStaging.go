@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"context"
+	"github.com/minio/minio-go/v7"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Uploads are staged in their own bucket until they are checked. Resumable uploads
+// keep the received parts as a multipart upload of an object named after the
+// upload, and the bytes that do not fill a part yet as a separate "<id>.tail"
+// object. Presigned uploads are sent straight to an object of the staging bucket.
+
+func (storage *FileStorage) ensureStaging(ctx context.Context) error {
+	if storage.stagingReady.Load() {
+		return nil
+	}
+
+	exists, err := storage.client.BucketExists(ctx, storage.staging)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		err = storage.client.MakeBucket(ctx, storage.staging, minio.MakeBucketOptions{})
+		if err != nil {
+			return err
+		}
+	}
+
+	storage.stagingReady.Store(true)
+
+	return nil
+}
+
+// StartUpload begins staging the upload id and returns the id of its multipart upload.
+func (storage *FileStorage) StartUpload(ctx context.Context, id string) (string, error) {
+	err := storage.ensureStaging(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	core := minio.Core{Client: storage.client}
+
+	return core.NewMultipartUpload(ctx, storage.staging, id, minio.PutObjectOptions{})
+}
+
+// PutUploadPart stores one part of a staged upload and returns its ETag.
+func (storage *FileStorage) PutUploadPart(ctx context.Context, id, multipartId string, partNumber int, data io.Reader, size int64) (string, error) {
+	core := minio.Core{Client: storage.client}
+
+	part, err := core.PutObjectPart(ctx, storage.staging, id, multipartId, partNumber, data, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return part.ETag, nil
+}
+
+func (storage *FileStorage) PutUploadTail(ctx context.Context, id string, data io.Reader, size int64) error {
+	_, err := storage.client.PutObject(ctx, storage.staging, id+".tail", data, size, minio.PutObjectOptions{})
+
+	return err
+}
+
+func (storage *FileStorage) OpenUploadTail(ctx context.Context, id string) (io.ReadCloser, error) {
+	return storage.client.GetObject(ctx, storage.staging, id+".tail", minio.GetObjectOptions{})
+}
+
+// AssembleUpload joins the parts of a staged upload into the staged object id.
+// Assembling an upload again finds it already done.
+func (storage *FileStorage) AssembleUpload(ctx context.Context, id, multipartId string, parts []string) error {
+	core := minio.Core{Client: storage.client}
+
+	completeParts := make([]minio.CompletePart, 0, len(parts))
+	for i, etag := range parts {
+		completeParts = append(completeParts, minio.CompletePart{PartNumber: i + 1, ETag: etag})
+	}
+
+	_, err := core.CompleteMultipartUpload(ctx, storage.staging, id, multipartId, completeParts, minio.PutObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code != "NoSuchUpload" {
+			return err
+		}
+		_, statErr := storage.client.StatObject(ctx, storage.staging, id, minio.StatObjectOptions{})
+		if statErr != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AbortUpload drops everything staged for an upload.
+func (storage *FileStorage) AbortUpload(ctx context.Context, id, multipartId string) error {
+	core := minio.Core{Client: storage.client}
+
+	err := core.AbortMultipartUpload(ctx, storage.staging, id, multipartId)
+	if err != nil && minio.ToErrorResponse(err).Code != "NoSuchUpload" {
+		return err
+	}
+
+	storage.removeStaged(ctx, id)
+
+	return nil
+}
+
+func (storage *FileStorage) removeStaged(ctx context.Context, id string) {
+	opts := minio.RemoveObjectOptions{}
+
+	storage.client.RemoveObject(ctx, storage.staging, id, opts)
+	storage.client.RemoveObject(ctx, storage.staging, id+".tail", opts)
+}
+
+// PresignStagedPut returns a URL that uploads the staged object key with a PUT
+// request until expiry. The content type and length are signed, so the upload must
+// send exactly that Content-Type and size bytes.
+func (storage *FileStorage) PresignStagedPut(ctx context.Context, key, contentType string, size int64, expiry time.Duration) (*url.URL, error) {
+	err := storage.ensureStaging(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", contentType)
+	headers.Set("Content-Length", strconv.FormatInt(size, 10))
+
+	return storage.presigner.PresignHeader(ctx, http.MethodPut, storage.staging, key, expiry, nil, headers)
+}
+
+// PresignStagedPost returns a URL and the form fields of a browser POST upload of
+// the staged object key. The policy makes MinIO itself refuse other content types
+// and bodies larger than maxSize.
+func (storage *FileStorage) PresignStagedPost(ctx context.Context, key, contentType string, maxSize int64, expiry time.Duration) (*url.URL, map[string]string, error) {
+	err := storage.ensureStaging(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	policy := minio.NewPostPolicy()
+
+	err = policy.SetBucket(storage.staging)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = policy.SetKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = policy.SetContentType(contentType)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = policy.SetContentLengthRange(1, maxSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = policy.SetExpires(time.Now().UTC().Add(expiry))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return storage.presigner.PresignedPostPolicy(ctx, policy)
+}
+
+// OpenStaged opens a staged object for reading; the caller must close it.
+func (storage *FileStorage) OpenStaged(ctx context.Context, key string) (io.ReadSeekCloser, minio.ObjectInfo, error) {
+	return storage.OpenFile(ctx, storage.staging, key)
+}
+
+// MoveStaged moves a staged object to fileName in bucketName with the given content type.
+func (storage *FileStorage) MoveStaged(ctx context.Context, key, bucketName, fileName, contentType string) error {
+	dst := minio.CopyDestOptions{
+		Bucket:          bucketName,
+		Object:          fileName,
+		ReplaceMetadata: true,
+		UserMetadata:    map[string]string{"Content-Type": contentType},
+	}
+	src := minio.CopySrcOptions{
+		Bucket: storage.staging,
+		Object: key,
+	}
+
+	_, err := storage.client.ComposeObject(ctx, dst, src)
+	if err != nil {
+		return err
+	}
+
+	storage.removeStaged(ctx, key)
+
+	return nil
+}
+
+// RemoveStaged drops a staged object.
+func (storage *FileStorage) RemoveStaged(ctx context.Context, key string) {
+	storage.removeStaged(ctx, key)
+}
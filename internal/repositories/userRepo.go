@@ -5,10 +5,19 @@ import (
 	"auth/pkg/customError"
 	"database/sql"
 	"fmt"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/lib/pq"
 	"sort"
+	"strings"
+	"time"
 )
 
+// userSortColumns maps sort keys accepted by ListUsers to profile columns.
+var userSortColumns = map[string]string{
+	"login":      "profile_login",
+	"created_at": "profile_created_at",
+	"status":     "profile_status",
+}
+
 type UsersRepository struct {
 	db *sql.DB
 }
@@ -20,7 +29,9 @@ func NewUsersRepository(db *sql.DB) *UsersRepository {
 func (repository *UsersRepository) GetUserByLogin(login string) (models.User, error) {
 	var dbData models.User
 
-	err := repository.db.QueryRow("SELECT * FROM profile WHERE profile_login = $1", login).Scan(&dbData.Id, &dbData.Login, &dbData.Password)
+	query := "SELECT profile_id, profile_login, profile_password, profile_status, profile_status_reason, profile_created_at FROM profile WHERE profile_login = $1 AND profile_deleted_at IS NULL"
+
+	err := repository.db.QueryRow(query, login).Scan(&dbData.Id, &dbData.Login, &dbData.Password, &dbData.Status, &dbData.StatusReason, &dbData.CreatedAt)
 	if err != nil {
 		return models.User{}, err
 	}
@@ -32,6 +43,13 @@ func (repository *UsersRepository) GetUserByLogin(login string) (models.User, er
 
 	dbData.Roles = roles
 
+	groups, err := repository.GetUserGroupsByLogin(login)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	dbData.Groups = groups
+
 	return dbData, err
 }
 
@@ -81,29 +99,69 @@ func (repository *UsersRepository) GetRolesListAsMap() (map[string]bool, error)
 	return roles, nil
 }
 
+// GetUserRolesByLogin returns effective roles of a user: the ones assigned directly
+// and the ones inherited from every group the user is a member of.
 func (repository *UsersRepository) GetUserRolesByLogin(login string) ([]string, error) {
-	var oldRoles []string
+	query := "SELECT role.role_id, role_name FROM profile INNER JOIN profile_role ON profile.profile_id=profile_role.profile_id INNER JOIN role ON profile_role.role_id = role.role_id WHERE profile_login = $1 " +
+		"UNION " +
+		"SELECT role.role_id, role_name FROM profile INNER JOIN group_profile ON profile.profile_id = group_profile.profile_id INNER JOIN group_role ON group_profile.group_id = group_role.group_id INNER JOIN role ON group_role.role_id = role.role_id WHERE profile_login = $1"
 
+	return repository.queryRoles(query, login)
+}
+
+// GetUserDirectRolesByLogin returns only roles assigned to the user itself.
+func (repository *UsersRepository) GetUserDirectRolesByLogin(login string) ([]string, error) {
 	query := "SELECT role.role_id, role_name FROM profile INNER JOIN profile_role ON profile.profile_id=profile_role.profile_id INNER JOIN role ON profile_role.role_id = role.role_id WHERE profile_login = $1"
 
+	return repository.queryRoles(query, login)
+}
+
+func (repository *UsersRepository) queryRoles(query, login string) ([]string, error) {
+	var roles []string
+
 	rows, err := repository.db.Query(query, login)
-	defer rows.Close()
 	if err != nil {
-		return oldRoles, err
+		return roles, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var role models.Role
 		err = rows.Scan(&role.Id, &role.Name)
 		if err != nil {
-			return oldRoles, err
+			return roles, err
+		}
+		roles = append(roles, role.Name)
+	}
+
+	sort.Strings(roles)
+
+	return roles, nil
+}
+
+func (repository *UsersRepository) GetUserGroupsByLogin(login string) ([]string, error) {
+	var groups []string
+
+	query := "SELECT group_name FROM profile INNER JOIN group_profile ON profile.profile_id = group_profile.profile_id INNER JOIN user_group ON group_profile.group_id = user_group.group_id WHERE profile_login = $1"
+
+	rows, err := repository.db.Query(query, login)
+	if err != nil {
+		return groups, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var group string
+		err = rows.Scan(&group)
+		if err != nil {
+			return groups, err
 		}
-		oldRoles = append(oldRoles, role.Name)
+		groups = append(groups, group)
 	}
 
-	sort.Strings(oldRoles)
+	sort.Strings(groups)
 
-	return oldRoles, nil
+	return groups, nil
 }
 
 func (repository *UsersRepository) GetRoleIdByName(name string) (string, error) {
@@ -125,31 +183,242 @@ func (repository *UsersRepository) Register(login string, pass []byte) error {
 	return nil
 }
 
-func (repository *UsersRepository) Login(pass, dbPass string) error {
+func (repository *UsersRepository) UpdatePassword(login string, pass []byte) error {
+	_, err := repository.db.Exec("UPDATE profile SET profile_password = $1 WHERE profile_login = $2", pass, login)
+
+	return err
+}
 
-	err := bcrypt.CompareHashAndPassword([]byte(dbPass), []byte(pass))
+// Purge deletes an account marked deleted before deletedBefore, calling
+// removeFiles while the profile is locked. It reports false and leaves the
+// account alone when it was restored in the meantime. When removeFiles fails
+// the profile is kept, so that the purge is tried again.
+func (repository *UsersRepository) Purge(login string, deletedBefore time.Time, removeFiles func() error) (bool, error) {
+	tx, err := repository.db.Begin()
 	if err != nil {
-		return customError.IncorrectPasswordError
+		return false, err
 	}
+	defer tx.Rollback()
 
-	return nil
+	result, err := tx.Exec("DELETE FROM profile WHERE profile_login = $1 AND profile_deleted_at IS NOT NULL AND profile_deleted_at < $2",
+		login, deletedBefore)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil || deleted == 0 {
+		return false, err
+	}
+
+	err = removeFiles()
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
 }
 
-func (repository *UsersRepository) Unregister(login string) error {
-	_, err := repository.db.Exec("DELETE FROM profile WHERE profile_login = $1", login)
+// MarkDeleted hides an account until it is either restored or purged.
+func (repository *UsersRepository) MarkDeleted(login string) error {
+	_, err := repository.db.Exec("UPDATE profile SET profile_deleted_at = now() WHERE profile_login = $1 AND profile_deleted_at IS NULL", login)
+
+	return err
+}
+
+// Restore brings back an account marked deleted. It returns sql.ErrNoRows when
+// there is none, for instance because it was purged in the meantime.
+func (repository *UsersRepository) Restore(login string) error {
+	result, err := repository.db.Exec("UPDATE profile SET profile_deleted_at = NULL WHERE profile_login = $1 AND profile_deleted_at IS NOT NULL", login)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	restored, err := result.RowsAffected()
+	if err == nil && restored == 0 {
+		err = sql.ErrNoRows
+	}
+
+	return err
+}
+
+func (repository *UsersRepository) GetDeletedUserByLogin(login string) (models.User, error) {
+	var dbData models.User
+
+	query := "SELECT profile_id, profile_login, profile_status, profile_created_at, profile_deleted_at FROM profile WHERE profile_login = $1 AND profile_deleted_at IS NOT NULL"
+
+	err := repository.db.QueryRow(query, login).Scan(&dbData.Id, &dbData.Login, &dbData.Status, &dbData.CreatedAt, &dbData.DeletedAt)
+
+	return dbData, err
+}
+
+// GetDeletedUsers returns accounts marked deleted before the given moment, oldest first.
+func (repository *UsersRepository) GetDeletedUsers(before time.Time) ([]models.User, error) {
+	var users []models.User
+
+	query := "SELECT profile_id, profile_login, profile_status, profile_created_at, profile_deleted_at FROM profile WHERE profile_deleted_at IS NOT NULL AND profile_deleted_at < $1 ORDER BY profile_deleted_at"
+
+	rows, err := repository.db.Query(query, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.User
+		err = rows.Scan(&user.Id, &user.Login, &user.Status, &user.CreatedAt, &user.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (repository *UsersRepository) GetUserStatus(login string) (string, error) {
+	var status string
+
+	err := repository.db.QueryRow("SELECT profile_status FROM profile WHERE profile_login = $1 AND profile_deleted_at IS NULL", login).Scan(&status)
+
+	return status, err
+}
+
+func (repository *UsersRepository) SetUserStatus(login, status, reason string) error {
+	query := "UPDATE profile SET profile_status = $1, profile_status_reason = $2, profile_status_changed_at = now() WHERE profile_login = $3 AND profile_deleted_at IS NULL"
+
+	_, err := repository.db.Exec(query, status, reason, login)
+
+	return err
 }
 
 func (repository *UsersRepository) AddRole(profileId, newRoleId string) error {
 
-	_, err := repository.db.Exec("INSERT INTO profile_role (profile_id, role_id) VALUES ($1, $2)", profileId, newRoleId)
+	tx, err := repository.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
+	_, err = tx.Exec("INSERT INTO profile_role (profile_id, role_id) VALUES ($1, $2)", profileId, newRoleId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO role_history (profile_id, role_name, history_action) SELECT $1, role_name, 'granted' FROM role WHERE role_id = $2", profileId, newRoleId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repository *UsersRepository) GetRoleHistory(profileId string) ([]models.RoleChange, error) {
+	var history []models.RoleChange
+
+	rows, err := repository.db.Query("SELECT role_name, history_action, history_at FROM role_history WHERE profile_id = $1 ORDER BY history_at", profileId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change models.RoleChange
+		err = rows.Scan(&change.Role, &change.Action, &change.At)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+
+	return history, rows.Err()
+}
+
+// ListUsers returns one page of users matching query and the total number of matching users.
+// Pages are keyset based: rows are ordered by the sort column and profile_id, and the page
+// starts right after the (AfterValue, AfterId) position when it is set.
+func (repository *UsersRepository) ListUsers(query models.UsersQuery) ([]models.User, int, error) {
+	column, ok := userSortColumns[query.Sort]
+	if !ok {
+		return nil, 0, customError.InvalidSortError
+	}
+
+	conditions := []string{"profile_deleted_at IS NULL"}
+	var args []interface{}
+
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.LoginPrefix != "" {
+		escaper := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+		addCondition("profile_login LIKE $%d", escaper.Replace(query.LoginPrefix)+"%")
+	}
+	if query.Status != "" {
+		addCondition("profile_status = $%d", query.Status)
+	}
+	if !query.CreatedFrom.IsZero() {
+		addCondition("profile_created_at >= $%d", query.CreatedFrom)
+	}
+	if !query.CreatedTo.IsZero() {
+		addCondition("profile_created_at < $%d", query.CreatedTo)
+	}
+	if query.Role != "" {
+		addCondition("EXISTS ("+
+			"SELECT 1 FROM profile_role INNER JOIN role ON profile_role.role_id = role.role_id "+
+			"WHERE profile_role.profile_id = profile.profile_id AND role_name = $%[1]d "+
+			"UNION ALL "+
+			"SELECT 1 FROM group_profile INNER JOIN group_role ON group_profile.group_id = group_role.group_id INNER JOIN role ON group_role.role_id = role.role_id "+
+			"WHERE group_profile.profile_id = profile.profile_id AND role_name = $%[1]d)", query.Role)
+	}
+
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	err := repository.db.QueryRow("SELECT COUNT(*) FROM profile"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	order, comparison := "ASC", ">"
+	if query.Desc {
+		order, comparison = "DESC", "<"
+	}
+
+	if query.AfterId != "" {
+		cast := ""
+		if column == "profile_created_at" {
+			cast = "::timestamptz"
+		}
+		args = append(args, query.AfterValue, query.AfterId)
+		conditions = append(conditions, fmt.Sprintf("(%s, profile_id) %s ($%d%s, $%d)", column, comparison, len(args)-1, cast, len(args)))
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, query.Limit)
+	selectQuery := "SELECT profile_id, profile_login, profile_status, profile_created_at, " +
+		"ARRAY(SELECT role_name FROM profile_role INNER JOIN role ON profile_role.role_id = role.role_id WHERE profile_role.profile_id = profile.profile_id " +
+		"UNION SELECT role_name FROM group_profile INNER JOIN group_role ON group_profile.group_id = group_role.group_id INNER JOIN role ON group_role.role_id = role.role_id WHERE group_profile.profile_id = profile.profile_id " +
+		"ORDER BY 1) FROM profile" + where +
+		fmt.Sprintf(" ORDER BY %s %s, profile_id %s LIMIT $%d", column, order, order, len(args))
+
+	rows, err := repository.db.Query(selectQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0, query.Limit)
+
+	for rows.Next() {
+		var user models.User
+		err = rows.Scan(&user.Id, &user.Login, &user.Status, &user.CreatedAt, pq.Array(&user.Roles))
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+
+	return users, total, rows.Err()
 }
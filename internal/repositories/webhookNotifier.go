@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"auth/internal/core/domain/models"
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+type newLoginNotification struct {
+	Login     string    `json:"login"`
+	SessionId string    `json:"session_id"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Device    string    `json:"device"`
+	Country   string    `json:"country,omitempty"`
+	City      string    `json:"city,omitempty"`
+	At        time.Time `json:"at"`
+	NewDevice bool      `json:"new_device"`
+	NewIP     bool      `json:"new_ip"`
+}
+
+// WebhookNotifier posts logins from new devices or IPs as JSON to a URL, e.g. of
+// a mailer that warns the user. Posting happens in the background and failures are only logged.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (notifier *WebhookNotifier) NotifyNewLogin(login string, session models.Session, newDevice, newIP bool) {
+	body, err := json.Marshal(newLoginNotification{
+		Login:     login,
+		SessionId: session.Id,
+		IP:        session.IP,
+		UserAgent: session.UserAgent,
+		Device:    session.Device,
+		Country:   session.Location.Country,
+		City:      session.Location.City,
+		At:        session.CreatedAt,
+		NewDevice: newDevice,
+		NewIP:     newIP,
+	})
+	if err != nil {
+		log.Printf("Encoding new login notification of %s failed: %v", login, err)
+		return
+	}
+
+	go func() {
+		resp, err := notifier.client.Post(notifier.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("Sending new login notification of %s failed: %v", login, err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 300 {
+			log.Printf("Sending new login notification of %s failed: webhook answered %s", login, resp.Status)
+		}
+	}()
+}
@@ -3,21 +3,27 @@ package main
 import (
 	docs "auth/docs"
 	"auth/internal/core"
+	"auth/internal/core/domain/models"
 	"auth/internal/handlers"
 	"auth/internal/repositories"
+	"auth/pkg/pwned"
+	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"log"
+	"os"
+	"strings"
+	"time"
 )
 
 // @title Auth API
 // @version 1.0
 
 // @host localhost:8080
-// @BasePath /user
+// @BasePath /
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Print("No .env file found")
@@ -25,27 +31,203 @@ func main() {
 
 	r := gin.Default()
 
-	userRepo := repositories.NewUsersRepository(repositories.AccessDataBase())
+	// Client IPs key rate limits, lockouts, audit records and risk scores, so
+	// X-Forwarded-For is only believed from TRUSTED_PROXIES, comma-separated IPs
+	// or CIDRs; by default from no one.
+	var trustedProxies []string
+	TRUSTED_PROXIES, _ := os.LookupEnv("TRUSTED_PROXIES")
+	for _, proxy := range strings.Split(TRUSTED_PROXIES, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal(err)
+	}
+
+	r.Use(handlers.RequestId())
+
+	db := repositories.AccessDataBase()
+
+	userRepo := repositories.NewUsersRepository(db)
+	groupRepo := repositories.NewGroupsRepository(db)
 	fileStorage := repositories.NewFileStorage()
-	userService := core.NewUserService(userRepo, fileStorage)
-	userHandler := handlers.NewUserHandler(userService)
 
-	docs.SwaggerInfo.BasePath = "/user"
+	var attemptStore core.LoginAttemptStore = repositories.NewMemoryAttemptStore()
+	var loginAttemptsRepo *repositories.LoginAttemptsRepository
+	if LOGIN_ATTEMPT_STORE, _ := os.LookupEnv("LOGIN_ATTEMPT_STORE"); LOGIN_ATTEMPT_STORE != "memory" {
+		loginAttemptsRepo = repositories.NewLoginAttemptsRepository(db)
+		attemptStore = loginAttemptsRepo
+	}
+
+	loginGuard := core.NewLoginGuard(attemptStore)
+	if loginAttemptsRepo != nil {
+		go loginAttemptsRepo.RunCleanup(context.Background(), loginGuard.Window())
+	}
+	var breachedPasswords core.BreachedPasswords
+	if BREACHED_PASSWORDS_FILE, ok := os.LookupEnv("BREACHED_PASSWORDS_FILE"); ok {
+		source, err := pwned.Open(BREACHED_PASSWORDS_FILE)
+		if err != nil {
+			log.Fatal(err)
+		}
+		breachedPasswords = source
+	}
+
+	passwordPolicy := core.NewPasswordPolicy(breachedPasswords)
+	// A filter reports every password it holds as seen MinCount times, so a higher
+	// minimum would never reject anything.
+	if filter, ok := breachedPasswords.(*pwned.Filter); ok {
+		if passwordPolicy.MinBreachCount() > filter.MinCount {
+			log.Fatalf("BREACHED_PASSWORDS_MIN_COUNT %d is above %d, the count the breached passwords filter was built with",
+				passwordPolicy.MinBreachCount(), filter.MinCount)
+		}
+		log.Printf("Breached passwords filter keeps passwords seen at least %d times", filter.MinCount)
+	}
+	peppers, pepperVersion, err := core.LoadPeppers()
+	if err != nil {
+		log.Fatal(err)
+	}
+	passwordHasher := core.NewPasswordHasher(peppers, pepperVersion)
+	sessionsRepo := repositories.NewSessionsRepository(db)
+	var loginNotifier core.LoginNotifier
+	if LOGIN_NOTIFY_WEBHOOK_URL, ok := os.LookupEnv("LOGIN_NOTIFY_WEBHOOK_URL"); ok {
+		loginNotifier = repositories.NewWebhookNotifier(LOGIN_NOTIFY_WEBHOOK_URL)
+	}
+	var geoLocator core.GeoLocator
+	if GEOIP_DATABASE_FILE, ok := os.LookupEnv("GEOIP_DATABASE_FILE"); ok {
+		geoDatabase, err := repositories.OpenGeoIPDatabase(GEOIP_DATABASE_FILE)
+		if err != nil {
+			log.Fatal(err)
+		}
+		geoLocator = geoDatabase
+	}
+	riskEngine := core.NewRiskEngine(geoLocator)
+	fileTypePolicy := core.NewFileTypePolicy()
+	storageQuotas := core.NewStorageQuotas(repositories.NewQuotaRepository(db))
+	auditLog := core.NewAuditLog(repositories.NewAuditRepository(db))
+	AUDIT_SINKS, _ := os.LookupEnv("AUDIT_SINKS")
+	for _, name := range strings.Split(AUDIT_SINKS, ",") {
+		var sink core.AuditSink
+		switch name = strings.TrimSpace(name); name {
+		case "":
+			continue
+		case "syslog":
+			sink, err = repositories.NewSyslogSink()
+		case "file":
+			sink, err = repositories.NewJSONLinesSink()
+		case "stdout":
+			sink = repositories.NewStdoutSink()
+		default:
+			log.Fatalf("Unknown audit sink %q in AUDIT_SINKS", name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		auditLog.AddSink(name, sink)
+	}
+	userService := core.NewUserService(userRepo, fileStorage, loginGuard, passwordPolicy, passwordHasher, sessionsRepo, loginNotifier, riskEngine,
+		fileTypePolicy, storageQuotas, repositories.NewSharesRepository(db),
+		repositories.NewShareLinksRepository(db), auditLog)
+	groupService := core.NewGroupService(groupRepo, userRepo, auditLog)
+
+	exportService := core.NewExportService(userRepo, fileStorage, sessionsRepo, auditLog)
+	auth := handlers.NewAuthenticator(userService)
+	userHandler := handlers.NewUserHandler(userService, auth, auditLog)
+	groupHandler := handlers.NewGroupHandler(groupService, auth)
+	exportHandler := handlers.NewExportHandler(exportService, auth)
+	uploadService := core.NewUploadService(userRepo, fileStorage, fileStorage, repositories.NewUploadsRepository(db),
+		repositories.NewPresignedUploadsRepository(db), fileTypePolicy, storageQuotas, auditLog)
+	uploadHandler := handlers.NewUploadHandler(uploadService, auth)
+
+	var rateLimitStore handlers.RateLimitStore = repositories.NewMemoryRateLimitStore()
+	if RATE_LIMIT_STORE, _ := os.LookupEnv("RATE_LIMIT_STORE"); RATE_LIMIT_STORE == "postgres" {
+		rateLimitRepo := repositories.NewRateLimitRepository(db)
+		go rateLimitRepo.RunCleanup(context.Background(), 24*time.Hour)
+		rateLimitStore = rateLimitRepo
+	}
+
+	limiter := handlers.NewRateLimiter(rateLimitStore)
+	defaultLimit := limiter.Limit(handlers.LoadRateLimitPolicy(models.RateLimitPolicy{Name: "default", Capacity: 60, Period: time.Minute, Key: "subject"}))
+	registerLimit := limiter.Limit(handlers.LoadRateLimitPolicy(models.RateLimitPolicy{Name: "register", Capacity: 5, Period: time.Hour, Key: "ip"}))
+	loginIPLimit := limiter.Limit(handlers.LoadRateLimitPolicy(models.RateLimitPolicy{Name: "login_ip", Capacity: 20, Period: time.Minute, Key: "ip"}))
+	loginLimit := limiter.Limit(handlers.LoadRateLimitPolicy(models.RateLimitPolicy{Name: "login", Capacity: 5, Period: time.Minute, Key: "login"}))
+	fileListLimit := limiter.Limit(handlers.LoadRateLimitPolicy(models.RateLimitPolicy{Name: "file_list", Capacity: 300, Period: time.Minute, Key: "subject"}))
+	uploadLimit := limiter.Limit(handlers.LoadRateLimitPolicy(models.RateLimitPolicy{Name: "upload", Capacity: 600, Period: time.Minute, Key: "subject"}))
+	shareLinkLimit := limiter.Limit(handlers.LoadRateLimitPolicy(models.RateLimitPolicy{Name: "share_link", Capacity: 30, Period: time.Minute, Key: "ip"}))
+
+	docs.SwaggerInfo.BasePath = "/"
 	user := r.Group("/user")
 	{
-		user.POST("/register", userHandler.Register)
-		user.POST("/login", userHandler.Login)
+		user.POST("/register", registerLimit, userHandler.Register)
+		user.POST("/login", loginIPLimit, loginLimit, userHandler.Login)
+		user.POST("/getFileList", fileListLimit, userHandler.GetFileList)
+	}
+	user = user.Group("", defaultLimit)
+	{
 		user.DELETE("/unregister", userHandler.Unregister)
 		user.PUT("/addRoles", userHandler.AddRoles)
 		user.POST("/getUserData", userHandler.GetUserData)
 		user.POST("/uploadFile", userHandler.UploadFile)
-		user.POST("/downloadFile", userHandler.DownloadFile)
 		user.DELETE("/deleteFile", userHandler.DeleteFile)
-		user.POST("/getFileList", userHandler.GetFileList)
+		user.POST("/createFolder", userHandler.CreateFolder)
+		user.DELETE("/deleteFolder", userHandler.DeleteFolder)
+		user.PUT("/moveFile", userHandler.MoveFile)
+		user.POST("/copyFile", userHandler.CopyFile)
+		user.POST("/presignUpload", uploadHandler.PresignUpload)
+		user.POST("/confirmUpload", uploadHandler.ConfirmUpload)
+		user.POST("/presignDownload", userHandler.PresignDownload)
+		user.POST("/createGroup", groupHandler.CreateGroup)
+		user.PUT("/renameGroup", groupHandler.RenameGroup)
+		user.DELETE("/deleteGroup", groupHandler.DeleteGroup)
+		user.PUT("/addGroupMembers", groupHandler.AddMembers)
+		user.PUT("/removeGroupMembers", groupHandler.RemoveMembers)
+		user.PUT("/addGroupRoles", groupHandler.AddRoles)
+		user.PUT("/removeGroupRoles", groupHandler.RemoveRoles)
+		user.POST("/export", exportHandler.StartExport)
+		user.GET("/export/:id", exportHandler.GetExport)
+		user.GET("/export/:id/download", exportHandler.DownloadExport)
+		user.GET("/sessions", userHandler.ListSessions)
+		user.DELETE("/sessions/:id", userHandler.RevokeSession)
+		user.GET("/quota", userHandler.GetQuota)
+		user.POST("/shares", userHandler.ShareFile)
+		user.GET("/shares", userHandler.ListShares)
+		user.GET("/shares/received", userHandler.ListSharedWith)
+		user.DELETE("/shares/:id", userHandler.RevokeShare)
+		user.POST("/links", userHandler.CreateShareLink)
+		user.GET("/links", userHandler.ListShareLinks)
+		user.DELETE("/links/:id", userHandler.RevokeShareLink)
+	}
+	files := r.Group("/files", defaultLimit)
+	{
+		files.GET("/*name", userHandler.GetFile)
+	}
+	r.GET("/s/:token", shareLinkLimit, userHandler.DownloadShareLink)
+	uploads := r.Group("/uploads", handlers.TusResumable(), uploadLimit)
+	{
+		uploads.OPTIONS("", uploadHandler.Options)
+		uploads.POST("", uploadHandler.CreateUpload)
+		uploads.HEAD("/:id", uploadHandler.GetUpload)
+		uploads.PATCH("/:id", uploadHandler.PatchUpload)
+		uploads.DELETE("/:id", uploadHandler.DeleteUpload)
+	}
+	admin := r.Group("/admin", defaultLimit)
+	{
+		admin.GET("/users", userHandler.ListUsers)
+		admin.PUT("/users/:login/status", userHandler.SetAccountStatus)
+		admin.PUT("/users/:login/quota", userHandler.SetQuota)
+		admin.GET("/deletions", userHandler.ListPendingDeletions)
+		admin.POST("/deletions/:login/restore", userHandler.RestoreUser)
+		admin.DELETE("/lockouts", userHandler.ClearLockout)
+		admin.GET("/audit", userHandler.ListAuditEvents)
 	}
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	err := r.Run()
+	go userService.RunPurger(context.Background())
+	go uploadService.RunCleanup(context.Background(), time.Hour)
+	go exportService.RunSweeper(context.Background(), time.Hour)
+	go userService.RunQuotaReconciler(context.Background())
+
+	err = r.Run()
 	if err != nil {
 		panic(err)
 	}
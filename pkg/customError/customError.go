@@ -1,6 +1,10 @@
 package customError
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	ExistingLoginError     = errors.New("user with such login already exists")
@@ -13,4 +17,122 @@ var (
 	TypeNotAllowed         = errors.New("such file type is not allowed")
 	ExistingFileError      = errors.New("such file already exists")
 	UnexistingFileError    = errors.New("such file does not exist")
+	ExistingGroupError     = errors.New("group with such name already exists")
+	UnexistingGroupError   = errors.New("group with such name does not exist")
+	InvalidCursorError     = errors.New("cursor is invalid")
+	InvalidSortError       = errors.New("such sort column is not allowed")
+	InvalidStatusError     = errors.New("such account status does not exist")
+	AccountDisabledError   = errors.New("account is disabled")
+	AccountLockedError     = errors.New("account is locked")
+	UnexistingDeletion     = errors.New("account with such login is not pending deletion")
+	RestorePeriodExpired   = errors.New("restore period of this account has expired")
+	UnexistingExportError  = errors.New("such export does not exist")
+	ExportNotReadyError    = errors.New("export is not ready yet")
+	TooManyAttemptsError   = errors.New("too many failed login attempts")
+	NoLockoutTargetError   = errors.New("login or ip must be provided")
+	RateLimitedError       = errors.New("too many requests")
+	WeakPasswordError      = errors.New("password does not satisfy password policy")
+	AuditChainBrokenError  = errors.New("audit log chain is broken")
+	UnexistingSessionError = errors.New("such session does not exist")
+	RevokedSessionError    = errors.New("session was revoked")
+	FileTooLargeError      = errors.New("file is larger than allowed")
+	UnexistingUploadError  = errors.New("such upload does not exist")
+	UploadExpiredError     = errors.New("upload has expired")
+	UploadOffsetError      = errors.New("upload offset does not match")
+	UploadBusyError        = errors.New("upload is being written by another request")
+	TooManyUploadsError    = errors.New("too many unfinished uploads")
+	QuotaExceededError     = errors.New("storage quota exceeded")
+	TooManyFilesError      = errors.New("too many files to sort, narrow the listing with a prefix or filter")
+	InvalidFileNameError   = errors.New("file name is invalid")
+	ExistingFolderError    = errors.New("such folder already exists")
+	UnexistingFolderError  = errors.New("such folder does not exist")
+	FolderTooLargeError    = errors.New("folder holds too many files for one request")
+	InvalidConflictError   = errors.New("such conflict policy does not exist")
+	FolderOverlapError     = errors.New("source and destination folders overlap")
+	InvalidGranteeError    = errors.New("a share must name either a user other than the owner or a group")
+	InvalidPermissionError = errors.New("such share permission does not exist")
+	UnexistingShareError   = errors.New("such share does not exist")
+	UnexistingLinkError    = errors.New("such link does not exist")
+	LinkUnavailableError   = errors.New("link was revoked, has expired or reached its download limit")
+	LinkPasswordError      = errors.New("link requires a correct password")
+	StepUpRequiredError    = errors.New("login requires additional verification")
+	LoginBlockedError      = errors.New("login was blocked as suspicious")
 )
+
+// TooManyAttempts is returned while logins are blocked after repeated failures.
+// It matches TooManyAttemptsError with errors.Is.
+type TooManyAttempts struct {
+	RetryAfter time.Duration
+}
+
+func (err *TooManyAttempts) Error() string {
+	return fmt.Sprintf("%s, retry in %s", TooManyAttemptsError, err.RetryAfter.Round(time.Second))
+}
+
+func (err *TooManyAttempts) Is(target error) bool {
+	return target == TooManyAttemptsError
+}
+
+type PolicyFailure struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// WeakPassword lists every password policy rule a password breaks.
+// It matches WeakPasswordError with errors.Is.
+type WeakPassword struct {
+	Failures []PolicyFailure
+}
+
+func (err *WeakPassword) Error() string {
+	return WeakPasswordError.Error()
+}
+
+func (err *WeakPassword) Is(target error) bool {
+	return target == WeakPasswordError
+}
+
+// FileRejected tells why a file may not be uploaded.
+// It matches TypeNotAllowed with errors.Is.
+type FileRejected struct {
+	Reason string
+}
+
+func (err *FileRejected) Error() string {
+	return fmt.Sprintf("%s: %s", TypeNotAllowed, err.Reason)
+}
+
+func (err *FileRejected) Is(target error) bool {
+	return target == TypeNotAllowed
+}
+
+// QuotaExceeded tells which storage limit an upload would break.
+// It matches QuotaExceededError with errors.Is.
+type QuotaExceeded struct {
+	Resource string
+	Used     int64
+	Max      int64
+}
+
+func (err *QuotaExceeded) Error() string {
+	return fmt.Sprintf("%s: %d of %d %s used", QuotaExceededError, err.Used, err.Max, err.Resource)
+}
+
+func (err *QuotaExceeded) Is(target error) bool {
+	return target == QuotaExceededError
+}
+
+// AuditChainBroken tells which audit log entry does not match the chain and why.
+// It matches AuditChainBrokenError with errors.Is.
+type AuditChainBroken struct {
+	Id     int64
+	Reason string
+}
+
+func (err *AuditChainBroken) Error() string {
+	return fmt.Sprintf("%s at entry %d: %s", AuditChainBrokenError, err.Id, err.Reason)
+}
+
+func (err *AuditChainBroken) Is(target error) bool {
+	return target == AuditChainBrokenError
+}
@@ -0,0 +1,135 @@
+package pwned
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+const filterMagic = "PWNDBLM1"
+
+var InvalidFilterError = errors.New("file is not a password filter")
+
+// Filter is a Bloom filter of password hashes. It only keeps hashes seen at least
+// MinCount times, so CountHash reports MinCount for a hash that is probably in the
+// filter and 0 for one that surely is not.
+//
+// Serialized, a filter is the magic, the number of bits and of hash functions,
+// MinCount and the bit array, all little endian.
+type Filter struct {
+	bits     []uint64
+	m        uint64
+	k        uint32
+	MinCount int
+}
+
+// NewFilter sizes a filter for n hashes with the given false positive rate.
+func NewFilter(n uint64, falsePositiveRate float64, minCount int) *Filter {
+	n = max(n, 1)
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	m = max(64, (m+63)/64*64)
+	k := uint32(max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+
+	return &Filter{
+		bits:     make([]uint64, m/64),
+		m:        m,
+		k:        k,
+		MinCount: minCount,
+	}
+}
+
+func (filter *Filter) Add(hash [sha1.Size]byte) {
+	h1, h2 := filterHashes(hash)
+
+	for i := uint64(0); i < uint64(filter.k); i++ {
+		bit := (h1 + i*h2) % filter.m
+		filter.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (filter *Filter) Contains(hash [sha1.Size]byte) bool {
+	h1, h2 := filterHashes(hash)
+
+	for i := uint64(0); i < uint64(filter.k); i++ {
+		bit := (h1 + i*h2) % filter.m
+		if filter.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (filter *Filter) Count(password string) (int, error) {
+	return filter.CountHash(Hash(password))
+}
+
+func (filter *Filter) CountHash(hash [sha1.Size]byte) (int, error) {
+	if filter.Contains(hash) {
+		return filter.MinCount, nil
+	}
+
+	return 0, nil
+}
+
+func (filter *Filter) WriteTo(w io.Writer) (int64, error) {
+	header := make([]byte, len(filterMagic)+16)
+	copy(header, filterMagic)
+	binary.LittleEndian.PutUint64(header[8:], filter.m)
+	binary.LittleEndian.PutUint32(header[16:], filter.k)
+	binary.LittleEndian.PutUint32(header[20:], uint32(filter.MinCount))
+
+	n, err := w.Write(header)
+	if err != nil {
+		return int64(n), err
+	}
+
+	err = binary.Write(w, binary.LittleEndian, filter.bits)
+	if err != nil {
+		return int64(n), err
+	}
+
+	return int64(n) + int64(len(filter.bits))*8, nil
+}
+
+// ReadFilter reads a filter written by WriteTo that is size bytes long. The bit
+// array must fill the rest of it exactly, so a corrupt header cannot make it
+// allocate more than the file holds.
+func ReadFilter(r io.Reader, size int64) (*Filter, error) {
+	header := make([]byte, len(filterMagic)+16)
+	if size < int64(len(header)) {
+		return nil, InvalidFilterError
+	}
+
+	_, err := io.ReadFull(r, header)
+	if err != nil || string(header[:len(filterMagic)]) != filterMagic {
+		return nil, InvalidFilterError
+	}
+
+	filter := &Filter{
+		m:        binary.LittleEndian.Uint64(header[8:]),
+		k:        binary.LittleEndian.Uint32(header[16:]),
+		MinCount: int(binary.LittleEndian.Uint32(header[20:])),
+	}
+	if filter.m == 0 || filter.m%64 != 0 || filter.m/8 != uint64(size)-uint64(len(header)) || filter.k == 0 {
+		return nil, InvalidFilterError
+	}
+
+	filter.bits = make([]uint64, filter.m/64)
+
+	err = binary.Read(r, binary.LittleEndian, filter.bits)
+	if err != nil {
+		return nil, InvalidFilterError
+	}
+
+	return filter, nil
+}
+
+// filterHashes derives the two hashes of double hashing from the SHA-1 digest,
+// which is uniformly distributed already.
+func filterHashes(hash [sha1.Size]byte) (uint64, uint64) {
+	return binary.BigEndian.Uint64(hash[0:8]), binary.BigEndian.Uint64(hash[8:16]) | 1
+}
@@ -0,0 +1,141 @@
+package pwned
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	const added = 1000
+
+	filter := NewFilter(added, 0.001, 10)
+	for i := 0; i < added; i++ {
+		filter.Add(Hash(fmt.Sprintf("password%d", i)))
+	}
+
+	var serialized bytes.Buffer
+	n, err := filter.WriteTo(&serialized)
+	if err != nil || n != int64(serialized.Len()) {
+		t.Fatalf("WriteTo() = %d, %v, wrote %d bytes", n, err, serialized.Len())
+	}
+
+	read, err := ReadFilter(bytes.NewReader(serialized.Bytes()), int64(serialized.Len()))
+	if err != nil {
+		t.Fatalf("ReadFilter() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "pwned.bloom")
+	if err := os.WriteFile(path, serialized.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, ok := opened.(*Filter); !ok {
+		t.Fatalf("Open() = %T, want *Filter", opened)
+	}
+
+	filters := []struct {
+		name   string
+		source Source
+	}{
+		{"built", filter},
+		{"read", read},
+		{"opened", opened},
+	}
+
+	for _, test := range filters {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < added; i++ {
+				password := fmt.Sprintf("password%d", i)
+				if got, err := test.source.Count(password); err != nil || got != 10 {
+					t.Fatalf("Count(%s) = %d, %v, want 10", password, got, err)
+				}
+			}
+
+			// With 0.1% false positives a handful of these may be reported.
+			falsePositives := 0
+			for i := 0; i < 10000; i++ {
+				got, err := test.source.CountHash(Hash(fmt.Sprintf("other%d", i)))
+				if err != nil {
+					t.Fatalf("CountHash() error = %v", err)
+				}
+				if got != 0 {
+					falsePositives++
+				}
+			}
+			if falsePositives > 50 {
+				t.Errorf("%d of 10000 absent hashes reported, want about 10", falsePositives)
+			}
+		})
+	}
+}
+
+func TestReadFilterInvalid(t *testing.T) {
+	header := func(magic string, m uint64, k uint32) []byte {
+		data := make([]byte, len(filterMagic)+16)
+		copy(data, magic)
+		binary.LittleEndian.PutUint64(data[8:], m)
+		binary.LittleEndian.PutUint32(data[16:], k)
+		binary.LittleEndian.PutUint32(data[20:], 1)
+		return data
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"short header", []byte(filterMagic)},
+		{"wrong magic", append(header("PWNDBLM0", 64, 1), make([]byte, 8)...)},
+		{"no bits", header(filterMagic, 0, 1)},
+		{"partial word", append(header(filterMagic, 65, 1), make([]byte, 16)...)},
+		{"no hash functions", append(header(filterMagic, 64, 0), make([]byte, 8)...)},
+		{"truncated bits", append(header(filterMagic, 128, 1), make([]byte, 8)...)},
+		{"trailing data", append(header(filterMagic, 64, 1), make([]byte, 16)...)},
+		{"huge bit count", append(header(filterMagic, 1<<62, 1), make([]byte, 8)...)},
+		{"bit count wrapping the size", append(header(filterMagic, 1<<63+64, 1), make([]byte, 8)...)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadFilter(bytes.NewReader(test.data), int64(len(test.data))); !errors.Is(err, InvalidFilterError) {
+				t.Errorf("ReadFilter() error = %v, want %v", err, InvalidFilterError)
+			}
+		})
+	}
+}
+
+func TestReadFilterSize(t *testing.T) {
+	var serialized bytes.Buffer
+	if _, err := NewFilter(10, 0.01, 1).WriteTo(&serialized); err != nil {
+		t.Fatal(err)
+	}
+	data := serialized.Bytes()
+
+	tests := []struct {
+		name    string
+		size    int64
+		wantErr error
+	}{
+		{"exact", int64(len(data)), nil},
+		{"smaller", int64(len(data)) - 8, InvalidFilterError},
+		{"larger", int64(len(data)) + 8, InvalidFilterError},
+		{"shorter than the header", 4, InvalidFilterError},
+		{"negative", -1, InvalidFilterError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadFilter(bytes.NewReader(data), test.size); !errors.Is(err, test.wantErr) {
+				t.Errorf("ReadFilter() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
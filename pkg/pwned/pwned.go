@@ -0,0 +1,168 @@
+// Package pwned screens passwords against the SHA-1 password dumps published by
+// HaveIBeenPwned, either the sorted "HASH:COUNT" text list or a Bloom filter built
+// from it with cmd/pwnedfilter.
+package pwned
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+)
+
+var InvalidLineError = errors.New("line is not in HASH:COUNT format")
+
+// Source tells how many times a password was seen in breaches.
+type Source interface {
+	Count(password string) (int, error)
+	CountHash(hash [sha1.Size]byte) (int, error)
+}
+
+func Hash(password string) [sha1.Size]byte {
+	return sha1.Sum([]byte(password))
+}
+
+// Open opens a Bloom filter written by Filter.WriteTo or, failing that, a hash list.
+func Open(path string) (Source, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	magic := make([]byte, len(filterMagic))
+	_, err = io.ReadFull(file, magic)
+	if err == nil && string(magic) == filterMagic {
+		defer file.Close()
+
+		stat, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = file.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+
+		return ReadFilter(bufio.NewReader(file), stat.Size())
+	}
+
+	return newHashList(file)
+}
+
+// ParseLine parses a "HASH:COUNT" line of a HaveIBeenPwned SHA-1 list.
+func ParseLine(line []byte) ([sha1.Size]byte, int, error) {
+	var hash [sha1.Size]byte
+
+	hexHash, countText, found := bytes.Cut(bytes.TrimRight(line, "\r\n"), []byte(":"))
+	if !found || hex.DecodedLen(len(hexHash)) != sha1.Size {
+		return hash, 0, InvalidLineError
+	}
+
+	_, err := hex.Decode(hash[:], hexHash)
+	if err != nil {
+		return hash, 0, InvalidLineError
+	}
+
+	count, err := strconv.Atoi(string(countText))
+	if err != nil {
+		return hash, 0, InvalidLineError
+	}
+
+	return hash, count, nil
+}
+
+// HashList looks hashes up in a HaveIBeenPwned SHA-1 list ordered by hash with a
+// binary search over the file, so the list is never loaded into memory.
+type HashList struct {
+	file *os.File
+	size int64
+}
+
+func newHashList(file *os.File) (*HashList, error) {
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &HashList{file: file, size: stat.Size()}, nil
+}
+
+func (list *HashList) Count(password string) (int, error) {
+	return list.CountHash(Hash(password))
+}
+
+func (list *HashList) CountHash(hash [sha1.Size]byte) (int, error) {
+	lo, hi := int64(0), list.size
+
+	// Every line starting before lo is lower than hash, every line starting at hi or later is greater.
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, next, line, err := list.lineFrom(mid)
+		if err != nil {
+			return 0, err
+		}
+		if line == nil || start >= hi {
+			hi = mid
+			continue
+		}
+
+		lineHash, count, err := ParseLine(line)
+		if err != nil {
+			return 0, err
+		}
+
+		switch bytes.Compare(lineHash[:], hash[:]) {
+		case 0:
+			return count, nil
+		case -1:
+			lo = next
+		default:
+			hi = mid
+		}
+	}
+
+	return 0, nil
+}
+
+func (list *HashList) Close() error {
+	return list.file.Close()
+}
+
+// lineFrom returns the first line starting at offset or later, its start and the
+// start of the line after it. line is nil when no line starts there.
+func (list *HashList) lineFrom(offset int64) (int64, int64, []byte, error) {
+	start := offset
+	if offset > 0 {
+		start--
+	}
+
+	reader := bufio.NewReaderSize(io.NewSectionReader(list.file, start, list.size-start), 128)
+
+	if offset > 0 {
+		skipped, err := reader.ReadSlice('\n')
+		if err == io.EOF {
+			return 0, 0, nil, nil
+		}
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		start += int64(len(skipped))
+	}
+
+	line, err := reader.ReadSlice('\n')
+	if err == io.EOF && len(line) == 0 {
+		return 0, 0, nil, nil
+	}
+	if err != nil && err != io.EOF {
+		return 0, 0, nil, err
+	}
+
+	return start, start + int64(len(line)), line, nil
+}
@@ -0,0 +1,210 @@
+package pwned
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type listEntry struct {
+	hash  [sha1.Size]byte
+	count int
+}
+
+// testEntries returns the hashes of password0 to password<n-1> in list order,
+// password<i> seen i+1 times.
+func testEntries(n int) []listEntry {
+	entries := make([]listEntry, 0, n)
+	for i := 0; i < n; i++ {
+		entries = append(entries, listEntry{Hash(fmt.Sprintf("password%d", i)), i + 1})
+	}
+	slices.SortFunc(entries, func(a, b listEntry) int { return bytes.Compare(a.hash[:], b.hash[:]) })
+
+	return entries
+}
+
+// writeList writes entries as a HaveIBeenPwned list with the given line ending,
+// leaving it off the last line when trailing is false, and returns its path.
+func writeList(t *testing.T, entries []listEntry, ending string, trailing bool) string {
+	t.Helper()
+
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(entry.hash[:])), entry.count))
+	}
+	content := strings.Join(lines, ending)
+	if trailing && len(lines) > 0 {
+		content += ending
+	}
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestParseLine(t *testing.T) {
+	hash := Hash("password")
+	hexHash := strings.ToUpper(hex.EncodeToString(hash[:]))
+
+	tests := []struct {
+		name    string
+		line    string
+		want    int
+		wantErr error
+	}{
+		{"plain", hexHash + ":42", 42, nil},
+		{"newline", hexHash + ":42\n", 42, nil},
+		{"crlf", hexHash + ":42\r\n", 42, nil},
+		{"lower case", strings.ToLower(hexHash) + ":7", 7, nil},
+		{"no count", hexHash, 0, InvalidLineError},
+		{"empty count", hexHash + ":", 0, InvalidLineError},
+		{"bad count", hexHash + ":many", 0, InvalidLineError},
+		{"short hash", hexHash[:38] + ":1", 0, InvalidLineError},
+		{"long hash", hexHash + "00:1", 0, InvalidLineError},
+		{"not hex", "Z" + hexHash[1:] + ":1", 0, InvalidLineError},
+		{"empty", "", 0, InvalidLineError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, count, err := ParseLine([]byte(test.line))
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("ParseLine() error = %v, want %v", err, test.wantErr)
+			}
+			if err == nil && (got != hash || count != test.want) {
+				t.Errorf("ParseLine() = %x, %d, want %x, %d", got, count, hash, test.want)
+			}
+		})
+	}
+}
+
+func TestHashList(t *testing.T) {
+	entries := testEntries(200)
+
+	var first, last [sha1.Size]byte
+	for i := range last {
+		last[i] = 0xFF
+	}
+
+	lists := []struct {
+		name     string
+		ending   string
+		trailing bool
+	}{
+		{"lf", "\n", true},
+		{"crlf", "\r\n", true},
+		{"no final newline", "\n", false},
+	}
+
+	for _, list := range lists {
+		t.Run(list.name, func(t *testing.T) {
+			source, err := Open(writeList(t, entries, list.ending, list.trailing))
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			hashList, ok := source.(*HashList)
+			if !ok {
+				t.Fatalf("Open() = %T, want *HashList", source)
+			}
+			defer hashList.Close()
+
+			tests := []struct {
+				name string
+				hash [sha1.Size]byte
+				want int
+			}{
+				{"first", entries[0].hash, entries[0].count},
+				{"second", entries[1].hash, entries[1].count},
+				{"middle", entries[100].hash, entries[100].count},
+				{"last", entries[199].hash, entries[199].count},
+				{"before first", first, 0},
+				{"after last", last, 0},
+				{"absent", Hash("not in the list"), 0},
+			}
+
+			for _, test := range tests {
+				got, err := hashList.CountHash(test.hash)
+				if err != nil || got != test.want {
+					t.Errorf("CountHash(%s) = %d, %v, want %d", test.name, got, err, test.want)
+				}
+			}
+
+			for _, entry := range entries {
+				got, err := hashList.CountHash(entry.hash)
+				if err != nil || got != entry.count {
+					t.Fatalf("CountHash(%x) = %d, %v, want %d", entry.hash, got, err, entry.count)
+				}
+			}
+
+			got, err := hashList.Count("password7")
+			if err != nil || got != 8 {
+				t.Errorf("Count(password7) = %d, %v, want 8", got, err)
+			}
+		})
+	}
+}
+
+func TestHashListSmall(t *testing.T) {
+	entries := testEntries(3)
+
+	tests := []struct {
+		name    string
+		entries []listEntry
+		hash    [sha1.Size]byte
+		want    int
+	}{
+		{"empty list", nil, entries[0].hash, 0},
+		{"single line", entries[:1], entries[0].hash, entries[0].count},
+		{"single line absent", entries[:1], entries[1].hash, 0},
+		{"two lines", entries[:2], entries[1].hash, entries[1].count},
+		{"three lines", entries, entries[2].hash, entries[2].count},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source, err := Open(writeList(t, test.entries, "\n", true))
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer source.(*HashList).Close()
+
+			got, err := source.CountHash(test.hash)
+			if err != nil || got != test.want {
+				t.Errorf("CountHash() = %d, %v, want %d", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestHashListInvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte("not a hash list\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer source.(*HashList).Close()
+
+	if _, err := source.Count("password"); !errors.Is(err, InvalidLineError) {
+		t.Errorf("Count() error = %v, want %v", err, InvalidLineError)
+	}
+}
+
+func TestOpenMissing(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Open() error = %v, want %v", err, os.ErrNotExist)
+	}
+}